@@ -0,0 +1,234 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	cfgFormatFlag = &cli.StringFlag{
+		Name:  "format",
+		Usage: "Output format of the control-flow graph (dot|json)",
+		Value: "dot",
+	}
+	eofCfgCommand = &cli.Command{
+		Name:   "eofcfg",
+		Usage:  "Validates hex eof container and prints the control-flow graph of its code sections.",
+		Action: eofCfgAction,
+		Flags: []cli.Flag{
+			hexFlag,
+			initcodeFlag,
+			cfgFormatFlag,
+		},
+	}
+)
+
+func eofCfgAction(ctx *cli.Context) error {
+	format := ctx.String(cfgFormatFlag.Name)
+	if format != "dot" && format != "json" {
+		return fmt.Errorf("unknown format %q", format)
+	}
+	isInitCode := ctx.Bool(initcodeFlag.Name)
+	// If `--hex` is set, use the hex string argument.
+	if ctx.IsSet(hexFlag.Name) {
+		return eofCfg(os.Stdout, ctx.String(hexFlag.Name), isInitCode, format)
+	}
+	// Otherwise read from stdin
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 1024*1024), 10*1024*1024)
+	for scanner.Scan() {
+		l := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(l, "#") || l == "" {
+			continue
+		}
+		if err := eofCfg(os.Stdout, l, isInitCode, format); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func eofCfg(out io.Writer, hexdata string, isInitCode bool, format string) error {
+	if len(hexdata) >= 2 && strings.HasPrefix(hexdata, "0x") {
+		hexdata = hexdata[2:]
+	}
+	b, err := hex.DecodeString(hexdata)
+	if err != nil {
+		return fmt.Errorf("unable to decode data: %w", err)
+	}
+	var c vm.Container
+	if err := c.UnmarshalBinary(b, isInitCode); err != nil {
+		return err
+	}
+	graph, err := c.ControlFlowGraph(&jt, isInitCode)
+	if err != nil {
+		return err
+	}
+	if format == "json" {
+		enc, err := json.Marshal(newCfgContainer(graph))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, string(enc))
+		return nil
+	}
+	fmt.Fprintln(out, "digraph eof {")
+	fmt.Fprintln(out, "\tnode [shape=box, fontname=\"monospace\"];")
+	writeDot(out, graph, "c", "")
+	fmt.Fprintln(out, "}")
+	return nil
+}
+
+// writeDot renders the graph of a container in graphviz format. Every section
+// is drawn as a cluster, with calls between sections drawn as dashed edges.
+// The prefix makes node names unique across containers, the name is used to
+// label nested containers.
+func writeDot(out io.Writer, graph *vm.ContainerGraph, prefix, name string) {
+	node := func(section, block int) string {
+		return fmt.Sprintf("%s_s%d_b%d", prefix, section, block)
+	}
+	for _, section := range graph.Sections {
+		outputs := fmt.Sprint(section.Outputs)
+		if section.Outputs == 0x80 {
+			outputs = "non-returning"
+		}
+		fmt.Fprintf(out, "\tsubgraph cluster_%s_s%d {\n", prefix, section.Section)
+		fmt.Fprintf(out, "\t\tlabel=\"%ssection %d (inputs=%d, outputs=%s, max_stack=%d)\";\n",
+			name, section.Section, section.Inputs, outputs, section.MaxStackHeight)
+		for i, block := range section.Blocks {
+			var label strings.Builder
+			fmt.Fprintf(&label, "block %d, stack [%d, %d]\\l", i, block.StackMin(), block.StackMax())
+			for _, in := range block.Instructions {
+				if len(in.Immediate) > 0 {
+					fmt.Fprintf(&label, "%04x: %v %#x\\l", in.Pos, in.Op, in.Immediate)
+				} else {
+					fmt.Fprintf(&label, "%04x: %v\\l", in.Pos, in.Op)
+				}
+			}
+			fmt.Fprintf(out, "\t\t%s [label=\"%s\"];\n", node(section.Section, i), label.String())
+		}
+		fmt.Fprintln(out, "\t}")
+		for _, edge := range section.Edges {
+			fmt.Fprintf(out, "\t%s -> %s [label=\"%s\"];\n", node(section.Section, edge.From), node(section.Section, edge.To), edge.Kind)
+		}
+		for _, call := range section.Calls {
+			fmt.Fprintf(out, "\t%s -> %s [label=\"%v\", style=dashed];\n", node(section.Section, call.Block), node(call.Section, 0), call.Op)
+		}
+	}
+	for i, sub := range graph.SubContainers {
+		writeDot(out, sub, fmt.Sprintf("%s_%d", prefix, i), fmt.Sprintf("%scontainer %d, ", name, i))
+	}
+}
+
+// The types below define the JSON representation of the control-flow graph.
+
+type cfgContainer struct {
+	Sections      []*cfgSection   `json:"sections"`
+	SubContainers []*cfgContainer `json:"subContainers,omitempty"`
+}
+
+type cfgSection struct {
+	Section        int            `json:"section"`
+	Inputs         uint8          `json:"inputs"`
+	Outputs        uint8          `json:"outputs"`
+	MaxStackHeight uint16         `json:"maxStackHeight"`
+	Blocks         []*cfgBlock    `json:"blocks"`
+	Edges          []*cfgEdge     `json:"edges"`
+	Calls          []*cfgCallEdge `json:"calls,omitempty"`
+}
+
+type cfgBlock struct {
+	Start        int               `json:"start"`
+	End          int               `json:"end"`
+	StackMin     int               `json:"stackMin"`
+	StackMax     int               `json:"stackMax"`
+	Instructions []*cfgInstruction `json:"instructions"`
+}
+
+type cfgInstruction struct {
+	Pos       int           `json:"pos"`
+	Op        string        `json:"op"`
+	Immediate hexutil.Bytes `json:"immediate,omitempty"`
+	StackMin  int           `json:"stackMin"`
+	StackMax  int           `json:"stackMax"`
+}
+
+type cfgEdge struct {
+	From int    `json:"from"`
+	To   int    `json:"to"`
+	Kind string `json:"kind"`
+}
+
+type cfgCallEdge struct {
+	Block   int    `json:"block"`
+	Pos     int    `json:"pos"`
+	Op      string `json:"op"`
+	Section int    `json:"section"`
+}
+
+func newCfgContainer(graph *vm.ContainerGraph) *cfgContainer {
+	c := new(cfgContainer)
+	for _, section := range graph.Sections {
+		s := &cfgSection{
+			Section:        section.Section,
+			Inputs:         section.Inputs,
+			Outputs:        section.Outputs,
+			MaxStackHeight: section.MaxStackHeight,
+			Edges:          make([]*cfgEdge, 0, len(section.Edges)),
+		}
+		for _, block := range section.Blocks {
+			b := &cfgBlock{
+				Start:    block.Start,
+				End:      block.End,
+				StackMin: block.StackMin(),
+				StackMax: block.StackMax(),
+			}
+			for _, in := range block.Instructions {
+				b.Instructions = append(b.Instructions, &cfgInstruction{
+					Pos:       in.Pos,
+					Op:        in.Op.String(),
+					Immediate: in.Immediate,
+					StackMin:  in.StackMin,
+					StackMax:  in.StackMax,
+				})
+			}
+			s.Blocks = append(s.Blocks, b)
+		}
+		for _, edge := range section.Edges {
+			s.Edges = append(s.Edges, &cfgEdge{From: edge.From, To: edge.To, Kind: string(edge.Kind)})
+		}
+		for _, call := range section.Calls {
+			s.Calls = append(s.Calls, &cfgCallEdge{Block: call.Block, Pos: call.Pos, Op: call.Op.String(), Section: call.Section})
+		}
+		c.Sections = append(c.Sections, s)
+	}
+	for _, sub := range graph.SubContainers {
+		c.SubContainers = append(c.SubContainers, newCfgContainer(sub))
+	}
+	return c
+}
@@ -20,12 +20,15 @@ import (
 	"bufio"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/ethereum/go-ethereum/core/asm"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/urfave/cli/v2"
@@ -48,6 +51,18 @@ var (
 		Name:  "test",
 		Usage: "Path to EOF validation reference test.",
 	}
+	initcodeFlag = &cli.BoolFlag{
+		Name:  "initcode",
+		Usage: "Validate the container as initcode",
+	}
+	diagnoseFlag = &cli.BoolFlag{
+		Name:  "diagnose",
+		Usage: "Report the location, stack heights and violated rule of validation failures",
+	}
+	diagnoseJSONFlag = &cli.BoolFlag{
+		Name:  "json",
+		Usage: "Output diagnostics as JSON (requires --diagnose)",
+	}
 	eofParseCommand = &cli.Command{
 		Name:    "eofparse",
 		Aliases: []string{"eof"},
@@ -56,6 +71,9 @@ var (
 		Flags: []cli.Flag{
 			hexFlag,
 			refTestFlag,
+			initcodeFlag,
+			diagnoseFlag,
+			diagnoseJSONFlag,
 		},
 	}
 	eofDumpCommand = &cli.Command{
@@ -66,6 +84,20 @@ var (
 			hexFlag,
 		},
 	}
+	eofDisasmCommand = &cli.Command{
+		Name:   "eofdisasm",
+		Usage:  "Disassembles hex eof container into textual assembly which can be reassembled with eofasm.",
+		Action: eofDisasmAction,
+		Flags: []cli.Flag{
+			hexFlag,
+		},
+	}
+	eofAsmCommand = &cli.Command{
+		Name:      "eofasm",
+		Usage:     "Assembles textual eof assembly into a hex eof container.",
+		ArgsUsage: "<file>",
+		Action:    eofAsmAction,
+	}
 )
 
 func eofParseAction(ctx *cli.Context) error {
@@ -95,9 +127,14 @@ func eofParseAction(ctx *cli.Context) error {
 		log.Info("Executed tests", "passed", passedTests, "total executed", executedTests)
 		return nil
 	}
+	isInitCode := ctx.Bool(initcodeFlag.Name)
+	// If `--diagnose` is set, report details about validation failures.
+	if ctx.Bool(diagnoseFlag.Name) {
+		return eofDiagnoseAction(ctx, isInitCode)
+	}
 	// If `--hex` is set, parse and validate the hex string argument.
 	if ctx.IsSet(hexFlag.Name) {
-		if _, err := parseAndValidate(ctx.String(hexFlag.Name), false); err != nil {
+		if _, err := parseAndValidate(ctx.String(hexFlag.Name), isInitCode); err != nil {
 			return fmt.Errorf("err: %w", err)
 		}
 		fmt.Println("OK")
@@ -111,7 +148,7 @@ func eofParseAction(ctx *cli.Context) error {
 		if strings.HasPrefix(l, "#") || l == "" {
 			continue
 		}
-		if _, err := parseAndValidate(l, false); err != nil {
+		if _, err := parseAndValidate(l, isInitCode); err != nil {
 			fmt.Printf("err: %v\n", err)
 		} else {
 			fmt.Println("OK")
@@ -190,6 +227,112 @@ func parse(b []byte, isInitCode bool) (*vm.Container, error) {
 	return &c, nil
 }
 
+// eofDiagnostic describes the outcome of validating a single container.
+type eofDiagnostic struct {
+	Valid     bool   `json:"valid"`
+	Error     string `json:"error,omitempty"`
+	Rule      string `json:"rule,omitempty"`
+	Container []int  `json:"container,omitempty"` // path of subcontainer indices
+	Section   *int   `json:"section,omitempty"`
+	Offset    *int   `json:"offset,omitempty"`
+	Opcode    string `json:"opcode,omitempty"`
+	StackMin  *int   `json:"stackMin,omitempty"`
+	StackMax  *int   `json:"stackMax,omitempty"`
+}
+
+// diagnose parses and validates the hex encoded container, and collects the
+// details about a validation failure, if any.
+func diagnose(s string, isInitCode bool) *eofDiagnostic {
+	_, err := parseAndValidate(s, isInitCode)
+	if err == nil {
+		return &eofDiagnostic{Valid: true}
+	}
+	diag := &eofDiagnostic{Error: err.Error()}
+	var verr *vm.ValidationError
+	if !errors.As(err, &verr) {
+		// Failures while decoding the header carry no location.
+		rule := err
+		for inner := errors.Unwrap(rule); inner != nil; inner = errors.Unwrap(rule) {
+			rule = inner
+		}
+		diag.Rule = rule.Error()
+		return diag
+	}
+	diag.Rule = verr.Rule().Error()
+	diag.Container = verr.Path
+	if verr.Section >= 0 {
+		diag.Section = &verr.Section
+	}
+	if verr.Pos >= 0 {
+		diag.Offset = &verr.Pos
+		diag.Opcode = verr.Op.String()
+	}
+	if verr.StackMin >= 0 {
+		diag.StackMin, diag.StackMax = &verr.StackMin, &verr.StackMax
+	}
+	return diag
+}
+
+// String returns a human-readable representation of the diagnostic.
+func (d *eofDiagnostic) String() string {
+	if d.Valid {
+		return "OK"
+	}
+	var (
+		out       = []string{fmt.Sprintf("err: %v", d.Error), fmt.Sprintf("  rule:      %v", d.Rule)}
+		container = "top-level"
+	)
+	if len(d.Container) > 0 {
+		path := make([]string, len(d.Container))
+		for i, idx := range d.Container {
+			path[i] = fmt.Sprint(idx)
+		}
+		container = "subcontainer " + strings.Join(path, "/")
+	}
+	out = append(out, fmt.Sprintf("  container: %v", container))
+	if d.Section != nil {
+		out = append(out, fmt.Sprintf("  section:   %d", *d.Section))
+	}
+	if d.Offset != nil {
+		out = append(out, fmt.Sprintf("  offset:    %d (%#04x)", *d.Offset, *d.Offset))
+		out = append(out, fmt.Sprintf("  opcode:    %v", d.Opcode))
+	}
+	if d.StackMin != nil {
+		out = append(out, fmt.Sprintf("  stack:     [%d, %d]", *d.StackMin, *d.StackMax))
+	}
+	return strings.Join(out, "\n")
+}
+
+func eofDiagnoseAction(ctx *cli.Context, isInitCode bool) error {
+	report := func(diag *eofDiagnostic) error {
+		if !ctx.Bool(diagnoseJSONFlag.Name) {
+			fmt.Println(diag)
+			return nil
+		}
+		out, err := json.Marshal(diag)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+	if ctx.IsSet(hexFlag.Name) {
+		return report(diagnose(ctx.String(hexFlag.Name), isInitCode))
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 1024*1024), 10*1024*1024)
+	for scanner.Scan() {
+		l := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(l, "#") || l == "" {
+			continue
+		}
+		if err := report(diagnose(l, isInitCode)); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
 func eofDumpAction(ctx *cli.Context) error {
 	// If `--hex` is set, parse and validate the hex string argument.
 	if ctx.IsSet(hexFlag.Name) {
@@ -226,3 +369,62 @@ func eofDump(hexdata string) error {
 	fmt.Println(c.String())
 	return nil
 }
+
+func eofDisasmAction(ctx *cli.Context) error {
+	// If `--hex` is set, disassemble the hex string argument.
+	if ctx.IsSet(hexFlag.Name) {
+		return eofDisasm(ctx.String(hexFlag.Name))
+	}
+	// Otherwise read from stdin
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 1024*1024), 10*1024*1024)
+	for scanner.Scan() {
+		l := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(l, "#") || l == "" {
+			continue
+		}
+		if err := eofDisasm(l); err != nil {
+			return err
+		}
+		fmt.Println("")
+	}
+	return scanner.Err()
+}
+
+func eofDisasm(hexdata string) error {
+	if len(hexdata) >= 2 && strings.HasPrefix(hexdata, "0x") {
+		hexdata = hexdata[2:]
+	}
+	b, err := hex.DecodeString(hexdata)
+	if err != nil {
+		return fmt.Errorf("unable to decode data: %w", err)
+	}
+	var c vm.Container
+	if err := c.UnmarshalBinary(b, false); err != nil {
+		return err
+	}
+	fmt.Print(asm.DisassembleEOF(&c))
+	return nil
+}
+
+func eofAsmAction(ctx *cli.Context) error {
+	var (
+		src []byte
+		err error
+	)
+	// Read the source from the file argument, or from stdin if none is given.
+	if ctx.Args().Len() > 0 {
+		src, err = os.ReadFile(ctx.Args().First())
+	} else {
+		src, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		return err
+	}
+	c, err := asm.AssembleEOF(string(src))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%x\n", c.MarshalBinary())
+	return nil
+}
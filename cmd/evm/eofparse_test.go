@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
@@ -164,3 +165,40 @@ func BenchmarkEofParse(b *testing.B) {
 		line++
 	}
 }
+
+func TestEofDiagnose(t *testing.T) {
+	for i, tc := range []struct {
+		code       string
+		isInitCode bool
+		want       string
+	}{
+		{
+			code: "ef0001010004020001000d04000000008000025fe100055f5fe000035f600100",
+			want: `{"valid":true}`,
+		},
+		{
+			code: "ef000101000402000100020400000000800000",
+			want: `{"valid":false,"error":"invalid container size: have 19, want 21","rule":"invalid container size"}`,
+		},
+		{ // ADD on an empty stack
+			code: "ef0001010004020001000204000000008000000100",
+			want: `{"valid":false,"error":"stack underflow (0 \u003c=\u003e 2): at pos 0","rule":"stack underflow","section":0,"offset":0,"opcode":"ADD","stackMin":0,"stackMax":0}`,
+		},
+		{ // RETURNCONTRACT in runtime code
+			code: "ef0001010004020001000603000100140400000000800002" + "5f5fee00" + "0000" + "ef000101000402000100010400000000800000fe",
+			want: `{"valid":false,"error":"incompatible container kind","rule":"incompatible container kind","section":0,"offset":2,"opcode":"RETURNCONTRACT"}`,
+		},
+		{ // STOP in a subcontainer referenced by EOFCREATE
+			code: "ef0001010004020001000803000100150400000000800004" + "5f5f5f5fec005000" + "ef0001010004020001000204000000008000000100",
+			want: `{"valid":false,"error":"initcode contains a RETURN or STOP opcode","rule":"initcode contains a RETURN or STOP opcode","container":[0],"section":0,"offset":1,"opcode":"STOP"}`,
+		},
+	} {
+		have, err := json.Marshal(diagnose(tc.code, tc.isInitCode))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(have) != tc.want {
+			t.Errorf("test %d: wrong diagnostic\nhave: %s\nwant: %s", i, have, tc.want)
+		}
+	}
+}
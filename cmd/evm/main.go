@@ -212,6 +212,9 @@ func init() {
 		blockBuilderCommand,
 		eofParseCommand,
 		eofDumpCommand,
+		eofDisasmCommand,
+		eofAsmCommand,
+		eofCfgCommand,
 	}
 	app.Before = func(ctx *cli.Context) error {
 		flags.MigrateGlobalFlags(ctx)
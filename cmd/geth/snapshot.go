@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"slices"
 	"time"
 
@@ -148,6 +149,44 @@ as the backend data source, making this command a lot faster.
 
 The argument is interpreted as block number or hash. If none is provided, the latest
 block is used.
+`,
+			},
+			{
+				Name:      "export",
+				Usage:     "Export the state into checksummed chunk files",
+				ArgsUsage: "<dir> [<root>]",
+				Action:    exportSnapshot,
+				Flags: slices.Concat([]cli.Flag{
+					utils.SnapshotChunkSizeFlag,
+				}, utils.NetworkFlags, utils.DatabaseFlags),
+				Description: `
+geth snapshot export <dir> [<state-root>]
+will export the flat state of the snapshot, including all accounts, storage
+slots and contract codes, into chunk files within a sub-directory of the given
+directory named by the state root. Each chunk is listed with its checksum in
+the manifest. If the export is interrupted, running the command again resumes
+it from the last written chunk.
+
+The default exporting target is the HEAD state.
+`,
+			},
+			{
+				Name:      "import",
+				Usage:     "Import the state from exported chunk files",
+				ArgsUsage: "<dir>",
+				Action:    importSnapshot,
+				Flags:     slices.Concat(utils.NetworkFlags, utils.DatabaseFlags),
+				Description: `
+geth snapshot import <dir>
+will import the state exported by 'geth snapshot export' from the given
+directory, which contains the manifest. The checksum of each chunk is verified,
+the existing snapshot in the database is replaced, and the state trie is
+regenerated and verified against the exported state root. If the import is
+interrupted, running the command again resumes it from the last imported chunk.
+In path scheme, the state can only be imported into a database without state.
+
+The imported state becomes usable once the chain containing the corresponding
+block is available in the database.
 `,
 			},
 			{
@@ -619,6 +658,80 @@ func dumpState(ctx *cli.Context) error {
 	return nil
 }
 
+// exportSnapshot exports the flat state of the snapshot into chunk files.
+func exportSnapshot(ctx *cli.Context) error {
+	if ctx.NArg() < 1 || ctx.NArg() > 2 {
+		return errors.New("need <dir> [<root>] args")
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chaindb := utils.MakeChainDatabase(ctx, stack, true)
+	defer chaindb.Close()
+
+	headBlock := rawdb.ReadHeadBlock(chaindb)
+	if headBlock == nil {
+		log.Error("Failed to load head block")
+		return errors.New("no head block")
+	}
+	root := headBlock.Root()
+	if ctx.NArg() == 2 {
+		var err error
+		root, err = parseRoot(ctx.Args().Get(1))
+		if err != nil {
+			log.Error("Failed to resolve state root", "err", err)
+			return err
+		}
+	}
+	triedb := utils.MakeTrieDatabase(ctx, chaindb, false, true, false)
+	defer triedb.Close()
+
+	snapConfig := snapshot.Config{
+		CacheSize:  256,
+		Recovery:   false,
+		NoBuild:    true,
+		AsyncBuild: false,
+	}
+	snaptree, err := snapshot.New(snapConfig, chaindb, triedb, headBlock.Root())
+	if err != nil {
+		log.Error("Failed to open snapshot tree", "err", err)
+		return err
+	}
+	dir := filepath.Join(ctx.Args().First(), root.Hex())
+	chunkSize := ctx.Int(utils.SnapshotChunkSizeFlag.Name) * 1024 * 1024
+	if err := snapshot.Export(snaptree, root, chaindb, dir, chunkSize); err != nil {
+		log.Error("Failed to export state", "root", root, "err", err)
+		return err
+	}
+	log.Info("Exported the state", "root", root, "dir", dir)
+	return nil
+}
+
+// importSnapshot imports the state from exported chunk files, regenerating
+// the state trie.
+func importSnapshot(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return errors.New("need <dir> arg")
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chaindb := utils.MakeChainDatabase(ctx, stack, false)
+	defer chaindb.Close()
+
+	scheme, err := rawdb.ParseStateScheme(ctx.String(utils.StateSchemeFlag.Name), chaindb)
+	if err != nil {
+		return err
+	}
+	root, err := snapshot.Import(chaindb, scheme, ctx.Args().First())
+	if err != nil {
+		log.Error("Failed to import state", "err", err)
+		return err
+	}
+	log.Info("Imported the state", "root", root)
+	return nil
+}
+
 // snapshotExportPreimages dumps the preimage data to a flat file.
 func snapshotExportPreimages(ctx *cli.Context) error {
 	if ctx.NArg() < 1 {
@@ -0,0 +1,186 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/stateless"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	statelessBlockFlag = &cli.StringFlag{
+		Name:  "block",
+		Usage: "Path to the RLP-encoded block to verify",
+	}
+	statelessWitnessFlag = &cli.StringFlag{
+		Name:  "witness",
+		Usage: "Path to the RLP-encoded execution witness of the block",
+	}
+	statelessGenesisFlag = &cli.StringFlag{
+		Name:  "genesis",
+		Usage: "Path to the genesis file of a custom network, defining the chain config",
+	}
+
+	statelessCommand = &cli.Command{
+		Name:  "stateless",
+		Usage: "A set of commands for stateless block execution",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "verify",
+				Usage:  "Verify a block against its execution witness",
+				Action: verifyStateless,
+				Flags: slices.Concat([]cli.Flag{
+					statelessBlockFlag,
+					statelessWitnessFlag,
+					statelessGenesisFlag,
+				}, utils.NetworkFlags),
+				Description: `
+geth stateless verify --block block.rlp --witness witness.rlp
+
+This command executes the block on top of the pre-state contained in the
+witness only, without any chain database. The computed state and receipt roots
+are checked against the block header, and the trie nodes and contract codes
+accessed during the execution but absent in the witness are reported.
+
+The chain config is selected by the network flags (mainnet by default), or
+loaded from the genesis file of a custom network.
+`,
+			},
+			{
+				Name:      "inspect",
+				Usage:     "Inspect the size and composition of execution witnesses",
+				ArgsUsage: "<witness.rlp> [<witness.rlp> ...]",
+				Action:    inspectWitness,
+				Description: `
+geth stateless inspect <witness.rlp> [<witness.rlp> ...]
+
+This command decodes the RLP-encoded execution witnesses and prints their
+statistics as JSON, one witness per line: the number and size of the headers,
+codes and trie nodes, with the trie nodes split into the account and storage
+tries and grouped by their depth.
+`,
+			},
+		},
+	}
+)
+
+// verifyStateless executes a block against its witness file.
+func verifyStateless(ctx *cli.Context) error {
+	if !ctx.IsSet(statelessBlockFlag.Name) || !ctx.IsSet(statelessWitnessFlag.Name) {
+		return errors.New("both --block and --witness are required")
+	}
+	config, err := statelessChainConfig(ctx)
+	if err != nil {
+		return err
+	}
+	block := new(types.Block)
+	if err := decodeRLPFile(ctx.String(statelessBlockFlag.Name), block); err != nil {
+		return fmt.Errorf("invalid block: %w", err)
+	}
+	witness := new(stateless.Witness)
+	if err := decodeRLPFile(ctx.String(statelessWitnessFlag.Name), witness); err != nil {
+		return fmt.Errorf("invalid witness: %w", err)
+	}
+	log.Info("Verifying block statelessly", "number", block.Number(), "hash", block.Hash(),
+		"headers", len(witness.Headers), "codes", len(witness.Codes), "nodes", len(witness.State))
+
+	start := time.Now()
+	res, err := core.VerifyStateless(config, vm.Config{}, block, witness)
+	if res != nil {
+		for _, hash := range res.MissingNodes {
+			log.Warn("Trie node missing in witness", "hash", hash)
+		}
+		for _, hash := range res.MissingCodes {
+			log.Warn("Contract code missing in witness", "hash", hash)
+		}
+		log.Info("Executed block statelessly", "stateRoot", res.StateRoot, "receiptRoot", res.ReceiptRoot,
+			"gasUsed", res.GasUsed, "elapsed", common.PrettyDuration(time.Since(start)))
+	}
+	if err != nil {
+		log.Error("Stateless verification failed", "err", err)
+		return err
+	}
+	log.Info("Block verified statelessly", "number", block.Number(), "hash", block.Hash())
+	return nil
+}
+
+// inspectWitness prints the statistics of the given witness files.
+func inspectWitness(ctx *cli.Context) error {
+	if ctx.NArg() == 0 {
+		return errors.New("witness file required")
+	}
+	enc := json.NewEncoder(os.Stdout)
+	for _, path := range ctx.Args().Slice() {
+		witness := new(stateless.Witness)
+		if err := decodeRLPFile(path, witness); err != nil {
+			return fmt.Errorf("invalid witness %s: %w", path, err)
+		}
+		if err := enc.Encode(witness.Stats()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// statelessChainConfig returns the chain config from the custom genesis file,
+// or the one of the network selected by the flags.
+func statelessChainConfig(ctx *cli.Context) (*params.ChainConfig, error) {
+	if path := ctx.String(statelessGenesisFlag.Name); path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		genesis := new(core.Genesis)
+		if err := json.NewDecoder(file).Decode(genesis); err != nil {
+			return nil, fmt.Errorf("invalid genesis file: %w", err)
+		}
+		if genesis.Config == nil {
+			return nil, errors.New("genesis file has no chain config")
+		}
+		return genesis.Config, nil
+	}
+	if genesis := utils.MakeGenesis(ctx); genesis != nil {
+		return genesis.Config, nil
+	}
+	return params.MainnetChainConfig, nil
+}
+
+// decodeRLPFile decodes the RLP content of the given file into val.
+func decodeRLPFile(path string, val interface{}) error {
+	blob, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return rlp.DecodeBytes(blob, val)
+}
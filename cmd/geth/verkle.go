@@ -23,11 +23,21 @@ import (
 	"fmt"
 	"os"
 	"slices"
+	"time"
 
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/trie/trienode"
+	trieutils "github.com/ethereum/go-ethereum/trie/utils"
+	"github.com/ethereum/go-ethereum/triedb"
 	"github.com/ethereum/go-verkle"
 	"github.com/urfave/cli/v2"
 )
@@ -35,6 +45,12 @@ import (
 var (
 	zero [32]byte
 
+	verkleFlushFlag = &cli.IntFlag{
+		Name:  "flush",
+		Usage: "Number of tree insertions between flushing the converted tree to disk",
+		Value: 1_000_000,
+	}
+
 	verkleCommand = &cli.Command{
 		Name:        "verkle",
 		Usage:       "A set of experimental verkle tree management commands",
@@ -49,6 +65,26 @@ var (
 				Description: `
 geth verkle verify <state-root>
 This command takes a root commitment and attempts to rebuild the tree.
+ `,
+			},
+			{
+				Name:      "convert",
+				Usage:     "Convert the MPT state into a verkle tree as a dry-run",
+				ArgsUsage: "[<root>]",
+				Action:    convertVerkle,
+				Flags:     slices.Concat(utils.NetworkFlags, utils.DatabaseFlags, []cli.Flag{verkleFlushFlag}),
+				Description: `
+geth verkle convert [<state-root>]
+This command iterates the state snapshot at the given state root (head state
+by default) and inserts all accounts, storage slots and chunked contract code
+into a fresh verkle tree, using the EIP-6800 key layout. The tree is written
+into a scratch database in the data directory, which is wiped before the
+conversion. The throughput and resulting size are reported. Afterwards, the
+commitment is recomputed from the leaves written to disk and the sampled
+accounts are checked against the source state.
+
+The node must have been running with --cache.preimages, as the verkle keys
+are derived from the account addresses and storage keys instead of hashes.
  `,
 			},
 			{
@@ -212,3 +248,315 @@ func expandVerkle(ctx *cli.Context) error {
 	}
 	return nil
 }
+
+// verkleConversion accumulates the statistics of a verkle conversion.
+type verkleConversion struct {
+	accounts uint64
+	slots    uint64
+	codes    uint64
+	chunks   uint64
+	missing  uint64 // number of hashed keys without preimage
+	inserts  int    // number of insertions since the last flush
+
+	samples []common.Address // accounts picked for verification
+}
+
+// convertVerkle is the entry point of the verkle conversion command.
+func convertVerkle(ctx *cli.Context) error {
+	if ctx.NArg() > 1 {
+		return errors.New("too many arguments")
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chaindb := utils.MakeChainDatabase(ctx, stack, true)
+	defer chaindb.Close()
+
+	headBlock := rawdb.ReadHeadBlock(chaindb)
+	if headBlock == nil {
+		log.Error("Failed to load head block")
+		return errors.New("no head block")
+	}
+	root := headBlock.Root()
+	if ctx.NArg() == 1 {
+		var err error
+		root, err = parseRoot(ctx.Args().First())
+		if err != nil {
+			log.Error("Failed to resolve state root", "err", err)
+			return err
+		}
+	}
+	mptdb := utils.MakeTrieDatabase(ctx, chaindb, false, true, false)
+	defer mptdb.Close()
+
+	snapConfig := snapshot.Config{
+		CacheSize:  256,
+		Recovery:   false,
+		NoBuild:    true,
+		AsyncBuild: false,
+	}
+	snaptree, err := snapshot.New(snapConfig, chaindb, mptdb, headBlock.Root())
+	if err != nil {
+		log.Error("Failed to open snapshot tree", "err", err)
+		return err
+	}
+	// Open a fresh scratch database for holding the converted tree.
+	path := stack.ResolvePath("verkle-convert")
+	if err := os.RemoveAll(path); err != nil {
+		return err
+	}
+	outdb, err := stack.OpenDatabase("verkle-convert", 512, utils.MakeDatabaseHandles(0), "eth/db/verkle/", false)
+	if err != nil {
+		return err
+	}
+	defer outdb.Close()
+
+	log.Info("Converting state to verkle tree", "root", root, "output", path)
+	_, err = runVerkleConversion(chaindb, snaptree, root, outdb, ctx.Int(verkleFlushFlag.Name))
+	return err
+}
+
+// runVerkleConversion inserts the entire state at the given root into a verkle
+// tree held in the output database, verifying the result afterwards. The root
+// commitment of the converted tree is returned.
+func runVerkleConversion(chaindb ethdb.Database, snaptree *snapshot.Tree, root common.Hash, outdb ethdb.Database, flushLimit int) (common.Hash, error) {
+	var (
+		start  = time.Now()
+		logged = time.Now()
+		stats  = &verkleConversion{}
+		cache  = trieutils.NewPointCache(4096)
+		vdb    = triedb.NewDatabase(outdb, triedb.VerkleDefaults)
+		parent = types.EmptyVerkleHash
+	)
+	defer vdb.Close()
+
+	tree, err := trie.NewVerkleTrie(parent, vdb, cache)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	// flush commits the pending changes of the tree and persists them into the
+	// output database, releasing the memory occupied by the in-memory nodes.
+	flush := func() error {
+		vroot, nodes := tree.Commit(false)
+		if err := vdb.Update(vroot, parent, 0, trienode.NewWithNodeSet(nodes), triedb.NewStateSet()); err != nil {
+			return err
+		}
+		if err := vdb.Commit(vroot, false); err != nil {
+			return err
+		}
+		parent, stats.inserts = vroot, 0
+
+		tree, err = trie.NewVerkleTrie(vroot, vdb, cache)
+		return err
+	}
+	accIt, err := snaptree.AccountIterator(root, common.Hash{})
+	if err != nil {
+		return common.Hash{}, err
+	}
+	defer accIt.Release()
+
+	for accIt.Next() {
+		preimage := rawdb.ReadPreimage(chaindb, accIt.Hash())
+		if len(preimage) != common.AddressLength {
+			stats.missing++
+			continue
+		}
+		address := common.BytesToAddress(preimage)
+		account, err := types.FullAccount(accIt.Account())
+		if err != nil {
+			return common.Hash{}, err
+		}
+		var code []byte
+		if !bytes.Equal(account.CodeHash, types.EmptyCodeHash.Bytes()) {
+			code = rawdb.ReadCode(chaindb, common.BytesToHash(account.CodeHash))
+			if len(code) == 0 {
+				return common.Hash{}, fmt.Errorf("missing code %x of account %x", account.CodeHash, address)
+			}
+		}
+		if err := tree.UpdateAccount(address, account, len(code)); err != nil {
+			return common.Hash{}, err
+		}
+		if len(code) > 0 {
+			if err := tree.UpdateContractCode(address, common.BytesToHash(account.CodeHash), code); err != nil {
+				return common.Hash{}, err
+			}
+			stats.codes++
+			stats.chunks += uint64(len(code)+30) / 31
+		}
+		stats.accounts++
+		stats.inserts++
+		if stats.accounts%1000 == 1 {
+			stats.samples = append(stats.samples, address)
+		}
+		if account.Root != types.EmptyRootHash {
+			stIt, err := snaptree.StorageIterator(root, accIt.Hash(), common.Hash{})
+			if err != nil {
+				return common.Hash{}, err
+			}
+			for stIt.Next() {
+				key := rawdb.ReadPreimage(chaindb, stIt.Hash())
+				if len(key) != common.HashLength {
+					stats.missing++
+					continue
+				}
+				_, value, _, err := rlp.Split(stIt.Slot())
+				if err != nil {
+					stIt.Release()
+					return common.Hash{}, err
+				}
+				if err := tree.UpdateStorage(address, key, value); err != nil {
+					stIt.Release()
+					return common.Hash{}, err
+				}
+				stats.slots++
+				stats.inserts++
+			}
+			stIt.Release()
+			if err := stIt.Error(); err != nil {
+				return common.Hash{}, err
+			}
+		}
+		if stats.inserts >= flushLimit {
+			if err := flush(); err != nil {
+				return common.Hash{}, err
+			}
+		}
+		if time.Since(logged) > 8*time.Second {
+			elapsed := time.Since(start)
+			log.Info("Verkle conversion in progress", "at", accIt.Hash(), "accounts", stats.accounts, "slots", stats.slots,
+				"codes", stats.codes, "accounts/s", rate(stats.accounts, elapsed), "slots/s", rate(stats.slots, elapsed),
+				"elapsed", common.PrettyDuration(elapsed))
+			logged = time.Now()
+		}
+	}
+	if err := accIt.Error(); err != nil {
+		return common.Hash{}, err
+	}
+	if err := flush(); err != nil {
+		return common.Hash{}, err
+	}
+	elapsed := time.Since(start)
+
+	// Measure the size of the converted tree.
+	var nodes, size uint64
+	it := outdb.NewIterator(append(rawdb.VerklePrefix, rawdb.TrieNodeAccountPrefix...), nil)
+	for it.Next() {
+		nodes++
+		size += uint64(len(it.Key()) + len(it.Value()))
+	}
+	it.Release()
+
+	log.Info("Converted state to verkle tree", "root", parent, "accounts", stats.accounts, "slots", stats.slots,
+		"codes", stats.codes, "chunks", stats.chunks, "accounts/s", rate(stats.accounts, elapsed), "slots/s", rate(stats.slots, elapsed),
+		"nodes", nodes, "size", common.StorageSize(size), "elapsed", common.PrettyDuration(elapsed))
+	if stats.missing > 0 {
+		log.Warn("Conversion is incomplete due to missing preimages", "missing", stats.missing)
+	}
+	if err := verifyVerkleConversion(snaptree, root, parent, outdb, stats.samples); err != nil {
+		return common.Hash{}, err
+	}
+	return parent, nil
+}
+
+// verifyVerkleConversion checks the converted tree in the output database. The
+// commitment is recomputed from the flushed leaves, independently of the stored
+// internal nodes, and the sampled accounts are checked against the source state.
+func verifyVerkleConversion(snaptree *snapshot.Tree, root common.Hash, vroot common.Hash, outdb ethdb.Database, samples []common.Address) error {
+	rebuilt, err := rebuildVerkleRoot(outdb)
+	if err != nil {
+		return fmt.Errorf("failed to rebuild verkle tree: %w", err)
+	}
+	if rebuilt != vroot {
+		return fmt.Errorf("verkle commitment mismatch, want %x, got %x", vroot, rebuilt)
+	}
+	vdb := triedb.NewDatabase(outdb, triedb.VerkleDefaults)
+	defer vdb.Close()
+
+	tree, err := trie.NewVerkleTrie(vroot, vdb, trieutils.NewPointCache(4096))
+	if err != nil {
+		return fmt.Errorf("failed to reload verkle tree: %w", err)
+	}
+	snap := snaptree.Snapshot(root)
+	if snap == nil {
+		return fmt.Errorf("snapshot %x is not available", root)
+	}
+	for _, address := range samples {
+		want, err := snap.Account(crypto.Keccak256Hash(address.Bytes()))
+		if err != nil {
+			return err
+		}
+		got, err := tree.GetAccount(address)
+		if err != nil {
+			return err
+		}
+		if want == nil {
+			return fmt.Errorf("account %x missing in snapshot", address)
+		}
+		codeHash := want.CodeHash
+		if len(codeHash) == 0 {
+			codeHash = types.EmptyCodeHash.Bytes()
+		}
+		if got == nil || got.Nonce != want.Nonce || got.Balance.Cmp(want.Balance) != 0 || !bytes.Equal(got.CodeHash, codeHash) {
+			return fmt.Errorf("account %x mismatch in verkle tree", address)
+		}
+	}
+	log.Info("Verified verkle commitment", "root", vroot, "samples", len(samples))
+	return nil
+}
+
+// rebuildVerkleRoot recomputes the root commitment of the verkle tree from the
+// leaves stored in the output database, by inserting them into a fresh tree.
+//
+// The leaves are iterated in the order of their paths, which is also the order
+// of their stems. Whenever the leading bytes of the stem change, the subtree of
+// the previous leaf is complete, it's committed and collapsed to keep only the
+// top two levels of the tree in memory.
+func rebuildVerkleRoot(outdb ethdb.Database) (common.Hash, error) {
+	var (
+		prefix = append(rawdb.VerklePrefix, rawdb.TrieNodeAccountPrefix...)
+		root   = verkle.New().(*verkle.InternalNode)
+		last   []byte
+	)
+	it := outdb.NewIterator(prefix, nil)
+	defer it.Release()
+
+	for it.Next() {
+		path := it.Key()[len(prefix):]
+		node, err := verkle.ParseNode(it.Value(), byte(len(path)))
+		if err != nil {
+			return common.Hash{}, fmt.Errorf("failed to parse node %x: %w", path, err)
+		}
+		leaf, ok := node.(*verkle.LeafNode)
+		if !ok {
+			continue
+		}
+		stem := leaf.Key(0)[:verkle.StemSize]
+		if last != nil {
+			parent := root
+			for depth := 0; depth < 2 && parent != nil; depth++ {
+				if last[depth] != stem[depth] {
+					root.Commit()
+					parent.SetChild(int(last[depth]), verkle.HashedNode{})
+					break
+				}
+				parent, _ = parent.Children()[last[depth]].(*verkle.InternalNode)
+			}
+		}
+		if err := root.InsertValuesAtStem(stem, leaf.Values(), nil); err != nil {
+			return common.Hash{}, err
+		}
+		last = stem
+	}
+	if err := it.Error(); err != nil {
+		return common.Hash{}, err
+	}
+	return root.Commit().Bytes(), nil
+}
+
+// rate returns the number of items processed per second.
+func rate(n uint64, elapsed time.Duration) uint64 {
+	if elapsed < time.Second {
+		return n
+	}
+	return n * uint64(time.Second) / uint64(elapsed)
+}
@@ -0,0 +1,162 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/trie"
+	trieutils "github.com/ethereum/go-ethereum/trie/utils"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/ethereum/go-ethereum/triedb/hashdb"
+	"github.com/ethereum/go-verkle"
+	"github.com/holiman/uint256"
+)
+
+func TestVerkleConversion(t *testing.T) {
+	// Construct the source state along with the preimages of the keys.
+	var (
+		db    = rawdb.NewMemoryDatabase()
+		tdb   = triedb.NewDatabase(db, &triedb.Config{Preimages: true, HashDB: hashdb.Defaults})
+		codes = make(map[common.Address][]byte)
+		slots = make(map[common.Address]map[common.Hash]common.Hash)
+	)
+	statedb, err := state.New(types.EmptyRootHash, state.NewDatabase(tdb, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 64; i++ {
+		addr := common.BytesToAddress([]byte{byte(i + 1)})
+		statedb.SetNonce(addr, uint64(i))
+		statedb.SetBalance(addr, uint256.NewInt(uint64(i)*1000+1), tracing.BalanceChangeUnspecified)
+		if i%8 == 0 {
+			codes[addr] = bytes.Repeat([]byte{byte(i + 1)}, 100+i*40)
+			statedb.SetCode(addr, codes[addr])
+
+			slots[addr] = make(map[common.Hash]common.Hash)
+			for j := 0; j < 4; j++ {
+				key, value := common.BytesToHash([]byte{byte(j)}), common.BytesToHash([]byte{byte(i + 1), byte(j + 1)})
+				statedb.SetState(addr, key, value)
+				slots[addr][key] = value
+			}
+		}
+	}
+	root, err := statedb.Commit(0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tdb.Commit(root, false); err != nil {
+		t.Fatal(err)
+	}
+	snaptree, err := snapshot.New(snapshot.Config{CacheSize: 16}, db, tdb, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Convert the state with a small flush limit, to flush the tree repeatedly.
+	outdb := rawdb.NewMemoryDatabase()
+	vroot, err := runVerkleConversion(db, snaptree, root, outdb, 16)
+	if err != nil {
+		t.Fatalf("Failed to convert state: %v", err)
+	}
+	tree, err := trie.NewVerkleTrie(vroot, triedb.NewDatabase(outdb, triedb.VerkleDefaults), trieutils.NewPointCache(64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes := rawdb.NewTable(outdb, string(rawdb.VerklePrefix))
+	resolver := func(path []byte) ([]byte, error) {
+		return rawdb.ReadAccountTrieNode(nodes, path), nil
+	}
+	rootNode, err := verkle.ParseNode(rawdb.ReadAccountTrieNode(nodes, nil), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 64; i += 7 {
+		addr := common.BytesToAddress([]byte{byte(i + 1)})
+		account, err := tree.GetAccount(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		codeHash := types.EmptyCodeHash
+		if code, ok := codes[addr]; ok {
+			codeHash = crypto.Keccak256Hash(code)
+		}
+		if account == nil || account.Nonce != uint64(i) || account.Balance.Uint64() != uint64(i)*1000+1 || common.BytesToHash(account.CodeHash) != codeHash {
+			t.Fatalf("Account %x mismatch: %+v", addr, account)
+		}
+	}
+	for addr, storage := range slots {
+		for key, want := range storage {
+			got, err := tree.GetStorage(addr, key.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, common.TrimLeftZeroes(want.Bytes())) {
+				t.Fatalf("Storage %x %x mismatch, want %x, got %x", addr, key, want, got)
+			}
+		}
+	}
+	for addr, code := range codes {
+		chunks := trie.ChunkifyCode(code)
+		for n := 0; n*32 < len(chunks); n++ {
+			got, err := rootNode.Get(trieutils.CodeChunkKey(addr.Bytes(), uint256.NewInt(uint64(n))), resolver)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := chunks[n*32 : (n+1)*32]; !bytes.Equal(got, want) {
+				t.Fatalf("Code chunk %d of %x mismatch, want %x, got %x", n, addr, want, got)
+			}
+		}
+	}
+	// Tamper with a flushed leaf, the recomputed commitment no longer matches.
+	it := nodes.NewIterator(rawdb.TrieNodeAccountPrefix, nil)
+	for it.Next() {
+		path := it.Key()[len(rawdb.TrieNodeAccountPrefix):]
+		node, err := verkle.ParseNode(it.Value(), byte(len(path)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		leaf, ok := node.(*verkle.LeafNode)
+		if !ok {
+			continue
+		}
+		values := leaf.Values()
+		values[255] = common.Hash{0xff}.Bytes()
+		tampered, err := verkle.NewLeafNode(leaf.Key(0)[:verkle.StemSize], values)
+		if err != nil {
+			t.Fatal(err)
+		}
+		blob, err := tampered.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		rawdb.WriteAccountTrieNode(nodes, path, blob)
+		break
+	}
+	it.Release()
+
+	if err := verifyVerkleConversion(snaptree, root, vroot, outdb, nil); err == nil {
+		t.Fatal("Expected commitment mismatch for tampered leaf")
+	}
+}
@@ -0,0 +1,542 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package asm
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// The textual EOF format produced by DisassembleEOF and accepted by AssembleEOF
+// looks as follows:
+//
+//	.code code_0 inputs=0 outputs=non-returning max_stack=2
+//		PUSH1 0x00
+//		RJUMPI @L6
+//		CALLF @code_1
+//		STOP
+//	L6:
+//		EOFCREATE @container_0
+//		...
+//	.code code_1 inputs=0 outputs=0 max_stack=0
+//		RETF
+//	.container container_0
+//		.code code_0 inputs=0 outputs=non-returning max_stack=0
+//			INVALID
+//		.data 0x
+//	.end
+//	.data 0x010203 size=4
+//
+// Relative jumps refer to labels within the same code section, CALLF and JUMPF
+// refer to code section names and EOFCREATE and RETURNCONTRACT to subcontainer
+// names. Plain numbers are accepted in place of labels for immediates which do
+// not point at a valid target. Bytes which cannot be decoded as instructions
+// are represented by a .bytes directive. Comments start with ';;'.
+
+// nonReturning is the outputs value of a code section which never returns.
+const nonReturning = 0x80
+
+// eofInstruction is a decoded instruction within an EOF code section. If op is
+// nil, the instruction represents undecodable raw bytes.
+type eofInstruction struct {
+	pc  int
+	op  *vm.OpCode
+	imm []byte
+	raw []byte
+}
+
+// isDefined returns whether op is a known instruction which can be assembled
+// back from its mnemonic.
+func isDefined(op vm.OpCode) bool {
+	return vm.StringToOp(op.String()) == op
+}
+
+// decodeEOFSection splits an EOF code section into instructions. It is lenient
+// about invalid code, which is returned as raw bytes.
+func decodeEOFSection(code []byte) []eofInstruction {
+	var instrs []eofInstruction
+	for pc := 0; pc < len(code); {
+		op := vm.OpCode(code[pc])
+		if !isDefined(op) {
+			instrs = append(instrs, eofInstruction{pc: pc, raw: code[pc : pc+1]})
+			pc++
+			continue
+		}
+		size := vm.Immediates(op)
+		if op == vm.RJUMPV && pc+1 < len(code) {
+			size = 1 + 2*(int(code[pc+1])+1)
+		}
+		if pc+1+size > len(code) {
+			// Truncated immediate, the remainder can't be decoded.
+			instrs = append(instrs, eofInstruction{pc: pc, raw: code[pc:]})
+			break
+		}
+		instrs = append(instrs, eofInstruction{pc: pc, op: &op, imm: code[pc+1 : pc+1+size]})
+		pc += 1 + size
+	}
+	return instrs
+}
+
+// jumpTargets returns the absolute destinations of a relative jump instruction.
+func (in *eofInstruction) jumpTargets() []int {
+	switch *in.op {
+	case vm.RJUMP, vm.RJUMPI:
+		return []int{in.pc + 3 + int(int16(binary.BigEndian.Uint16(in.imm)))}
+	case vm.RJUMPV:
+		var (
+			targets []int
+			next    = in.pc + 1 + len(in.imm)
+		)
+		for i := 1; i < len(in.imm); i += 2 {
+			targets = append(targets, next+int(int16(binary.BigEndian.Uint16(in.imm[i:]))))
+		}
+		return targets
+	}
+	return nil
+}
+
+// DisassembleEOF returns the textual representation of an EOF container.
+func DisassembleEOF(c *vm.Container) string {
+	var out = new(strings.Builder)
+	disassembleContainer(out, c, "")
+	return out.String()
+}
+
+func disassembleContainer(out *strings.Builder, c *vm.Container, indent string) {
+	var (
+		sections = c.CodeSections()
+		subs     = c.SubContainers()
+	)
+	for i, section := range sections {
+		outputs := strconv.Itoa(int(section.Outputs))
+		if section.Outputs == nonReturning {
+			outputs = "non-returning"
+		}
+		fmt.Fprintf(out, "%s.code code_%d inputs=%d outputs=%s max_stack=%d\n", indent, i, section.Inputs, outputs, section.MaxStackHeight)
+		disassembleSection(out, section.Code, len(sections), len(subs), indent)
+	}
+	for i, sub := range subs {
+		fmt.Fprintf(out, "%s.container container_%d\n", indent, i)
+		disassembleContainer(out, sub, indent+"\t")
+		fmt.Fprintf(out, "%s.end\n", indent)
+	}
+	if size := c.DataSize(); size != len(c.Data()) {
+		fmt.Fprintf(out, "%s.data %s size=%d\n", indent, hexutil.Encode(c.Data()), size)
+	} else {
+		fmt.Fprintf(out, "%s.data %s\n", indent, hexutil.Encode(c.Data()))
+	}
+}
+
+func disassembleSection(out *strings.Builder, code []byte, sections, subs int, indent string) {
+	var (
+		instrs = decodeEOFSection(code)
+		starts = make(map[int]bool)
+		labels = make(map[int]bool)
+	)
+	for _, in := range instrs {
+		starts[in.pc] = true
+	}
+	for _, in := range instrs {
+		if in.op == nil {
+			continue
+		}
+		for _, dest := range in.jumpTargets() {
+			if starts[dest] {
+				labels[dest] = true
+			}
+		}
+	}
+	for _, in := range instrs {
+		if labels[in.pc] {
+			fmt.Fprintf(out, "%sL%d:\n", indent, in.pc)
+		}
+		if in.op == nil {
+			fmt.Fprintf(out, "%s\t.bytes %s\n", indent, hexutil.Encode(in.raw))
+			continue
+		}
+		var args []string
+		switch *in.op {
+		case vm.RJUMP, vm.RJUMPI, vm.RJUMPV:
+			for i, dest := range in.jumpTargets() {
+				if labels[dest] {
+					args = append(args, fmt.Sprintf("@L%d", dest))
+				} else if *in.op == vm.RJUMPV {
+					args = append(args, strconv.Itoa(int(int16(binary.BigEndian.Uint16(in.imm[1+2*i:])))))
+				} else {
+					args = append(args, strconv.Itoa(int(int16(binary.BigEndian.Uint16(in.imm)))))
+				}
+			}
+		case vm.CALLF, vm.JUMPF:
+			if arg := int(binary.BigEndian.Uint16(in.imm)); arg < sections {
+				args = append(args, fmt.Sprintf("@code_%d", arg))
+			} else {
+				args = append(args, strconv.Itoa(arg))
+			}
+		case vm.EOFCREATE, vm.RETURNCONTRACT:
+			if arg := int(in.imm[0]); arg < subs {
+				args = append(args, fmt.Sprintf("@container_%d", arg))
+			} else {
+				args = append(args, strconv.Itoa(arg))
+			}
+		default:
+			if len(in.imm) > 0 {
+				args = append(args, fmt.Sprintf("%#x", in.imm))
+			}
+		}
+		if len(args) == 0 {
+			fmt.Fprintf(out, "%s\t%v\n", indent, *in.op)
+		} else {
+			fmt.Fprintf(out, "%s\t%v %s\n", indent, *in.op, strings.Join(args, " "))
+		}
+	}
+}
+
+// eofSource is the parsed, not yet assembled, representation of a container.
+type eofSource struct {
+	sections []*sectionSource
+	subs     []*eofSource
+	names    map[string]int // subcontainer names
+	data     []byte
+	dataSize int
+	line     int
+}
+
+// sectionSource is the parsed representation of a single code section.
+type sectionSource struct {
+	name   string
+	meta   vm.CodeSection
+	lines  []sourceLine
+	labels map[string]int // label name to index into lines
+	line   int
+}
+
+// sourceLine is a single instruction or .bytes directive of a code section.
+type sourceLine struct {
+	lineno int
+	fields []string
+}
+
+// AssembleEOF parses the textual representation of an EOF container, as
+// produced by DisassembleEOF, and assembles it into a container.
+func AssembleEOF(src string) (*vm.Container, error) {
+	var (
+		root  = &eofSource{names: make(map[string]int)}
+		stack = []*eofSource{root}
+		cur   *sectionSource
+	)
+	for i, line := range strings.Split(src, "\n") {
+		lineno := i + 1
+		if idx := strings.Index(line, ";;"); idx >= 0 {
+			line = line[:idx]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		top := stack[len(stack)-1]
+		switch fields[0] {
+		case ".code":
+			section, err := parseSectionHeader(fields, lineno)
+			if err != nil {
+				return nil, err
+			}
+			for _, s := range top.sections {
+				if s.name == section.name {
+					return nil, fmt.Errorf("%d: duplicate code section %q", lineno, section.name)
+				}
+			}
+			top.sections = append(top.sections, section)
+			cur = section
+		case ".container":
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("%d: expected .container <name>", lineno)
+			}
+			if _, ok := top.names[fields[1]]; ok {
+				return nil, fmt.Errorf("%d: duplicate container %q", lineno, fields[1])
+			}
+			sub := &eofSource{names: make(map[string]int), line: lineno}
+			top.names[fields[1]] = len(top.subs)
+			top.subs = append(top.subs, sub)
+			stack = append(stack, sub)
+			cur = nil
+		case ".end":
+			if len(stack) == 1 {
+				return nil, fmt.Errorf("%d: .end without .container", lineno)
+			}
+			stack = stack[:len(stack)-1]
+			cur = nil
+		case ".data":
+			if err := parseData(top, fields, lineno); err != nil {
+				return nil, err
+			}
+			cur = nil
+		default:
+			if cur == nil {
+				return nil, fmt.Errorf("%d: instruction outside of code section", lineno)
+			}
+			if len(fields) == 1 && strings.HasSuffix(fields[0], ":") {
+				name := strings.TrimSuffix(fields[0], ":")
+				if _, ok := cur.labels[name]; ok {
+					return nil, fmt.Errorf("%d: duplicate label %q", lineno, name)
+				}
+				cur.labels[name] = len(cur.lines)
+				continue
+			}
+			cur.lines = append(cur.lines, sourceLine{lineno: lineno, fields: fields})
+		}
+	}
+	if len(stack) != 1 {
+		return nil, fmt.Errorf("%d: unterminated .container", stack[len(stack)-1].line)
+	}
+	return root.assemble()
+}
+
+// parseSectionHeader parses a '.code <name> inputs=<n> outputs=<n> max_stack=<n>'
+// directive.
+func parseSectionHeader(fields []string, lineno int) (*sectionSource, error) {
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%d: expected .code <name> inputs=<n> outputs=<n> max_stack=<n>", lineno)
+	}
+	section := &sectionSource{name: fields[1], labels: make(map[string]int), line: lineno}
+	for _, field := range fields[2:] {
+		key, value, _ := strings.Cut(field, "=")
+		if key == "outputs" && value == "non-returning" {
+			section.meta.Outputs = nonReturning
+			continue
+		}
+		var bits = 8
+		if key == "max_stack" {
+			bits = 16
+		}
+		n, err := strconv.ParseUint(value, 0, bits)
+		if err != nil {
+			return nil, fmt.Errorf("%d: invalid %s: %v", lineno, key, err)
+		}
+		switch key {
+		case "inputs":
+			section.meta.Inputs = uint8(n)
+		case "outputs":
+			section.meta.Outputs = uint8(n)
+		case "max_stack":
+			section.meta.MaxStackHeight = uint16(n)
+		default:
+			return nil, fmt.Errorf("%d: unknown code section attribute %q", lineno, key)
+		}
+	}
+	return section, nil
+}
+
+// parseData parses a '.data <hex> [size=<n>]' directive.
+func parseData(c *eofSource, fields []string, lineno int) error {
+	if len(fields) < 2 || len(fields) > 3 {
+		return fmt.Errorf("%d: expected .data <hex> [size=<n>]", lineno)
+	}
+	data, err := hexutil.Decode(fields[1])
+	if err != nil {
+		return fmt.Errorf("%d: invalid data: %v", lineno, err)
+	}
+	c.data, c.dataSize = data, len(data)
+	if len(fields) == 3 {
+		value, ok := strings.CutPrefix(fields[2], "size=")
+		if !ok {
+			return fmt.Errorf("%d: unexpected %q, expected size=<n>", lineno, fields[2])
+		}
+		n, err := strconv.ParseUint(value, 0, 16)
+		if err != nil {
+			return fmt.Errorf("%d: invalid data size: %v", lineno, err)
+		}
+		c.dataSize = int(n)
+	}
+	return nil
+}
+
+// assemble turns the parsed source into a container.
+func (c *eofSource) assemble() (*vm.Container, error) {
+	if len(c.sections) == 0 {
+		if c.line == 0 {
+			return nil, errors.New("container without code sections")
+		}
+		return nil, fmt.Errorf("%d: container without code sections", c.line)
+	}
+	subs := make([]*vm.Container, len(c.subs))
+	for i, sub := range c.subs {
+		container, err := sub.assemble()
+		if err != nil {
+			return nil, err
+		}
+		subs[i] = container
+	}
+	sectionNames := make(map[string]int)
+	for i, s := range c.sections {
+		sectionNames[s.name] = i
+	}
+	sections := make([]vm.CodeSection, len(c.sections))
+	for i, s := range c.sections {
+		code, err := s.assemble(sectionNames, c.names)
+		if err != nil {
+			return nil, err
+		}
+		sections[i] = s.meta
+		sections[i].Code = code
+	}
+	return vm.NewContainer(sections, subs, c.data, c.dataSize), nil
+}
+
+// assemble encodes the instructions of a code section. The first pass
+// determines the offset of every line, the second one emits the code.
+func (s *sectionSource) assemble(sections, containers map[string]int) ([]byte, error) {
+	var (
+		offsets = make([]int, len(s.lines)+1)
+		pc      int
+	)
+	for i, line := range s.lines {
+		offsets[i] = pc
+		size, err := line.size()
+		if err != nil {
+			return nil, err
+		}
+		pc += size
+	}
+	offsets[len(s.lines)] = pc
+
+	var code []byte
+	for _, line := range s.lines {
+		if line.fields[0] == ".bytes" {
+			raw, _ := hexutil.Decode(line.fields[1]) // checked in size
+			code = append(code, raw...)
+			continue
+		}
+		op := vm.StringToOp(strings.ToUpper(line.fields[0]))
+		code = append(code, byte(op))
+		args := line.fields[1:]
+
+		switch op {
+		case vm.RJUMP, vm.RJUMPI, vm.RJUMPV:
+			if op == vm.RJUMPV {
+				code = append(code, byte(len(args)-1))
+			}
+			next := len(code) + 2*len(args)
+			for _, arg := range args {
+				var rel int
+				if name, ok := strings.CutPrefix(arg, "@"); ok {
+					idx, ok := s.labels[name]
+					if !ok {
+						return nil, fmt.Errorf("%d: undefined label %q", line.lineno, name)
+					}
+					rel = offsets[idx] - next
+					if rel < -32768 || rel > 32767 {
+						return nil, fmt.Errorf("%d: jump to %q out of range", line.lineno, name)
+					}
+				} else {
+					n, err := strconv.ParseInt(arg, 0, 16)
+					if err != nil {
+						return nil, fmt.Errorf("%d: invalid jump offset %q: %v", line.lineno, arg, err)
+					}
+					rel = int(n)
+				}
+				code = binary.BigEndian.AppendUint16(code, uint16(int16(rel)))
+			}
+		case vm.CALLF, vm.JUMPF:
+			n, err := resolve(args[0], sections, 16)
+			if err != nil {
+				return nil, fmt.Errorf("%d: %v", line.lineno, err)
+			}
+			code = binary.BigEndian.AppendUint16(code, uint16(n))
+		case vm.EOFCREATE, vm.RETURNCONTRACT:
+			n, err := resolve(args[0], containers, 8)
+			if err != nil {
+				return nil, fmt.Errorf("%d: %v", line.lineno, err)
+			}
+			code = append(code, byte(n))
+		default:
+			if size := vm.Immediates(op); size > 0 {
+				num, ok := math.ParseBig256(args[0])
+				if !ok {
+					return nil, fmt.Errorf("%d: invalid immediate %q", line.lineno, args[0])
+				}
+				imm := num.Bytes()
+				if len(imm) > size {
+					return nil, fmt.Errorf("%d: immediate %q exceeds %d bytes", line.lineno, args[0], size)
+				}
+				code = append(code, make([]byte, size-len(imm))...)
+				code = append(code, imm...)
+			}
+		}
+	}
+	return code, nil
+}
+
+// size returns the number of bytes the line assembles to, and validates the
+// number of arguments.
+func (l *sourceLine) size() (int, error) {
+	if l.fields[0] == ".bytes" {
+		if len(l.fields) != 2 {
+			return 0, fmt.Errorf("%d: expected .bytes <hex>", l.lineno)
+		}
+		raw, err := hexutil.Decode(l.fields[1])
+		if err != nil {
+			return 0, fmt.Errorf("%d: invalid bytes: %v", l.lineno, err)
+		}
+		return len(raw), nil
+	}
+	name := strings.ToUpper(l.fields[0])
+	op := vm.StringToOp(name)
+	if op.String() != name {
+		return 0, fmt.Errorf("%d: unknown instruction %q", l.lineno, l.fields[0])
+	}
+	var (
+		args = len(l.fields) - 1
+		want = 0
+	)
+	switch {
+	case op == vm.RJUMPV:
+		if args == 0 || args > 256 {
+			return 0, fmt.Errorf("%d: RJUMPV requires between 1 and 256 targets", l.lineno)
+		}
+		return 2 + 2*args, nil
+	case vm.Immediates(op) > 0:
+		want = 1
+	}
+	if args != want {
+		return 0, fmt.Errorf("%d: %v expects %d argument(s), have %d", l.lineno, op, want, args)
+	}
+	return 1 + vm.Immediates(op), nil
+}
+
+// resolve returns the index referenced by arg, which is either an '@name'
+// reference or a plain number.
+func resolve(arg string, names map[string]int, bits int) (int, error) {
+	if name, ok := strings.CutPrefix(arg, "@"); ok {
+		idx, ok := names[name]
+		if !ok {
+			return 0, fmt.Errorf("undefined reference %q", name)
+		}
+		return idx, nil
+	}
+	n, err := strconv.ParseUint(arg, 0, bits)
+	if err != nil {
+		return 0, fmt.Errorf("invalid argument %q: %v", arg, err)
+	}
+	return int(n), nil
+}
@@ -0,0 +1,153 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package asm
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+func TestAssembleEOF(t *testing.T) {
+	src := `
+.code main inputs=0 outputs=non-returning max_stack=4
+	PUSH0
+	RJUMPI @skip ;; comment
+	CALLF @helper
+	POP
+skip:
+	PUSH0
+	PUSH0
+	PUSH0
+	PUSH0
+	EOFCREATE @child
+	POP
+	STOP
+.code helper inputs=0 outputs=1 max_stack=1
+	PUSH1 0x2a
+	RETF
+.container child
+	.code code_0 inputs=0 outputs=non-returning max_stack=2
+		PUSH0
+		PUSH0
+		RETURNCONTRACT @runtime
+	.container runtime
+		.code code_0 inputs=0 outputs=non-returning max_stack=0
+			INVALID
+		.data 0x
+	.end
+	.data 0x
+.end
+.data 0x0102
+`
+	c, err := AssembleEOF(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "ef00010100080200020010000303000100300400020000800004000100015fe10004e30001505f5f5f5fec005000602ae4ef00010100040200010004030001001404000000008000025f5fee00ef000101000402000100010400000000800000fe0102"
+	if have := common.Bytes2Hex(c.MarshalBinary()); have != want {
+		t.Fatalf("wrong code\nhave: %v\nwant: %v", have, want)
+	}
+	jt := vm.NewEOFInstructionSetForTesting()
+	if err := c.ValidateCode(&jt, false); err != nil {
+		t.Fatalf("assembled container is invalid: %v", err)
+	}
+}
+
+func TestAssembleEOFErrors(t *testing.T) {
+	for i, tc := range []struct {
+		src string
+		err string
+	}{
+		{".data 0x", "container without code sections"},
+		{"PUSH0", "1: instruction outside of code section"},
+		{".code a inputs=0 outputs=0", "1: expected .code <name> inputs=<n> outputs=<n> max_stack=<n>"},
+		{".code a inputs=0 outputs=0 stack=0", `1: unknown code section attribute "stack"`},
+		{".code a inputs=0 outputs=0 max_stack=0\n\tFOO", `2: unknown instruction "FOO"`},
+		{".code a inputs=0 outputs=0 max_stack=0\n\tRJUMP @nowhere", `2: undefined label "nowhere"`},
+		{".code a inputs=0 outputs=0 max_stack=0\n\tCALLF @b", `2: undefined reference "b"`},
+		{".code a inputs=0 outputs=0 max_stack=0\n\tPUSH1 0x0102", `2: immediate "0x0102" exceeds 1 bytes`},
+		{".code a inputs=0 outputs=0 max_stack=0\n\tPUSH1", "2: PUSH1 expects 1 argument(s), have 0"},
+		{".container a\n.code a inputs=0 outputs=0 max_stack=0", "1: unterminated .container"},
+		{".end", "1: .end without .container"},
+	} {
+		_, err := AssembleEOF(tc.src)
+		if err == nil {
+			t.Errorf("test %d: expected error %q", i, tc.err)
+			continue
+		}
+		if have := err.Error(); have != tc.err {
+			t.Errorf("test %d: wrong error\nhave: %q\nwant: %q", i, have, tc.err)
+		}
+	}
+}
+
+// Tests that disassembling and re-assembling EOF containers yields the original
+// code, also for containers which are not valid.
+func TestEOFRoundTrip(t *testing.T) {
+	var tests = []string{
+		// Fixtures from core/vm/eof_test.go.
+		"EF000101000402000100040400000000800000E0000000",
+		"ef0001010004020001000d04000000008000025fe100055f5fe000035f600100",
+		"ef000101000402000100030400030000800001604200010203",
+		"ef000101000c02000300030005000104000000008000010203000401010001604200604260420000",
+		"ef0001010004020001000303000100140400030000800001604200ef000101000402000100010400000000800000fe010203",
+	}
+	// And the fuzzing corpus of evm eofparse.
+	corpus, err := os.Open("../../cmd/evm/testdata/eof/eof_corpus_1.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer corpus.Close()
+	scanner := bufio.NewScanner(corpus)
+	scanner.Buffer(make([]byte, 1024), 10*1024*1024)
+	for scanner.Scan() {
+		tests = append(tests, strings.TrimPrefix(scanner.Text(), "0x"))
+	}
+	var parsed int
+	for i, test := range tests {
+		code, err := hex.DecodeString(test)
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		var c vm.Container
+		if err := c.UnmarshalBinary(code, false); err != nil {
+			continue
+		}
+		parsed++
+		text := DisassembleEOF(&c)
+		have, err := AssembleEOF(text)
+		if err != nil {
+			t.Fatalf("test %d: failed to assemble: %v\n%v", i, err, text)
+		}
+		if enc := have.MarshalBinary(); !bytes.Equal(enc, code) {
+			t.Fatalf("test %d: round trip mismatch\nhave: %x\nwant: %x\n%v", i, enc, code, text)
+		}
+		if again := DisassembleEOF(have); again != text {
+			t.Fatalf("test %d: disassembly mismatch\nhave:\n%v\nwant:\n%v", i, again, text)
+		}
+	}
+	if parsed < len(tests)/4 {
+		t.Fatalf("too few containers parsed: %d of %d", parsed, len(tests))
+	}
+}
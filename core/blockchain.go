@@ -141,6 +141,7 @@ type CacheConfig struct {
 	SnapshotLimit       int           // Memory allowance (MB) to use for caching snapshot entries in memory
 	Preimages           bool          // Whether to store preimage of trie key to the disk
 	StateHistory        uint64        // Number of blocks from head whose state histories are reserved.
+	StateIndex          bool          // Whether to index the state histories for historical state access
 	StateScheme         string        // Scheme used to store ethereum states and merkle tree nodes on top
 
 	SnapshotNoBuild bool // Whether the background generation is allowed
@@ -160,9 +161,10 @@ func (c *CacheConfig) triedbConfig(isVerkle bool) *triedb.Config {
 	}
 	if c.StateScheme == rawdb.PathScheme {
 		config.PathDB = &pathdb.Config{
-			StateHistory:    c.StateHistory,
-			CleanCacheSize:  c.TrieCleanLimit * 1024 * 1024,
-			WriteBufferSize: c.TrieDirtyLimit * 1024 * 1024,
+			StateHistory:        c.StateHistory,
+			EnableStateIndexing: c.StateIndex,
+			CleanCacheSize:      c.TrieCleanLimit * 1024 * 1024,
+			WriteBufferSize:     c.TrieDirtyLimit * 1024 * 1024,
 		}
 	}
 	return config
@@ -278,6 +280,10 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 	if _, ok := genesisErr.(*params.ConfigCompatError); genesisErr != nil && !ok {
 		return nil, genesisErr
 	}
+	// Custom precompiles can't be resolved by the EVM unless they are registered.
+	if err := vm.CheckPrecompiles(chainConfig); err != nil {
+		return nil, err
+	}
 	log.Info("")
 	log.Info(strings.Repeat("-", 153))
 	for _, line := range strings.Split(chainConfig.Description(), "\n") {
@@ -1819,6 +1825,11 @@ func (bc *BlockChain) insertChain(chain types.Blocks, setHead bool, makeWitness
 		if err != nil {
 			return nil, it.index, err
 		}
+		// Summarizing the witness walks all its nodes, only do it if somebody
+		// is interested in the result.
+		if witness != nil && metrics.Enabled() {
+			witness.Stats().Report()
+		}
 		// Report the import stats before returning the various results
 		stats.processed++
 		stats.usedGas += res.usedGas
@@ -164,6 +164,27 @@ func DeleteSnapshotGenerator(db ethdb.KeyValueWriter) {
 	}
 }
 
+// ReadSnapshotImport retrieves the serialized progress of the snapshot import
+// which was interrupted.
+func ReadSnapshotImport(db ethdb.KeyValueReader) []byte {
+	data, _ := db.Get(snapshotImportKey)
+	return data
+}
+
+// WriteSnapshotImport stores the serialized progress of the snapshot import.
+func WriteSnapshotImport(db ethdb.KeyValueWriter, progress []byte) {
+	if err := db.Put(snapshotImportKey, progress); err != nil {
+		log.Crit("Failed to store snapshot import progress", "err", err)
+	}
+}
+
+// DeleteSnapshotImport deletes the serialized progress of the snapshot import.
+func DeleteSnapshotImport(db ethdb.KeyValueWriter) {
+	if err := db.Delete(snapshotImportKey); err != nil {
+		log.Crit("Failed to remove snapshot import progress", "err", err)
+	}
+}
+
 // ReadSnapshotRecoveryNumber retrieves the block number of the last persisted
 // snapshot layer.
 func ReadSnapshotRecoveryNumber(db ethdb.KeyValueReader) *uint64 {
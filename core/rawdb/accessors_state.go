@@ -264,3 +264,89 @@ func WriteStateHistory(db ethdb.AncientWriter, id uint64, meta []byte, accountIn
 		return nil
 	})
 }
+
+// ReadStateHistoryIndexMeta retrieves the metadata of the state history index,
+// describing the range of indexed state histories.
+func ReadStateHistoryIndexMeta(db ethdb.KeyValueReader) []byte {
+	data, _ := db.Get(stateHistoryIndexKey)
+	return data
+}
+
+// WriteStateHistoryIndexMeta stores the metadata of the state history index.
+func WriteStateHistoryIndexMeta(db ethdb.KeyValueWriter, blob []byte) {
+	if err := db.Put(stateHistoryIndexKey, blob); err != nil {
+		log.Crit("Failed to store state history index metadata", "err", err)
+	}
+}
+
+// WriteAccountHistoryIndex records that the account was mutated in the state
+// history with the given id.
+func WriteAccountHistoryIndex(db ethdb.KeyValueWriter, address common.Address, id uint64) {
+	if err := db.Put(stateHistoryAccountIndexKey(address, id), []byte{}); err != nil {
+		log.Crit("Failed to store account history index", "err", err)
+	}
+}
+
+// DeleteAccountHistoryIndex deletes the specified account history index entry.
+func DeleteAccountHistoryIndex(db ethdb.KeyValueWriter, address common.Address, id uint64) {
+	if err := db.Delete(stateHistoryAccountIndexKey(address, id)); err != nil {
+		log.Crit("Failed to delete account history index", "err", err)
+	}
+}
+
+// WriteStorageHistoryIndex records that the storage slot was mutated in the
+// state history with the given id.
+func WriteStorageHistoryIndex(db ethdb.KeyValueWriter, address common.Address, slot common.Hash, id uint64) {
+	if err := db.Put(stateHistoryStorageIndexKey(address, slot, id), []byte{}); err != nil {
+		log.Crit("Failed to store storage history index", "err", err)
+	}
+}
+
+// DeleteStorageHistoryIndex deletes the specified storage history index entry.
+func DeleteStorageHistoryIndex(db ethdb.KeyValueWriter, address common.Address, slot common.Hash, id uint64) {
+	if err := db.Delete(stateHistoryStorageIndexKey(address, slot, id)); err != nil {
+		log.Crit("Failed to delete storage history index", "err", err)
+	}
+}
+
+// ReadAccountHistoryIndex returns the id of the first state history, starting
+// from the given id, in which the account was mutated. False is returned if
+// there is no such state history in the index.
+func ReadAccountHistoryIndex(db ethdb.Iteratee, address common.Address, from uint64) (uint64, bool) {
+	return seekHistoryIndex(db, stateHistoryAccountIndexKey(address, from))
+}
+
+// ReadStorageHistoryIndex returns the id of the first state history, starting
+// from the given id, in which the storage slot was mutated. False is returned
+// if there is no such state history in the index.
+func ReadStorageHistoryIndex(db ethdb.Iteratee, address common.Address, slot common.Hash, from uint64) (uint64, bool) {
+	return seekHistoryIndex(db, stateHistoryStorageIndexKey(address, slot, from))
+}
+
+// seekHistoryIndex returns the state id of the first index entry which shares
+// the prefix of the given key and is not less than it.
+func seekHistoryIndex(db ethdb.Iteratee, key []byte) (uint64, bool) {
+	prefix := key[:len(key)-8]
+	it := db.NewIterator(prefix, key[len(prefix):])
+	defer it.Release()
+
+	if !it.Next() || len(it.Key()) != len(key) {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(it.Key()[len(prefix):]), true
+}
+
+// DeleteStateHistoryIndex removes the entire state history index along with
+// its metadata.
+func DeleteStateHistoryIndex(db ethdb.KeyValueStore) {
+	for _, prefix := range [][]byte{StateHistoryAccountIndexPrefix, StateHistoryStorageIndexPrefix} {
+		limit := common.CopyBytes(prefix)
+		limit[len(limit)-1]++
+		if err := db.DeleteRange(prefix, limit); err != nil {
+			log.Crit("Failed to delete state history index", "err", err)
+		}
+	}
+	if err := db.Delete(stateHistoryIndexKey); err != nil {
+		log.Crit("Failed to delete state history index metadata", "err", err)
+	}
+}
@@ -67,6 +67,9 @@ var (
 	// snapshotRecoveryKey tracks the snapshot recovery marker across restarts.
 	snapshotRecoveryKey = []byte("SnapshotRecovery")
 
+	// snapshotImportKey tracks the snapshot import progress across restarts.
+	snapshotImportKey = []byte("SnapshotImport")
+
 	// snapshotSyncStatusKey tracks the snapshot sync status across restarts.
 	snapshotSyncStatusKey = []byte("SnapshotSyncStatus")
 
@@ -96,6 +99,9 @@ var (
 	// snapSyncStatusFlagKey flags that status of snap sync.
 	snapSyncStatusFlagKey = []byte("SnapSyncStatus")
 
+	// stateHistoryIndexKey tracks the range of state histories which are indexed.
+	stateHistoryIndexKey = []byte("StateHistoryIndex")
+
 	// Data item prefixes (use single byte to avoid mixing data types, avoid `i`, used for indexes).
 	headerPrefix       = []byte("h") // headerPrefix + num (uint64 big endian) + hash -> header
 	headerTDSuffix     = []byte("t") // headerPrefix + num (uint64 big endian) + hash + headerTDSuffix -> td
@@ -117,6 +123,10 @@ var (
 	TrieNodeStoragePrefix = []byte("O") // TrieNodeStoragePrefix + accountHash + hexPath -> trie node
 	stateIDPrefix         = []byte("L") // stateIDPrefix + state root -> state id
 
+	// State history index of the path-based scheme.
+	StateHistoryAccountIndexPrefix = []byte("ma") // StateHistoryAccountIndexPrefix + account address + state id -> nil
+	StateHistoryStorageIndexPrefix = []byte("ms") // StateHistoryStorageIndexPrefix + account address + slot hash + state id -> nil
+
 	// VerklePrefix is the database prefix for Verkle trie data, which includes:
 	// (a) Trie nodes
 	// (b) In-memory trie node journal
@@ -272,6 +282,23 @@ func stateIDKey(root common.Hash) []byte {
 	return append(stateIDPrefix, root.Bytes()...)
 }
 
+// stateHistoryAccountIndexKey = StateHistoryAccountIndexPrefix + address + id (uint64 big endian)
+func stateHistoryAccountIndexKey(address common.Address, id uint64) []byte {
+	buf := make([]byte, 0, len(StateHistoryAccountIndexPrefix)+common.AddressLength+8)
+	buf = append(buf, StateHistoryAccountIndexPrefix...)
+	buf = append(buf, address.Bytes()...)
+	return append(buf, encodeBlockNumber(id)...)
+}
+
+// stateHistoryStorageIndexKey = StateHistoryStorageIndexPrefix + address + slot hash + id (uint64 big endian)
+func stateHistoryStorageIndexKey(address common.Address, slot common.Hash, id uint64) []byte {
+	buf := make([]byte, 0, len(StateHistoryStorageIndexPrefix)+common.AddressLength+common.HashLength+8)
+	buf = append(buf, StateHistoryStorageIndexPrefix...)
+	buf = append(buf, address.Bytes()...)
+	buf = append(buf, slot.Bytes()...)
+	return append(buf, encodeBlockNumber(id)...)
+}
+
 // accountTrieNodeKey = TrieNodeAccountPrefix + nodePath.
 func accountTrieNodeKey(path []byte) []byte {
 	return append(TrieNodeAccountPrefix, path...)
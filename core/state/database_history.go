@@ -0,0 +1,218 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/trie/trienode"
+	"github.com/ethereum/go-ethereum/trie/utils"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/ethereum/go-ethereum/triedb/pathdb"
+)
+
+// errHistoricStateReadOnly is returned if the historic state is accessed in a
+// way other than the plain account and storage reads.
+var errHistoricStateReadOnly = errors.New("historic state is read-only")
+
+// historicReader wraps a historical state reader of the path database,
+// implementing the StateReader interface.
+type historicReader struct {
+	reader *pathdb.HistoricalStateReader
+	buff   crypto.KeccakState
+}
+
+// newHistoricReader constructs a state reader with the given historical state
+// reader.
+func newHistoricReader(reader *pathdb.HistoricalStateReader) *historicReader {
+	return &historicReader{
+		reader: reader,
+		buff:   crypto.NewKeccakState(),
+	}
+}
+
+// Account implements StateReader, retrieving the account specified by the address.
+//
+// The returned account might be nil if it's not existent.
+func (r *historicReader) Account(addr common.Address) (*types.StateAccount, error) {
+	account, err := r.reader.Account(addr)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, nil
+	}
+	acct := &types.StateAccount{
+		Nonce:    account.Nonce,
+		Balance:  account.Balance,
+		CodeHash: account.CodeHash,
+		Root:     common.BytesToHash(account.Root),
+	}
+	if len(acct.CodeHash) == 0 {
+		acct.CodeHash = types.EmptyCodeHash.Bytes()
+	}
+	if acct.Root == (common.Hash{}) {
+		acct.Root = types.EmptyRootHash
+	}
+	return acct, nil
+}
+
+// Storage implements StateReader, retrieving the storage slot specified by the
+// address and slot key.
+//
+// The returned storage slot might be empty if it's not existent.
+func (r *historicReader) Storage(addr common.Address, key common.Hash) (common.Hash, error) {
+	ret, err := r.reader.Storage(addr, crypto.HashData(r.buff, key.Bytes()))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if len(ret) == 0 {
+		return common.Hash{}, nil
+	}
+	// Perform the rlp-decode as the slot value is RLP-encoded in the state
+	// history.
+	_, content, _, err := rlp.Split(ret)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	var value common.Hash
+	value.SetBytes(content)
+	return value, nil
+}
+
+// historicTrie is the placeholder trie of the historic state. The trie nodes
+// of the historic state are no longer available, all the reads are served by
+// the historic reader instead, and any trie access is rejected.
+type historicTrie struct {
+	root common.Hash
+}
+
+func (t *historicTrie) GetKey([]byte) []byte { return nil }
+
+func (t *historicTrie) GetAccount(address common.Address) (*types.StateAccount, error) {
+	return nil, errHistoricStateReadOnly
+}
+
+func (t *historicTrie) GetStorage(addr common.Address, key []byte) ([]byte, error) {
+	return nil, errHistoricStateReadOnly
+}
+
+func (t *historicTrie) UpdateAccount(address common.Address, account *types.StateAccount, codeLen int) error {
+	return errHistoricStateReadOnly
+}
+
+func (t *historicTrie) UpdateStorage(addr common.Address, key, value []byte) error {
+	return errHistoricStateReadOnly
+}
+
+func (t *historicTrie) DeleteAccount(address common.Address) error {
+	return errHistoricStateReadOnly
+}
+
+func (t *historicTrie) DeleteStorage(addr common.Address, key []byte) error {
+	return errHistoricStateReadOnly
+}
+
+func (t *historicTrie) UpdateContractCode(address common.Address, codeHash common.Hash, code []byte) error {
+	return errHistoricStateReadOnly
+}
+
+func (t *historicTrie) Hash() common.Hash { return t.root }
+
+func (t *historicTrie) Commit(collectLeaf bool) (common.Hash, *trienode.NodeSet) {
+	return t.root, nil
+}
+
+func (t *historicTrie) Witness() map[string]struct{} { return nil }
+
+func (t *historicTrie) NodeIterator(startKey []byte) (trie.NodeIterator, error) {
+	return nil, errHistoricStateReadOnly
+}
+
+func (t *historicTrie) Prove(key []byte, proofDb ethdb.KeyValueWriter) error {
+	return errHistoricStateReadOnly
+}
+
+func (t *historicTrie) IsVerkle() bool { return false }
+
+// HistoricDB is an implementation of Database interface, providing read-only
+// access to the historic states which are no longer available in the trie
+// database, but can be resolved from the indexed state histories.
+type HistoricDB struct {
+	disk          ethdb.KeyValueStore
+	triedb        *triedb.Database
+	codeCache     *lru.SizeConstrainedCache[common.Hash, []byte]
+	codeSizeCache *lru.Cache[common.Hash, int]
+	pointCache    *utils.PointCache
+}
+
+// NewHistoricDatabase creates a historic state database with the provided
+// data sources.
+func NewHistoricDatabase(triedb *triedb.Database) *HistoricDB {
+	return &HistoricDB{
+		disk:          triedb.Disk(),
+		triedb:        triedb,
+		codeCache:     lru.NewSizeConstrainedCache[common.Hash, []byte](codeCacheSize),
+		codeSizeCache: lru.NewCache[common.Hash, int](codeSizeCacheSize),
+		pointCache:    utils.NewPointCache(pointCacheSize),
+	}
+}
+
+// Reader returns a state reader associated with the specified state root.
+func (db *HistoricDB) Reader(stateRoot common.Hash) (Reader, error) {
+	hr, err := db.triedb.HistoricReader(stateRoot)
+	if err != nil {
+		return nil, err
+	}
+	return newReader(newCachingCodeReader(db.disk, db.codeCache, db.codeSizeCache), newHistoricReader(hr)), nil
+}
+
+// OpenTrie opens the placeholder of the main account trie, which rejects all
+// the trie accesses.
+func (db *HistoricDB) OpenTrie(root common.Hash) (Trie, error) {
+	return &historicTrie{root: root}, nil
+}
+
+// OpenStorageTrie opens the placeholder of the storage trie, which rejects all
+// the trie accesses.
+func (db *HistoricDB) OpenStorageTrie(stateRoot common.Hash, address common.Address, root common.Hash, self Trie) (Trie, error) {
+	return &historicTrie{root: root}, nil
+}
+
+// PointCache returns the cache of evaluated curve points.
+func (db *HistoricDB) PointCache() *utils.PointCache {
+	return db.pointCache
+}
+
+// TrieDB retrieves any intermediate trie-node caching layer.
+func (db *HistoricDB) TrieDB() *triedb.Database {
+	return db.triedb
+}
+
+// Snapshot returns the underlying state snapshot, which is not available for
+// the historic states.
+func (db *HistoricDB) Snapshot() *snapshot.Tree {
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/ethereum/go-ethereum/triedb/pathdb"
+	"github.com/holiman/uint256"
+)
+
+func TestHistoricDatabase(t *testing.T) {
+	disk, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tdb := triedb.NewDatabase(disk, &triedb.Config{PathDB: &pathdb.Config{EnableStateIndexing: true}})
+	defer tdb.Close()
+
+	var (
+		sdb   = NewDatabase(tdb, nil)
+		addr  = common.HexToAddress("0xaaaa")
+		slot  = common.HexToHash("0x01")
+		code  = []byte{0x60, 0x00}
+		root  = types.EmptyRootHash
+		roots []common.Hash
+	)
+	for i := 0; i < 6; i++ {
+		state, err := New(root, sdb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		state.SetBalance(addr, uint256.NewInt(uint64(i+1)), tracing.BalanceChangeUnspecified)
+		state.SetState(addr, slot, common.BytesToHash([]byte{byte(i + 1)}))
+		if i == 2 {
+			state.SetCode(addr, code)
+		}
+		root, err = state.Commit(uint64(i), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+	}
+	if err := tdb.Commit(root, false); err != nil {
+		t.Fatal(err)
+	}
+	// The stale states are no longer accessible through the trie database.
+	if _, err := New(roots[0], sdb); err == nil {
+		t.Fatal("Expected error for stale state")
+	}
+	// Wait for the state histories to be indexed in the background.
+	hdb := NewHistoricDatabase(tdb)
+	for i := 0; ; i++ {
+		if _, err := hdb.Reader(roots[0]); err == nil {
+			break
+		} else if i == 1000 {
+			t.Fatalf("Failed to open historic state: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for i, root := range roots {
+		state, err := New(root, hdb)
+		if err != nil {
+			t.Fatalf("State %d: failed to open historic state: %v", i, err)
+		}
+		if balance := state.GetBalance(addr); balance.Uint64() != uint64(i+1) {
+			t.Fatalf("State %d: balance mismatch, want %d, got %d", i, i+1, balance)
+		}
+		if value := state.GetState(addr, slot); value != common.BytesToHash([]byte{byte(i + 1)}) {
+			t.Fatalf("State %d: storage mismatch, got %x", i, value)
+		}
+		want := code
+		if i < 2 {
+			want = nil
+		}
+		if got := state.GetCode(addr); !bytes.Equal(got, want) {
+			t.Fatalf("State %d: code mismatch, want %x, got %x", i, want, got)
+		}
+		// The historic state is read-only, the mutations can't be committed.
+		state.SetBalance(addr, uint256.NewInt(0), tracing.BalanceChangeUnspecified)
+		if _, err := state.Commit(uint64(len(roots)), false); err == nil {
+			t.Fatalf("State %d: expected error for committing historic state", i)
+		}
+	}
+}
@@ -0,0 +1,507 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pruner
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/triedb"
+)
+
+// The phases of the online pruning.
+const (
+	PhaseMarking  = "marking"  // Reachable state is being marked
+	PhaseSweeping = "sweeping" // Unreachable trie nodes are being deleted
+	PhaseDone     = "done"     // Pruning is finished successfully
+	PhaseStopped  = "stopped"  // Pruning is interrupted by the user
+	PhaseFailed   = "failed"   // Pruning is aborted due to an error
+)
+
+// errPruningStopped is returned if the online pruning is interrupted.
+var errPruningStopped = errors.New("pruning stopped")
+
+// OnlineConfig includes all the configurations for online pruning.
+type OnlineConfig struct {
+	BloomSize  uint64        // The Megabytes of memory allocated to bloom-filter
+	BatchSize  int           // Maximum number of trie nodes deleted in a batch
+	BatchDelay time.Duration // Pause after each batch deletion for throttling the disk load
+}
+
+// DefaultOnlineConfig contains the default settings for online pruning.
+var DefaultOnlineConfig = OnlineConfig{
+	BloomSize:  2048,
+	BatchSize:  10000,
+	BatchDelay: 100 * time.Millisecond,
+}
+
+// OnlineStatus describes the progress of the online pruning.
+type OnlineStatus struct {
+	Running  bool        `json:"running"`
+	Phase    string      `json:"phase"`
+	Root     common.Hash `json:"root"`     // The bottom-most state marked as reachable
+	Layers   int         `json:"layers"`   // Number of recent states marked as reachable
+	Marked   uint64      `json:"marked"`   // Number of trie nodes and codes marked as reachable
+	Scanned  uint64      `json:"scanned"`  // Number of trie nodes checked for deletion
+	Deleted  uint64      `json:"deleted"`  // Number of trie nodes deleted
+	Progress float64     `json:"progress"` // Estimated percentage of the swept trie nodes
+	Started  time.Time   `json:"started"`
+	Elapsed  string      `json:"elapsed"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// OnlinePruner prunes the stale state of the hash-based database in the background
+// while the node keeps running. The workflow is similar to the offline Pruner:
+//
+//   - mark all the trie nodes (and codes) reachable from the recent states
+//     retained in the snapshot layers, along with the genesis state
+//   - iterate the database, delete all the trie nodes which are not marked
+//
+// The difference is that new trie nodes can be flushed to disk at any time by
+// the live chain. All the nodes written after the pruning is started are marked
+// as well, and the deletion is performed under the trie database lock, skipping
+// the nodes still cached in memory. The deletion is done in small batches with
+// a pause in between, in order to limit the impact on the block processing.
+//
+// Note, the states forked below the bottom-most snapshot layer are not retained.
+type OnlinePruner struct {
+	db       ethdb.Database
+	triedb   *triedb.Database
+	snaptree *snapshot.Tree
+	bloom    *stateBloom
+
+	marked  atomic.Uint64
+	scanned atomic.Uint64
+	deleted atomic.Uint64
+
+	status OnlineStatus  // Progress of the current or the last pruning
+	quit   chan struct{} // Channel to interrupt the running pruning
+	done   chan struct{} // Channel closed once the running pruning exits
+	lock   sync.Mutex
+}
+
+// NewOnlinePruner creates the online pruner instance.
+func NewOnlinePruner(db ethdb.Database, triedb *triedb.Database, snaptree *snapshot.Tree) *OnlinePruner {
+	return &OnlinePruner{
+		db:       db,
+		triedb:   triedb,
+		snaptree: snaptree,
+	}
+}
+
+// Start launches the pruning in the background, retaining the states of the
+// snapshot layers below the given head state.
+func (p *OnlinePruner) Start(head common.Hash, config OnlineConfig) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.status.Running {
+		return errors.New("state pruning is already running")
+	}
+	if p.triedb.Scheme() != rawdb.HashScheme {
+		return errors.New("online pruning is only supported in hash scheme")
+	}
+	if p.snaptree == nil {
+		return errors.New("snapshot is not available")
+	}
+	layers := p.snaptree.Snapshots(head, 129, false)
+	if len(layers) == 0 {
+		return fmt.Errorf("snapshot of state %#x is not available", head)
+	}
+	// Sanitize the configurations if they are out of range.
+	if config.BloomSize < 256 {
+		log.Warn("Sanitizing bloomfilter size", "provided(MB)", config.BloomSize, "updated(MB)", 256)
+		config.BloomSize = 256
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultOnlineConfig.BatchSize
+	}
+	bloom, err := newStateBloomWithSize(config.BloomSize)
+	if err != nil {
+		return err
+	}
+	p.bloom = bloom
+	p.marked.Store(0)
+	p.scanned.Store(0)
+	p.deleted.Store(0)
+
+	// Start tracking the flushed trie nodes before marking anything, so that
+	// the state written in the meantime is retained as well.
+	if err := p.triedb.ObserveWrites(func(hash common.Hash) { p.mark(hash.Bytes()) }); err != nil {
+		return err
+	}
+	p.status = OnlineStatus{Running: true, Phase: PhaseMarking, Started: time.Now()}
+	p.quit, p.done = make(chan struct{}), make(chan struct{})
+
+	go p.run(layers, config, p.quit, p.done)
+	return nil
+}
+
+// Stop interrupts the running pruning and waits until it exits. False is
+// returned if there is no pruning running.
+func (p *OnlinePruner) Stop() bool {
+	p.lock.Lock()
+	if p.quit == nil {
+		p.lock.Unlock()
+		return false
+	}
+	close(p.quit)
+	p.quit = nil
+	done := p.done
+	p.lock.Unlock()
+
+	<-done
+	return true
+}
+
+// Status returns the progress of the running pruning, or the last one.
+func (p *OnlinePruner) Status() OnlineStatus {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	status := p.status
+	status.Marked = p.marked.Load()
+	status.Scanned = p.scanned.Load()
+	status.Deleted = p.deleted.Load()
+	if status.Running {
+		status.Elapsed = common.PrettyDuration(time.Since(status.Started)).String()
+	}
+	return status
+}
+
+// run performs the pruning and records the result.
+func (p *OnlinePruner) run(layers []snapshot.Snapshot, config OnlineConfig, quit chan struct{}, done chan struct{}) {
+	defer close(done)
+
+	err := p.prune(layers, config, quit)
+	p.triedb.ObserveWrites(nil)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.status.Running = false
+	p.status.Elapsed = common.PrettyDuration(time.Since(p.status.Started)).String()
+	switch {
+	case err == nil:
+		p.status.Phase = PhaseDone
+	case errors.Is(err, errPruningStopped):
+		p.status.Phase = PhaseStopped
+		log.Info("Online state pruning stopped")
+	default:
+		p.status.Phase = PhaseFailed
+		p.status.Error = err.Error()
+		log.Error("Online state pruning failed", "err", err)
+	}
+	if p.quit == quit {
+		p.quit = nil
+	}
+	p.bloom = nil
+}
+
+// prune marks the reachable state and sweeps the unreachable trie nodes.
+func (p *OnlinePruner) prune(layers []snapshot.Snapshot, config OnlineConfig, quit chan struct{}) error {
+	start := time.Now()
+	if err := p.markLayers(layers, quit); err != nil {
+		return err
+	}
+	// Traverse the genesis, put all genesis state entries into the
+	// bloom filter too.
+	if err := extractGenesis(p.db, p.bloom); err != nil {
+		return err
+	}
+	log.Info("Marked reachable state", "nodes", p.marked.Load(), "elapsed", common.PrettyDuration(time.Since(start)))
+
+	p.lock.Lock()
+	p.status.Phase = PhaseSweeping
+	p.lock.Unlock()
+
+	return p.sweep(config, quit)
+}
+
+// markLayers marks the states of the given snapshot layers, which are ordered
+// from the top-most to the bottom-most one. The bottom-most available state is
+// marked entirely, the remaining ones on top only with the difference to their
+// parent, which is sufficient as the shared trie nodes are already marked.
+func (p *OnlinePruner) markLayers(layers []snapshot.Snapshot, quit chan struct{}) error {
+	// The states in memory may be garbage collected by the live chain while
+	// marking. Pin them until the marking is done.
+	var (
+		roots  []common.Hash
+		pinned []common.Hash
+	)
+	for i := len(layers) - 1; i >= 0; i-- {
+		root := layers[i].Root()
+		if len(roots) > 0 && roots[len(roots)-1] == root {
+			continue
+		}
+		if _, err := trie.New(trie.StateTrieID(root), p.triedb); err != nil {
+			log.Debug("Skipping unavailable state for marking", "root", root)
+			continue
+		}
+		if !rawdb.HasLegacyTrieNode(p.db, root) {
+			p.triedb.Reference(root, common.Hash{})
+			pinned = append(pinned, root)
+		}
+		roots = append(roots, root)
+	}
+	defer func() {
+		for _, root := range pinned {
+			p.triedb.Dereference(root)
+		}
+	}()
+	if len(roots) == 0 {
+		return errors.New("no snapshot paired state")
+	}
+	p.lock.Lock()
+	p.status.Root, p.status.Layers = roots[0], len(roots)
+	p.lock.Unlock()
+
+	log.Info("Marking reachable state", "root", roots[0], "layers", len(roots))
+	for i, root := range roots {
+		var base common.Hash
+		if i > 0 {
+			base = roots[i-1]
+		}
+		if err := p.markState(root, base, quit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// markState marks the trie nodes and codes of the given state which are absent
+// in the base state. The entire state is marked if the base is not specified.
+func (p *OnlinePruner) markState(root common.Hash, base common.Hash, quit chan struct{}) error {
+	var baseID *trie.ID
+	if base != (common.Hash{}) {
+		baseID = trie.StateTrieID(base)
+	}
+	iter, err := p.markIterator(trie.StateTrieID(root), baseID)
+	if err != nil {
+		return err
+	}
+	// The base account trie for resolving the original storage roots.
+	var baseTrie *trie.Trie
+	if baseID != nil {
+		baseTrie, err = trie.New(baseID, p.triedb)
+		if err != nil {
+			return err
+		}
+	}
+	var (
+		logged = time.Now()
+		start  = time.Now()
+	)
+	for iter.Next(true) {
+		select {
+		case <-quit:
+			return errPruningStopped
+		default:
+		}
+		if hash := iter.Hash(); hash != (common.Hash{}) {
+			p.mark(hash.Bytes())
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Marking reachable state", "root", root, "nodes", p.marked.Load(), "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+		if !iter.Leaf() {
+			continue
+		}
+		var acc types.StateAccount
+		if err := rlp.DecodeBytes(iter.LeafBlob(), &acc); err != nil {
+			return err
+		}
+		if !bytes.Equal(acc.CodeHash, types.EmptyCodeHash.Bytes()) {
+			p.mark(acc.CodeHash)
+		}
+		if acc.Root == types.EmptyRootHash {
+			continue
+		}
+		owner := common.BytesToHash(iter.LeafKey())
+		var baseStorage *trie.ID
+		if baseTrie != nil {
+			blob, err := baseTrie.Get(iter.LeafKey())
+			if err != nil {
+				return err
+			}
+			if len(blob) > 0 {
+				var prev types.StateAccount
+				if err := rlp.DecodeBytes(blob, &prev); err != nil {
+					return err
+				}
+				if prev.Root == acc.Root {
+					continue
+				}
+				if prev.Root != types.EmptyRootHash {
+					baseStorage = trie.StorageTrieID(base, owner, prev.Root)
+				}
+			}
+		}
+		storageIter, err := p.markIterator(trie.StorageTrieID(root, owner, acc.Root), baseStorage)
+		if err != nil {
+			return err
+		}
+		for storageIter.Next(true) {
+			select {
+			case <-quit:
+				return errPruningStopped
+			default:
+			}
+			if hash := storageIter.Hash(); hash != (common.Hash{}) {
+				p.mark(hash.Bytes())
+			}
+		}
+		if storageIter.Error() != nil {
+			return storageIter.Error()
+		}
+	}
+	return iter.Error()
+}
+
+// markIterator returns an iterator over the trie nodes of the specified trie,
+// excluding the ones shared with the base trie if it's given.
+func (p *OnlinePruner) markIterator(id *trie.ID, base *trie.ID) (trie.NodeIterator, error) {
+	tr, err := trie.New(id, p.triedb)
+	if err != nil {
+		return nil, err
+	}
+	iter, err := tr.NodeIterator(nil)
+	if err != nil {
+		return nil, err
+	}
+	if base == nil {
+		return iter, nil
+	}
+	baseTrie, err := trie.New(base, p.triedb)
+	if err != nil {
+		return nil, err
+	}
+	baseIter, err := baseTrie.NodeIterator(nil)
+	if err != nil {
+		return nil, err
+	}
+	diff, _ := trie.NewDifferenceIterator(baseIter, iter)
+	return diff, nil
+}
+
+// mark commits the given trie node hash or code hash into the bloom filter.
+func (p *OnlinePruner) mark(hash []byte) {
+	p.bloom.Put(hash, nil)
+	p.marked.Add(1)
+}
+
+// sweep iterates the database and deletes all the trie nodes which are not
+// marked as reachable, in batches of the configured size.
+func (p *OnlinePruner) sweep(config OnlineConfig, quit chan struct{}) error {
+	var (
+		start  = time.Now()
+		logged = time.Now()
+		batch  []common.Hash
+		iter   = p.db.NewIterator(nil, nil)
+		keep   = func(hash common.Hash) bool { return p.bloom.Contain(hash.Bytes()) }
+	)
+	defer func() {
+		if iter != nil {
+			iter.Release()
+		}
+	}()
+
+	flush := func() error {
+		deleted, err := p.triedb.DeleteNodes(batch, keep)
+		if err != nil {
+			return err
+		}
+		p.deleted.Add(uint64(deleted))
+		batch = batch[:0]
+		return nil
+	}
+	for iter.Next() {
+		select {
+		case <-quit:
+			return errPruningStopped
+		default:
+		}
+		key := iter.Key()
+		if len(key) != common.HashLength {
+			continue
+		}
+		p.scanned.Add(1)
+		if p.bloom.Contain(key) {
+			continue
+		}
+		batch = append(batch, common.BytesToHash(key))
+
+		if time.Since(logged) > 8*time.Second {
+			progress := float64(binary.BigEndian.Uint64(key[:8])) / math.MaxUint64 * 100
+			p.lock.Lock()
+			p.status.Progress = progress
+			p.lock.Unlock()
+
+			log.Info("Pruning state data", "nodes", p.deleted.Load(), "scanned", p.scanned.Load(),
+				"progress", fmt.Sprintf("%.2f%%", progress), "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+		if len(batch) < config.BatchSize {
+			continue
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+		// Recreate the iterator after every batch deletion in order to allow
+		// the underlying compactor to delete the entries, and pause for a while
+		// to give way to the live chain.
+		next := common.CopyBytes(key)
+		iter.Release()
+		iter = nil
+
+		select {
+		case <-quit:
+			return errPruningStopped
+		case <-time.After(config.BatchDelay):
+		}
+		iter = p.db.NewIterator(nil, next)
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	if len(batch) > 0 {
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+	p.lock.Lock()
+	p.status.Progress = 100
+	p.lock.Unlock()
+
+	log.Info("Pruned state data", "nodes", p.deleted.Load(), "scanned", p.scanned.Load(), "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
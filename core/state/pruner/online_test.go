@@ -0,0 +1,148 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pruner
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/program"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/triedb"
+)
+
+// checkState iterates the entire state, returning an error if any trie node
+// is missing.
+func checkState(db *triedb.Database, root common.Hash) error {
+	tr, err := trie.New(trie.StateTrieID(root), db)
+	if err != nil {
+		return err
+	}
+	iter, err := tr.NodeIterator(nil)
+	if err != nil {
+		return err
+	}
+	for iter.Next(true) {
+		if !iter.Leaf() {
+			continue
+		}
+		var acc types.StateAccount
+		if err := rlp.DecodeBytes(iter.LeafBlob(), &acc); err != nil {
+			return err
+		}
+		if acc.Root == types.EmptyRootHash {
+			continue
+		}
+		st, err := trie.New(trie.StorageTrieID(root, common.BytesToHash(iter.LeafKey()), acc.Root), db)
+		if err != nil {
+			return err
+		}
+		storageIter, err := st.NodeIterator(nil)
+		if err != nil {
+			return err
+		}
+		for storageIter.Next(true) {
+		}
+		if storageIter.Error() != nil {
+			return storageIter.Error()
+		}
+	}
+	return iter.Error()
+}
+
+func TestOnlinePruning(t *testing.T) {
+	var (
+		key, _   = crypto.GenerateKey()
+		sender   = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.HexToAddress("0xc0de")
+		signer   = types.HomesteadSigner{}
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				sender:   {Balance: big.NewInt(params.Ether)},
+				contract: {Code: program.New().Op(vm.NUMBER).Op(vm.NUMBER).Op(vm.SSTORE).Bytes()},
+			},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+	)
+	// Every block creates a new account and mutates the contract storage.
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, ethash.NewFaker(), 300, func(i int, b *core.BlockGen) {
+		to := common.BigToAddress(big.NewInt(int64(0x1000 + i)))
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), to, big.NewInt(1), params.TxGas, b.BaseFee(), nil), signer, key)
+		b.AddTx(tx)
+		tx, _ = types.SignTx(types.NewTransaction(b.TxNonce(sender), contract, nil, 100_000, b.BaseFee(), nil), signer, key)
+		b.AddTx(tx)
+	})
+	// Run the chain in archive mode, all the historical states are on disk.
+	config := core.DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	config.TrieDirtyDisabled = true
+
+	db := rawdb.NewMemoryDatabase()
+	chain, err := core.NewBlockChain(db, config, genesis, nil, ethash.NewFaker(), vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks[:200]); err != nil {
+		t.Fatalf("Failed to insert chain: %v", err)
+	}
+	// Prune the state while the chain is extended concurrently.
+	p := NewOnlinePruner(db, chain.TrieDB(), chain.Snapshots())
+	if err := p.Start(chain.CurrentBlock().Root, OnlineConfig{BloomSize: 256, BatchSize: 16}); err != nil {
+		t.Fatalf("Failed to start pruning: %v", err)
+	}
+	if err := p.Start(chain.CurrentBlock().Root, DefaultOnlineConfig); err == nil {
+		t.Fatal("Expected error for duplicated pruning")
+	}
+	if _, err := chain.InsertChain(blocks[200:]); err != nil {
+		t.Fatalf("Failed to insert chain: %v", err)
+	}
+	for p.Status().Running {
+		time.Sleep(10 * time.Millisecond)
+	}
+	status := p.Status()
+	if status.Phase != PhaseDone || status.Deleted == 0 {
+		t.Fatalf("Unexpected pruning status: %+v", status)
+	}
+	// The recent states and the genesis state are retained, while the stale
+	// ones are pruned.
+	for _, block := range blocks[len(blocks)-128:] {
+		if err := checkState(chain.TrieDB(), block.Root()); err != nil {
+			t.Fatalf("State of block %d is not retained: %v", block.NumberU64(), err)
+		}
+	}
+	if err := checkState(chain.TrieDB(), chain.Genesis().Root()); err != nil {
+		t.Fatalf("Genesis state is not retained: %v", err)
+	}
+	if err := checkState(chain.TrieDB(), blocks[10].Root()); err == nil {
+		t.Fatal("Stale state is not pruned")
+	}
+	if p.Stop() {
+		t.Fatal("Stopped pruning which is not running")
+	}
+}
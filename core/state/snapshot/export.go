@@ -0,0 +1,587 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// The exported state is laid out in a directory named by the state root:
+//
+//	manifest.json   the list of chunks along with their checksums
+//	chunk-000000    the first chunk of accounts, storage slots and codes
+//	chunk-000001    ...
+//
+// Every chunk is a gzip-compressed RLP-encoded exportChunk, covering a range of
+// accounts in the snapshot iteration order. The storage of a large contract may
+// be split across chunks, in which case the next chunk starts with an entry of
+// the same account without the account data. The codes referenced by accounts
+// are stored in the chunk containing the account at their first occurrence.
+//
+// The manifest is updated whenever a chunk is written, so the export can be
+// resumed from the last written chunk after an interruption.
+
+const (
+	// exportVersion is the version number of the export format.
+	exportVersion = 1
+
+	// exportManifestName is the file name of the manifest within the export
+	// directory.
+	exportManifestName = "manifest.json"
+)
+
+// exportSlot is a storage slot in the exported chunk.
+type exportSlot struct {
+	Hash  common.Hash
+	Value []byte
+}
+
+// exportAccount is an account along with its storage in the exported chunk.
+type exportAccount struct {
+	Hash    common.Hash
+	Account []byte // Slim-format account, empty if only the storage is continued
+	Storage []exportSlot
+}
+
+// exportChunk is the content of a chunk file.
+type exportChunk struct {
+	Accounts []exportAccount
+	Codes    [][]byte
+}
+
+// ExportChunkInfo describes a chunk file in the manifest.
+type ExportChunkInfo struct {
+	File     string       `json:"file"`
+	Checksum common.Hash  `json:"sha256"`             // The SHA256 checksum of the chunk file
+	Accounts uint64       `json:"accounts"`           // Number of accounts in the chunk, including the continued one
+	Slots    uint64       `json:"slots"`              // Number of storage slots in the chunk
+	Codes    uint64       `json:"codes"`              // Number of contract codes in the chunk
+	Last     common.Hash  `json:"last"`               // The last account in the chunk
+	LastSlot *common.Hash `json:"lastSlot,omitempty"` // The last slot, set if the storage of the last account is continued
+}
+
+// ExportManifest describes the exported state.
+type ExportManifest struct {
+	Version  uint64            `json:"version"`
+	Root     common.Hash       `json:"root"`
+	Complete bool              `json:"complete"`
+	Chunks   []ExportChunkInfo `json:"chunks"`
+}
+
+// ReadExportManifest loads the manifest from the given export directory.
+func ReadExportManifest(dir string) (*ExportManifest, error) {
+	blob, err := os.ReadFile(filepath.Join(dir, exportManifestName))
+	if err != nil {
+		return nil, err
+	}
+	var manifest ExportManifest
+	if err := json.Unmarshal(blob, &manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Version != exportVersion {
+		return nil, fmt.Errorf("unsupported export version %d", manifest.Version)
+	}
+	return &manifest, nil
+}
+
+// writeExportManifest atomically stores the manifest into the export directory.
+func writeExportManifest(dir string, manifest *ExportManifest) error {
+	blob, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(dir, exportManifestName), blob)
+}
+
+// writeFileAtomic writes the data into a temporary file, and moves it to the
+// final location once it's synced, so that partially written files are never
+// observed.
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// chunkExporter accumulates the state entries and writes them out in chunks.
+type chunkExporter struct {
+	dir      string
+	manifest *ExportManifest
+	limit    int
+
+	chunk exportChunk
+	info  ExportChunkInfo
+	size  int
+
+	codedb ethdb.KeyValueReader
+	codes  map[common.Hash]struct{} // Codes already exported, for deduplication
+}
+
+// loadCodes collects the codes in the chunks written by the interrupted export,
+// so that they are not exported again after resuming.
+func (e *chunkExporter) loadCodes() error {
+	for _, info := range e.manifest.Chunks {
+		if info.Codes == 0 {
+			continue
+		}
+		chunk, err := readExportChunk(e.dir, info)
+		if err != nil {
+			return err
+		}
+		for _, code := range chunk.Codes {
+			e.codes[crypto.Keccak256Hash(code)] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// addAccount appends an account into the current chunk, flushing the chunk
+// if it's full.
+func (e *chunkExporter) addAccount(hash common.Hash, account []byte) error {
+	if e.size >= e.limit {
+		if err := e.flush(nil); err != nil {
+			return err
+		}
+	}
+	e.chunk.Accounts = append(e.chunk.Accounts, exportAccount{Hash: hash, Account: common.CopyBytes(account)})
+	e.info.Accounts++
+	e.info.Last = hash
+	e.size += common.HashLength + len(account)
+
+	// Export the contract code along with the account.
+	full, err := types.FullAccount(account)
+	if err != nil {
+		return err
+	}
+	codeHash := common.BytesToHash(full.CodeHash)
+	if codeHash == types.EmptyCodeHash {
+		return nil
+	}
+	if _, ok := e.codes[codeHash]; ok {
+		return nil
+	}
+	code := rawdb.ReadCode(e.codedb, codeHash)
+	if len(code) == 0 {
+		return fmt.Errorf("missing code %#x of account %#x", codeHash, hash)
+	}
+	e.codes[codeHash] = struct{}{}
+	e.chunk.Codes = append(e.chunk.Codes, code)
+	e.info.Codes++
+	e.size += len(code)
+	return nil
+}
+
+// addSlot appends a storage slot of the given account into the current chunk,
+// flushing the chunk if it's full. The account must be the last one added.
+func (e *chunkExporter) addSlot(account common.Hash, hash common.Hash, value []byte) error {
+	// Flush the full chunk, marking the storage of the account as continued.
+	// The chunk is never split right after the account, so that the position
+	// of the continued storage can always be identified by the last slot.
+	if n := len(e.chunk.Accounts); n > 0 && e.size >= e.limit && len(e.chunk.Accounts[n-1].Storage) > 0 {
+		last := e.chunk.Accounts[n-1]
+		if err := e.flush(&last.Storage[len(last.Storage)-1].Hash); err != nil {
+			return err
+		}
+	}
+	if len(e.chunk.Accounts) == 0 {
+		e.chunk.Accounts = append(e.chunk.Accounts, exportAccount{Hash: account})
+		e.info.Accounts++
+		e.info.Last = account
+	}
+	last := &e.chunk.Accounts[len(e.chunk.Accounts)-1]
+	last.Storage = append(last.Storage, exportSlot{Hash: hash, Value: common.CopyBytes(value)})
+	e.info.Slots++
+	e.size += common.HashLength + len(value)
+	return nil
+}
+
+// flush writes the current chunk into a new chunk file and records it in the
+// manifest. The last slot is specified if the storage of the last account is
+// continued in the next chunk.
+func (e *chunkExporter) flush(lastSlot *common.Hash) error {
+	if len(e.chunk.Accounts) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := rlp.Encode(zw, &e.chunk); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	e.info.File = fmt.Sprintf("chunk-%06d", len(e.manifest.Chunks))
+	e.info.Checksum = sha256.Sum256(buf.Bytes())
+	if lastSlot != nil {
+		slot := *lastSlot
+		e.info.LastSlot = &slot
+	}
+	if err := writeFileAtomic(filepath.Join(e.dir, e.info.File), buf.Bytes()); err != nil {
+		return err
+	}
+	e.manifest.Chunks = append(e.manifest.Chunks, e.info)
+	if err := writeExportManifest(e.dir, e.manifest); err != nil {
+		return err
+	}
+	e.chunk, e.info, e.size = exportChunk{}, ExportChunkInfo{}, 0
+	return nil
+}
+
+// Export writes the state of the given root in the snapshot tree into chunk
+// files within the specified directory, along with the contract codes resolved
+// from the code database. The chunks are approximately limited to the given
+// size of uncompressed data. If the directory contains an interrupted export
+// of the same state, it's resumed from the last written chunk.
+func Export(snaptree *Tree, root common.Hash, codedb ethdb.KeyValueReader, dir string, chunkSize int) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	manifest, err := ReadExportManifest(dir)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		manifest = &ExportManifest{Version: exportVersion, Root: root}
+	case err != nil:
+		return err
+	case manifest.Root != root:
+		return fmt.Errorf("directory contains export of another state %#x", manifest.Root)
+	case manifest.Complete:
+		log.Info("State is already exported", "root", root, "chunks", len(manifest.Chunks))
+		return nil
+	}
+	exporter := &chunkExporter{
+		dir:      dir,
+		manifest: manifest,
+		limit:    chunkSize,
+		codedb:   codedb,
+		codes:    make(map[common.Hash]struct{}),
+	}
+	// Resolve the position for resuming the interrupted export.
+	var (
+		start    = time.Now()
+		logged   = time.Now()
+		accounts uint64
+		slots    uint64
+		origin   common.Hash
+	)
+	if n := len(manifest.Chunks); n > 0 {
+		if err := exporter.loadCodes(); err != nil {
+			return err
+		}
+		last := manifest.Chunks[n-1]
+		if last.LastSlot != nil {
+			slotOrigin := common.BytesToHash(increaseKey(common.CopyBytes(last.LastSlot[:])))
+			if slotOrigin != (common.Hash{}) {
+				if err := exportStorage(snaptree, root, exporter, last.Last, slotOrigin, &slots); err != nil {
+					return err
+				}
+			}
+		}
+		next := increaseKey(common.CopyBytes(last.Last[:]))
+		if next == nil {
+			return exporter.finish()
+		}
+		origin = common.BytesToHash(next)
+		log.Info("Resuming state export", "root", root, "chunks", n, "origin", origin)
+	} else {
+		log.Info("Exporting state", "root", root, "dir", dir)
+	}
+	it, err := snaptree.AccountIterator(root, origin)
+	if err != nil {
+		return err
+	}
+	defer it.Release()
+
+	for it.Next() {
+		if err := exporter.addAccount(it.Hash(), it.Account()); err != nil {
+			return err
+		}
+		if err := exportStorage(snaptree, root, exporter, it.Hash(), common.Hash{}, &slots); err != nil {
+			return err
+		}
+		accounts++
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Exporting state", "at", it.Hash(), "accounts", accounts, "slots", slots,
+				"chunks", len(manifest.Chunks), "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if err := exporter.finish(); err != nil {
+		return err
+	}
+	log.Info("Exported state", "root", root, "accounts", accounts, "slots", slots,
+		"chunks", len(manifest.Chunks), "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// exportStorage exports the storage slots of the given account from the origin.
+func exportStorage(snaptree *Tree, root common.Hash, exporter *chunkExporter, account common.Hash, origin common.Hash, slots *uint64) error {
+	it, err := snaptree.StorageIterator(root, account, origin)
+	if err != nil {
+		return err
+	}
+	defer it.Release()
+
+	for it.Next() {
+		if err := exporter.addSlot(account, it.Hash(), it.Slot()); err != nil {
+			return err
+		}
+		*slots++
+	}
+	return it.Error()
+}
+
+// finish flushes the last chunk and marks the export as complete.
+func (e *chunkExporter) finish() error {
+	if err := e.flush(nil); err != nil {
+		return err
+	}
+	e.manifest.Complete = true
+	return writeExportManifest(e.dir, e.manifest)
+}
+
+// readExportChunk loads the specified chunk file, verifying its checksum.
+func readExportChunk(dir string, info ExportChunkInfo) (*exportChunk, error) {
+	blob, err := os.ReadFile(filepath.Join(dir, info.File))
+	if err != nil {
+		return nil, err
+	}
+	if common.Hash(sha256.Sum256(blob)) != info.Checksum {
+		return nil, fmt.Errorf("checksum mismatch of chunk %s", info.File)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(blob))
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+	var chunk exportChunk
+	if err := rlp.DecodeBytes(data, &chunk); err != nil {
+		return nil, fmt.Errorf("failed to decode chunk %s: %v", info.File, err)
+	}
+	return &chunk, nil
+}
+
+// encodeImportProgress packs the import progress, the state root and the number
+// of imported chunks, into byte stream.
+func encodeImportProgress(root common.Hash, chunks uint64) []byte {
+	return binary.BigEndian.AppendUint64(root.Bytes(), chunks)
+}
+
+// decodeImportProgress unpacks the import progress from byte stream.
+func decodeImportProgress(blob []byte) (common.Hash, uint64, bool) {
+	if len(blob) != common.HashLength+8 {
+		return common.Hash{}, 0, false
+	}
+	return common.BytesToHash(blob[:common.HashLength]), binary.BigEndian.Uint64(blob[common.HashLength:]), true
+}
+
+// Import loads the exported state in the given directory into the database as
+// the snapshot, replacing the existing one, and regenerates the state trie with
+// the given scheme. The import is aborted if the regenerated root doesn't match
+// the exported one. An interrupted import of the same state is resumed from the
+// last imported chunk.
+//
+// In path scheme the state can only be imported into a database without state,
+// as the regenerated trie nodes would be mixed up with the existing ones.
+func Import(db ethdb.Database, scheme string, dir string) (common.Hash, error) {
+	manifest, err := ReadExportManifest(dir)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if !manifest.Complete {
+		return common.Hash{}, errors.New("export is not complete")
+	}
+	root := manifest.Root
+
+	// Resume the interrupted import if it's for the same state, otherwise wipe
+	// out the existing snapshot before importing.
+	var next uint64
+	if prev, n, ok := decodeImportProgress(rawdb.ReadSnapshotImport(db)); ok && prev == root {
+		next = n
+		log.Info("Resuming state import", "root", root, "imported", n, "chunks", len(manifest.Chunks))
+	} else {
+		// The trie nodes of the path scheme are keyed by path, regenerating
+		// the trie over the existing one would leave the stale nodes behind.
+		// The hash scheme nodes are keyed by hash and can be safely mixed.
+		if scheme == rawdb.PathScheme && rawdb.HasAccountTrieNode(db, nil) {
+			return common.Hash{}, errors.New("database already contains state")
+		}
+		if err := wipeSnapshot(db); err != nil {
+			return common.Hash{}, err
+		}
+		rawdb.WriteSnapshotImport(db, encodeImportProgress(root, 0))
+		log.Info("Importing state", "root", root, "chunks", len(manifest.Chunks))
+	}
+	var (
+		start  = time.Now()
+		logged = time.Now()
+		last   []byte // The last imported account, used for the ordering check
+	)
+	if next > 0 && next <= uint64(len(manifest.Chunks)) {
+		last = manifest.Chunks[next-1].Last.Bytes()
+	}
+	for i := next; i < uint64(len(manifest.Chunks)); i++ {
+		info := manifest.Chunks[i]
+		chunk, err := readExportChunk(dir, info)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		batch := db.NewBatch()
+		for _, code := range chunk.Codes {
+			rawdb.WriteCode(batch, crypto.Keccak256Hash(code), code)
+		}
+		for j, account := range chunk.Accounts {
+			// The continued storage of the last account in the previous chunk is
+			// only allowed as the first entry.
+			if len(account.Account) == 0 {
+				if j != 0 || !bytes.Equal(account.Hash.Bytes(), last) {
+					return common.Hash{}, fmt.Errorf("unexpected storage continuation of %#x in chunk %s", account.Hash, info.File)
+				}
+			} else {
+				if last != nil && bytes.Compare(account.Hash.Bytes(), last) <= 0 {
+					return common.Hash{}, fmt.Errorf("unordered account %#x in chunk %s", account.Hash, info.File)
+				}
+				rawdb.WriteAccountSnapshot(batch, account.Hash, account.Account)
+			}
+			last = account.Hash.Bytes()
+
+			for _, slot := range account.Storage {
+				rawdb.WriteStorageSnapshot(batch, account.Hash, slot.Hash, slot.Value)
+			}
+			if batch.ValueSize() > ethdb.IdealBatchSize {
+				if err := batch.Write(); err != nil {
+					return common.Hash{}, err
+				}
+				batch.Reset()
+			}
+		}
+		rawdb.WriteSnapshotImport(batch, encodeImportProgress(root, i+1))
+		if err := batch.Write(); err != nil {
+			return common.Hash{}, err
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Importing state", "chunks", i+1, "total", len(manifest.Chunks), "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	log.Info("Imported state snapshot, regenerating trie", "root", root, "elapsed", common.PrettyDuration(time.Since(start)))
+
+	// Regenerate the state trie from the imported snapshot, verifying the root.
+	dl := &diskLayer{diskdb: db, root: root}
+	acctIt := dl.AccountIterator(common.Hash{})
+	defer acctIt.Release()
+
+	got, err := generateTrieRoot(db, scheme, acctIt, common.Hash{}, stackTrieGenerate, func(dst ethdb.KeyValueWriter, accountHash, codeHash common.Hash, stat *generateStats) (common.Hash, error) {
+		if codeHash != types.EmptyCodeHash && !rawdb.HasCode(db, codeHash) {
+			return common.Hash{}, fmt.Errorf("missing code %#x of account %#x", codeHash, accountHash)
+		}
+		storageIt := dl.StorageIterator(accountHash, common.Hash{})
+		defer storageIt.Release()
+
+		return generateTrieRoot(dst, scheme, storageIt, accountHash, stackTrieGenerate, nil, stat, false)
+	}, newGenerateStats(), true)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if got != root {
+		rawdb.DeleteSnapshotImport(db)
+		return common.Hash{}, fmt.Errorf("state root hash mismatch: got %x, want %x", got, root)
+	}
+	// Mark the imported snapshot as complete.
+	batch := db.NewBatch()
+	rawdb.WriteSnapshotRoot(batch, root)
+	journalProgress(batch, nil, nil)
+	rawdb.DeleteSnapshotJournal(batch)
+	rawdb.DeleteSnapshotImport(batch)
+	if err := batch.Write(); err != nil {
+		return common.Hash{}, err
+	}
+	log.Info("Imported state", "root", root, "elapsed", common.PrettyDuration(time.Since(start)))
+	return root, nil
+}
+
+// wipeSnapshot removes the entire snapshot from the database. The entries are
+// identified by the key length, as the prefixes are shared with the trie nodes
+// of the hash scheme.
+func wipeSnapshot(db ethdb.KeyValueStore) error {
+	rawdb.DeleteSnapshotRoot(db)
+
+	batch := db.NewBatch()
+	for _, prefix := range []struct {
+		key    []byte
+		length int
+	}{
+		{rawdb.SnapshotAccountPrefix, len(rawdb.SnapshotAccountPrefix) + common.HashLength},
+		{rawdb.SnapshotStoragePrefix, len(rawdb.SnapshotStoragePrefix) + 2*common.HashLength},
+	} {
+		it := db.NewIterator(prefix.key, nil)
+		for it.Next() {
+			if len(it.Key()) != prefix.length {
+				continue
+			}
+			batch.Delete(it.Key())
+			if batch.ValueSize() > ethdb.IdealBatchSize {
+				if err := batch.Write(); err != nil {
+					it.Release()
+					return err
+				}
+				batch.Reset()
+			}
+		}
+		it.Release()
+		if err := it.Error(); err != nil {
+			return err
+		}
+	}
+	return batch.Write()
+}
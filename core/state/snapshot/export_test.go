@@ -0,0 +1,176 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/ethereum/go-ethereum/triedb/hashdb"
+	"github.com/ethereum/go-ethereum/triedb/pathdb"
+	"github.com/holiman/uint256"
+)
+
+// newExportTestTree creates a snapshot tree of a state with a few contracts,
+// returning the tree along with the state root.
+func newExportTestTree(t *testing.T) (*Tree, common.Hash) {
+	helper := newHelper(rawdb.HashScheme)
+	for i := 0; i < 16; i++ {
+		acc := &types.StateAccount{Balance: uint256.NewInt(uint64(i)), Root: types.EmptyRootHash, CodeHash: types.EmptyCodeHash.Bytes()}
+		if i%4 == 0 {
+			var keys, vals []string
+			for j := 0; j < 32; j++ {
+				keys = append(keys, fmt.Sprintf("key-%d", j))
+				vals = append(vals, fmt.Sprintf("val-%d-%d", i, j))
+			}
+			code := []byte(fmt.Sprintf("code-%d", i%8)) // Contracts 0 and 8, 4 and 12 share the code
+			rawdb.WriteCode(helper.diskdb, crypto.Keccak256Hash(code), code)
+
+			acc.Root = helper.makeStorageTrie(fmt.Sprintf("acc-%d", i), keys, vals, true)
+			acc.CodeHash = crypto.Keccak256(code)
+		}
+		helper.addTrieAccount(fmt.Sprintf("acc-%d", i), acc)
+	}
+	root, snap := helper.CommitAndGenerate()
+	select {
+	case <-snap.genPending:
+	case <-time.After(3 * time.Second):
+		t.Fatal("Snapshot generation failed")
+	}
+	return &Tree{layers: map[common.Hash]snapshot{root: snap}}, root
+}
+
+func TestExportImport(t *testing.T) {
+	snaps, root := newExportTestTree(t)
+	diskdb := snaps.disklayer().diskdb
+
+	dir := filepath.Join(t.TempDir(), root.Hex())
+	if err := Export(snaps, root, diskdb, dir, 256); err != nil {
+		t.Fatalf("Failed to export state: %v", err)
+	}
+	manifest, err := ReadExportManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !manifest.Complete || manifest.Root != root || len(manifest.Chunks) < 4 {
+		t.Fatalf("Unexpected manifest: %+v", manifest)
+	}
+	// Simulate an interruption, the export is expected to be resumed and yield
+	// the same result.
+	interrupted := *manifest
+	interrupted.Complete = false
+	interrupted.Chunks = manifest.Chunks[:len(manifest.Chunks)/2]
+	for _, info := range manifest.Chunks[len(manifest.Chunks)/2:] {
+		os.Remove(filepath.Join(dir, info.File))
+	}
+	if err := writeExportManifest(dir, &interrupted); err != nil {
+		t.Fatal(err)
+	}
+	if err := Export(snaps, root, diskdb, dir, 256); err != nil {
+		t.Fatalf("Failed to resume export: %v", err)
+	}
+	resumed, err := ReadExportManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(manifest, resumed) {
+		t.Fatalf("Resumed export mismatch, want: %+v, got: %+v", manifest, resumed)
+	}
+	// Import the state into a fresh database, the trie is regenerated.
+	for _, scheme := range []string{rawdb.HashScheme, rawdb.PathScheme} {
+		db := rawdb.NewMemoryDatabase()
+		imported, err := Import(db, scheme, dir)
+		if err != nil {
+			t.Fatalf("Failed to import state: %v", err)
+		}
+		if imported != root || rawdb.ReadSnapshotRoot(db) != root || len(rawdb.ReadSnapshotImport(db)) != 0 {
+			t.Fatalf("Unexpected import result: %x", imported)
+		}
+		config := &triedb.Config{HashDB: &hashdb.Config{}}
+		if scheme == rawdb.PathScheme {
+			config = &triedb.Config{PathDB: &pathdb.Config{}}
+		}
+		tr, err := trie.New(trie.StateTrieID(root), triedb.NewDatabase(db, config))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var accounts int
+		it := trie.NewIterator(tr.MustNodeIterator(nil))
+		for it.Next() {
+			accounts++
+			want := rawdb.ReadAccountSnapshot(diskdb, common.BytesToHash(it.Key))
+			if got := rawdb.ReadAccountSnapshot(db, common.BytesToHash(it.Key)); !bytes.Equal(got, want) {
+				t.Fatalf("Account snapshot mismatch, want: %x, got: %x", want, got)
+			}
+		}
+		if accounts != 16 {
+			t.Fatalf("Unexpected number of accounts: %d", accounts)
+		}
+		// Importing the state again over the existing one is rejected in path
+		// scheme, which would leave the stale trie nodes behind.
+		_, err = Import(db, scheme, dir)
+		if scheme == rawdb.PathScheme && err == nil {
+			t.Fatal("Expected error for importing into database with state")
+		}
+		if scheme == rawdb.HashScheme && err != nil {
+			t.Fatalf("Failed to re-import state: %v", err)
+		}
+	}
+}
+
+func TestImportCorruptedChunk(t *testing.T) {
+	snaps, root := newExportTestTree(t)
+
+	dir := t.TempDir()
+	if err := Export(snaps, root, snaps.disklayer().diskdb, dir, 256); err != nil {
+		t.Fatalf("Failed to export state: %v", err)
+	}
+	manifest, err := ReadExportManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Import the first chunks, and corrupt the next one.
+	path := filepath.Join(dir, manifest.Chunks[2].File)
+	blob, _ := os.ReadFile(path)
+	blob[len(blob)-1] ^= 0xff
+	os.WriteFile(path, blob, 0644)
+
+	db := rawdb.NewMemoryDatabase()
+	if _, err := Import(db, rawdb.HashScheme, dir); err == nil {
+		t.Fatal("Expected error for corrupted chunk")
+	}
+	if _, n, _ := decodeImportProgress(rawdb.ReadSnapshotImport(db)); n != 2 {
+		t.Fatalf("Unexpected import progress: %d", n)
+	}
+	// Restore the chunk, the import is resumed.
+	blob[len(blob)-1] ^= 0xff
+	os.WriteFile(path, blob, 0644)
+	if _, err := Import(db, rawdb.HashScheme, dir); err != nil {
+		t.Fatalf("Failed to resume import: %v", err)
+	}
+}
@@ -17,20 +17,31 @@
 package core
 
 import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/lru"
 	"github.com/ethereum/go-ethereum/consensus/beacon"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/stateless"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/trie"
 	"github.com/ethereum/go-ethereum/triedb"
 )
 
+// errWitnessNoHeaders is returned if the witness doesn't contain the parent
+// header, which holds the pre-state root.
+var errWitnessNoHeaders = errors.New("witness contains no headers")
+
 // ExecuteStateless runs a stateless execution based on a witness, verifies
 // everything it can locally and returns the state root and receipt root, that
 // need the other side to explicitly check.
@@ -49,11 +60,20 @@ func ExecuteStateless(config *params.ChainConfig, vmconfig vm.Config, block *typ
 	if block.ReceiptHash() != (common.Hash{}) {
 		log.Error("stateless runner received receipt root it's expected to calculate (faulty consensus client)", "block", block.Number())
 	}
+	stateRoot, receiptRoot, _, err := executeStateless(config, vmconfig, block, witness, witness.MakeHashDB())
+	return stateRoot, receiptRoot, err
+}
+
+// executeStateless runs the block on top of the state stored in the given witness
+// database, returning the state root, receipt root and the gas used.
+func executeStateless(config *params.ChainConfig, vmconfig vm.Config, block *types.Block, witness *stateless.Witness, memdb ethdb.Database) (common.Hash, common.Hash, uint64, error) {
+	if len(witness.Headers) == 0 {
+		return common.Hash{}, common.Hash{}, 0, errWitnessNoHeaders
+	}
 	// Create and populate the state database to serve as the stateless backend
-	memdb := witness.MakeHashDB()
 	db, err := state.New(witness.Root(), state.NewDatabase(triedb.NewDatabase(memdb, triedb.HashDefaults), nil))
 	if err != nil {
-		return common.Hash{}, common.Hash{}, err
+		return common.Hash{}, common.Hash{}, 0, err
 	}
 	// Create a blockchain that is idle, but can be used to access headers through
 	chain := &HeaderChain{
@@ -68,13 +88,111 @@ func ExecuteStateless(config *params.ChainConfig, vmconfig vm.Config, block *typ
 	// Run the stateless blocks processing and self-validate certain fields
 	res, err := processor.Process(block, db, vmconfig)
 	if err != nil {
-		return common.Hash{}, common.Hash{}, err
+		return common.Hash{}, common.Hash{}, 0, err
 	}
 	if err = validator.ValidateState(block, db, res, true); err != nil {
-		return common.Hash{}, common.Hash{}, err
+		return common.Hash{}, common.Hash{}, 0, err
 	}
 	// Almost everything validated, but receipt and state root needs to be returned
 	receiptRoot := types.DeriveSha(res.Receipts, trie.NewStackTrie(nil))
 	stateRoot := db.IntermediateRoot(config.IsEIP158(block.Number()))
-	return stateRoot, receiptRoot, nil
+	return stateRoot, receiptRoot, res.GasUsed, nil
+}
+
+// StatelessResult is the outcome of verifying a block against its witness.
+type StatelessResult struct {
+	StateRoot    common.Hash   `json:"stateRoot"`    // State root computed from the witness
+	ReceiptRoot  common.Hash   `json:"receiptRoot"`  // Receipt root computed from the execution
+	GasUsed      uint64        `json:"gasUsed"`      // Gas used by the execution
+	MissingNodes []common.Hash `json:"missingNodes"` // Trie nodes accessed but absent in the witness
+	MissingCodes []common.Hash `json:"missingCodes"` // Contract codes accessed but absent in the witness
+}
+
+// VerifyStateless executes a sealed block against the pre-state contained in
+// the witness only, and checks the computed state and receipt roots against the
+// ones in the block header. The result is returned even if the verification
+// fails, reporting the trie nodes and codes that the witness is missing.
+func VerifyStateless(config *params.ChainConfig, vmconfig vm.Config, block *types.Block, witness *stateless.Witness) (*StatelessResult, error) {
+	if len(witness.Headers) == 0 {
+		return nil, errWitnessNoHeaders
+	}
+	if parent := witness.Headers[0]; parent.Hash() != block.ParentHash() {
+		return nil, fmt.Errorf("witness parent mismatch: have %x, want %x", parent.Hash(), block.ParentHash())
+	}
+	// The roots are expected to be computed by the stateless execution, strip
+	// them off from the block.
+	header := block.Header()
+	header.Root, header.ReceiptHash = common.Hash{}, common.Hash{}
+
+	var (
+		db     = &witnessTracker{Database: witness.MakeHashDB(), missing: make(map[string]struct{})}
+		result = new(StatelessResult)
+		err    error
+	)
+	result.StateRoot, result.ReceiptRoot, result.GasUsed, err = executeStateless(config, vmconfig, block.WithSeal(header), witness, db)
+	result.MissingNodes, result.MissingCodes = db.report()
+	if err != nil {
+		return result, err
+	}
+	if len(result.MissingNodes) > 0 || len(result.MissingCodes) > 0 {
+		return result, fmt.Errorf("incomplete witness: %d trie nodes, %d codes missing", len(result.MissingNodes), len(result.MissingCodes))
+	}
+	if result.StateRoot != block.Root() {
+		return result, fmt.Errorf("invalid merkle root (remote: %x local: %x)", block.Root(), result.StateRoot)
+	}
+	if result.ReceiptRoot != block.ReceiptHash() {
+		return result, fmt.Errorf("invalid receipt root hash (remote: %x local: %x)", block.ReceiptHash(), result.ReceiptRoot)
+	}
+	return result, nil
+}
+
+// witnessTracker is a database wrapper recording the keys of all the failed
+// lookups, to find out the data missing in a witness.
+type witnessTracker struct {
+	ethdb.Database
+	missing map[string]struct{}
+	lock    sync.Mutex
+}
+
+// Get implements ethdb.KeyValueReader, retrieving the value of the given key
+// and recording the key if it's not found.
+func (t *witnessTracker) Get(key []byte) ([]byte, error) {
+	blob, err := t.Database.Get(key)
+	if err != nil {
+		t.lock.Lock()
+		t.missing[string(key)] = struct{}{}
+		t.lock.Unlock()
+	}
+	return blob, err
+}
+
+// report returns the hashes of the missing trie nodes and codes. Codes are
+// looked up with both the prefixed and legacy keys, the latter of which has
+// the same format as the trie nodes, the codes are excluded from the nodes.
+func (t *witnessTracker) report() ([]common.Hash, []common.Hash) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var (
+		nodes []common.Hash
+		codes []common.Hash
+		seen  = make(map[common.Hash]struct{})
+	)
+	for key := range t.missing {
+		if ok, hash := rawdb.IsCodeKey([]byte(key)); ok {
+			codes = append(codes, common.BytesToHash(hash))
+			seen[common.BytesToHash(hash)] = struct{}{}
+		}
+	}
+	for key := range t.missing {
+		if len(key) != common.HashLength {
+			continue
+		}
+		if _, ok := seen[common.BytesToHash([]byte(key))]; !ok {
+			nodes = append(nodes, common.BytesToHash([]byte(key)))
+		}
+	}
+	slices.SortFunc(nodes, common.Hash.Cmp)
+	slices.SortFunc(codes, common.Hash.Cmp)
+	return nodes, codes
 }
@@ -17,8 +17,12 @@
 package stateless
 
 import (
+	"bytes"
+	"encoding/json"
 	"io"
+	"slices"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/rlp"
 )
@@ -74,3 +78,45 @@ type extWitness struct {
 	Codes   [][]byte
 	State   [][]byte
 }
+
+// MarshalJSON serializes a witness as JSON. The codes and trie nodes are sorted
+// to make the output deterministic.
+func (w *Witness) MarshalJSON() ([]byte, error) {
+	enc := jsonWitness{
+		Headers: w.Headers,
+		Codes:   make([]hexutil.Bytes, 0, len(w.Codes)),
+		State:   make([]hexutil.Bytes, 0, len(w.State)),
+	}
+	for code := range w.Codes {
+		enc.Codes = append(enc.Codes, []byte(code))
+	}
+	for node := range w.State {
+		enc.State = append(enc.State, []byte(node))
+	}
+	slices.SortFunc(enc.Codes, func(a, b hexutil.Bytes) int { return bytes.Compare(a, b) })
+	slices.SortFunc(enc.State, func(a, b hexutil.Bytes) int { return bytes.Compare(a, b) })
+	return json.Marshal(&enc)
+}
+
+// UnmarshalJSON decodes a witness from JSON.
+func (w *Witness) UnmarshalJSON(input []byte) error {
+	var dec jsonWitness
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	ext := &extWitness{Headers: dec.Headers}
+	for _, code := range dec.Codes {
+		ext.Codes = append(ext.Codes, code)
+	}
+	for _, node := range dec.State {
+		ext.State = append(ext.State, node)
+	}
+	return w.fromExtWitness(ext)
+}
+
+// jsonWitness is the JSON representation of a witness.
+type jsonWitness struct {
+	Headers []*types.Header `json:"headers"`
+	Codes   []hexutil.Bytes `json:"codes"`
+	State   []hexutil.Bytes `json:"state"`
+}
@@ -0,0 +1,235 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package stateless
+
+import (
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var (
+	witnessSizeHist         = metrics.NewRegisteredHistogram("witness/size", nil, metrics.NewExpDecaySample(1028, 0.015))
+	witnessHeaderCountHist  = metrics.NewRegisteredHistogram("witness/headers", nil, metrics.NewExpDecaySample(1028, 0.015))
+	witnessCodeBytesHist    = metrics.NewRegisteredHistogram("witness/code/bytes", nil, metrics.NewExpDecaySample(1028, 0.015))
+	witnessAccountNodesHist = metrics.NewRegisteredHistogram("witness/trie/account/nodes", nil, metrics.NewExpDecaySample(1028, 0.015))
+	witnessAccountBytesHist = metrics.NewRegisteredHistogram("witness/trie/account/bytes", nil, metrics.NewExpDecaySample(1028, 0.015))
+	witnessStorageNodesHist = metrics.NewRegisteredHistogram("witness/trie/storage/nodes", nil, metrics.NewExpDecaySample(1028, 0.015))
+	witnessStorageBytesHist = metrics.NewRegisteredHistogram("witness/trie/storage/bytes", nil, metrics.NewExpDecaySample(1028, 0.015))
+)
+
+// DepthStats is the number and total size of the trie nodes at a certain depth.
+type DepthStats struct {
+	Nodes int    `json:"nodes"`
+	Bytes uint64 `json:"bytes"`
+}
+
+// TrieStats summarizes the trie nodes of a witness belonging to one kind of
+// trie. The depth of a node is the length of its path in nibbles.
+type TrieStats struct {
+	Nodes  int          `json:"nodes"`
+	Bytes  uint64       `json:"bytes"`
+	Depths []DepthStats `json:"depths"`
+}
+
+// add accounts a trie node at the given depth.
+func (s *TrieStats) add(depth int, size int) {
+	for len(s.Depths) <= depth {
+		s.Depths = append(s.Depths, DepthStats{})
+	}
+	s.Nodes++
+	s.Bytes += uint64(size)
+	s.Depths[depth].Nodes++
+	s.Depths[depth].Bytes += uint64(size)
+}
+
+// WitnessStats summarizes the size and the composition of a witness.
+type WitnessStats struct {
+	Size        uint64 `json:"size"`        // Size of the RLP-encoded witness
+	Headers     int    `json:"headers"`     // Number of headers
+	HeaderBytes uint64 `json:"headerBytes"` // Total size of the RLP-encoded headers
+	Codes       int    `json:"codes"`       // Number of contract codes
+	CodeBytes   uint64 `json:"codeBytes"`   // Total size of the contract codes
+
+	AccountTrie  TrieStats `json:"accountTrie"`  // Nodes of the account trie
+	StorageTrie  TrieStats `json:"storageTrie"`  // Nodes of all the storage tries together
+	StorageTries int       `json:"storageTries"` // Number of storage tries with nodes in the witness
+
+	Unreachable      int    `json:"unreachable"`      // Number of nodes not reachable from the pre-state root
+	UnreachableBytes uint64 `json:"unreachableBytes"` // Total size of the unreachable nodes
+}
+
+// Stats walks the trie nodes of the witness starting from the pre-state root,
+// and summarizes the size and composition of the witness.
+func (w *Witness) Stats() *WitnessStats {
+	stats := new(WitnessStats)
+	if blob, err := rlp.EncodeToBytes(w); err == nil {
+		stats.Size = uint64(len(blob))
+	}
+	stats.Headers = len(w.Headers)
+	for _, header := range w.Headers {
+		if blob, err := rlp.EncodeToBytes(header); err == nil {
+			stats.HeaderBytes += uint64(len(blob))
+		}
+	}
+	stats.Codes = len(w.Codes)
+	for code := range w.Codes {
+		stats.CodeBytes += uint64(len(code))
+	}
+	// Index the trie nodes by hash and walk the tries from the root
+	walker := &witnessWalker{
+		nodes:   make(map[string][]byte, len(w.State)),
+		visited: make(map[string]struct{}, len(w.State)),
+		stats:   stats,
+	}
+	for node := range w.State {
+		walker.nodes[string(crypto.Keccak256([]byte(node)))] = []byte(node)
+	}
+	if len(w.Headers) > 0 {
+		walker.walkHash(w.Root().Bytes(), 0, true)
+	}
+	for hash, blob := range walker.nodes {
+		if _, ok := walker.visited[hash]; !ok {
+			stats.Unreachable++
+			stats.UnreachableBytes += uint64(len(blob))
+		}
+	}
+	return stats
+}
+
+// Report updates the witness metrics with the statistics.
+func (s *WitnessStats) Report() {
+	witnessSizeHist.Update(int64(s.Size))
+	witnessHeaderCountHist.Update(int64(s.Headers))
+	witnessCodeBytesHist.Update(int64(s.CodeBytes))
+	witnessAccountNodesHist.Update(int64(s.AccountTrie.Nodes))
+	witnessAccountBytesHist.Update(int64(s.AccountTrie.Bytes))
+	witnessStorageNodesHist.Update(int64(s.StorageTrie.Nodes))
+	witnessStorageBytesHist.Update(int64(s.StorageTrie.Bytes))
+}
+
+// witnessWalker traverses the tries contained in a witness. The nodes are
+// decoded in their raw RLP form, as the trie package doesn't expose its node
+// decoder.
+type witnessWalker struct {
+	nodes   map[string][]byte   // Witness trie nodes indexed by hash
+	visited map[string]struct{} // Hashes of the nodes already accounted
+	stats   *WitnessStats
+}
+
+// walkHash accounts the node with the given hash if it's in the witness, and
+// descends into its children.
+func (w *witnessWalker) walkHash(hash []byte, depth int, account bool) {
+	blob, ok := w.nodes[string(hash)]
+	if !ok {
+		return
+	}
+	// Identical storage tries may share nodes, count them only once
+	if _, ok := w.visited[string(hash)]; ok {
+		return
+	}
+	w.visited[string(hash)] = struct{}{}
+
+	if account {
+		w.stats.AccountTrie.add(depth, len(blob))
+	} else {
+		w.stats.StorageTrie.add(depth, len(blob))
+		if depth == 0 {
+			w.stats.StorageTries++
+		}
+	}
+	w.walkNode(blob, depth, account)
+}
+
+// walkNode descends into the children of the given RLP-encoded node.
+func (w *witnessWalker) walkNode(blob []byte, depth int, account bool) {
+	elems, _, err := rlp.SplitList(blob)
+	if err != nil {
+		return
+	}
+	count, err := rlp.CountValues(elems)
+	if err != nil {
+		return
+	}
+	switch count {
+	case 2:
+		key, rest, err := rlp.SplitString(elems)
+		if err != nil || len(key) == 0 {
+			return
+		}
+		// Decode the length and the kind of the hex-prefix encoded key
+		var (
+			flag    = key[0] >> 4
+			nibbles = 2*len(key) - 2 + int(flag&1)
+		)
+		if flag >= 2 {
+			// Leaf node, descend into the storage trie for accounts
+			if !account {
+				return
+			}
+			value, _, err := rlp.SplitString(rest)
+			if err != nil {
+				return
+			}
+			var acc types.StateAccount
+			if err := rlp.DecodeBytes(value, &acc); err != nil {
+				return
+			}
+			if acc.Root != types.EmptyRootHash {
+				w.walkHash(acc.Root.Bytes(), 0, false)
+			}
+			return
+		}
+		w.walkChild(rest, depth+nibbles, account)
+
+	case 17:
+		for i := 0; i < 16; i++ {
+			var child []byte
+			child, elems, err = splitRaw(elems)
+			if err != nil {
+				return
+			}
+			w.walkChild(child, depth+1, account)
+		}
+	}
+}
+
+// walkChild descends into the child reference, which is either a hash or an
+// embedded node.
+func (w *witnessWalker) walkChild(raw []byte, depth int, account bool) {
+	kind, content, _, err := rlp.Split(raw)
+	if err != nil {
+		return
+	}
+	switch {
+	case kind == rlp.String && len(content) == 32:
+		w.walkHash(content, depth, account)
+	case kind == rlp.List:
+		w.walkNode(raw, depth, account)
+	}
+}
+
+// splitRaw splits the first RLP value from the input, returning it in encoded
+// form along with the remaining bytes.
+func splitRaw(b []byte) ([]byte, []byte, error) {
+	_, _, rest, err := rlp.Split(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b[:len(b)-len(rest)], rest, nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package stateless
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/trie/trienode"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/holiman/uint256"
+)
+
+// addNodes adds all the nodes of the given node set into the witness, returning
+// the number of newly added nodes and their total size.
+func addNodes(w *Witness, set *trienode.NodeSet) (int, uint64) {
+	var (
+		nodes int
+		size  uint64
+	)
+	set.ForEachWithOrder(func(path string, n *trienode.Node) {
+		if _, ok := w.State[string(n.Blob)]; ok {
+			return
+		}
+		w.State[string(n.Blob)] = struct{}{}
+		nodes++
+		size += uint64(len(n.Blob))
+	})
+	return nodes, size
+}
+
+func TestWitnessStats(t *testing.T) {
+	var (
+		db      = triedb.NewDatabase(rawdb.NewMemoryDatabase(), nil)
+		witness = &Witness{Codes: make(map[string]struct{}), State: make(map[string]struct{})}
+
+		storageNodes int
+		storageBytes uint64
+	)
+	accTrie := trie.NewEmpty(db)
+	for i := 0; i < 100; i++ {
+		acc := &types.StateAccount{Balance: uint256.NewInt(uint64(i)), Root: types.EmptyRootHash, CodeHash: types.EmptyCodeHash.Bytes()}
+		if i%10 == 0 {
+			// There are only two distinct storage tries, the identical ones
+			// share the nodes.
+			st := trie.NewEmpty(db)
+			for j := 0; j < 20*(i%20+1); j++ {
+				st.MustUpdate(crypto.Keccak256([]byte(fmt.Sprintf("slot-%d", j))), []byte{0x1, byte(j)})
+			}
+			root, set := st.Commit(false)
+			if i == 0 || i == 10 {
+				nodes, size := addNodes(witness, set)
+				storageNodes += nodes
+				storageBytes += size
+			}
+			acc.Root = root
+		}
+		blob, _ := rlp.EncodeToBytes(acc)
+		accTrie.MustUpdate(crypto.Keccak256(common.BigToAddress(common.Big1).Bytes(), []byte{byte(i)}), blob)
+	}
+	root, set := accTrie.Commit(false)
+	accountNodes, accountBytes := addNodes(witness, set)
+
+	witness.Headers = []*types.Header{{Root: root, Number: common.Big1}}
+	witness.Codes["code"] = struct{}{}
+	witness.State["junk"] = struct{}{}
+
+	stats := witness.Stats()
+	if stats.AccountTrie.Nodes != accountNodes || stats.AccountTrie.Bytes != accountBytes {
+		t.Fatalf("account trie mismatch, want %d nodes %d bytes, got %+v", accountNodes, accountBytes, stats.AccountTrie)
+	}
+	if stats.StorageTrie.Nodes != storageNodes || stats.StorageTrie.Bytes != storageBytes || stats.StorageTries != 2 {
+		t.Fatalf("storage trie mismatch, want %d nodes %d bytes, got %+v", storageNodes, storageBytes, stats.StorageTrie)
+	}
+	if stats.Unreachable != 1 || stats.UnreachableBytes != 4 {
+		t.Fatalf("unreachable mismatch, got %d nodes %d bytes", stats.Unreachable, stats.UnreachableBytes)
+	}
+	if stats.Headers != 1 || stats.Codes != 1 || stats.CodeBytes != 4 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	for _, ts := range []TrieStats{stats.AccountTrie, stats.StorageTrie} {
+		var (
+			nodes int
+			size  uint64
+		)
+		for _, depth := range ts.Depths {
+			nodes += depth.Nodes
+			size += depth.Bytes
+		}
+		if nodes != ts.Nodes || size != ts.Bytes || ts.Depths[0].Nodes == 0 {
+			t.Fatalf("depth stats mismatch: %+v", ts)
+		}
+	}
+}
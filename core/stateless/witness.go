@@ -113,10 +113,12 @@ func (w *Witness) Copy() *Witness {
 	return cpy
 }
 
-// Root returns the pre-state root from the first header.
-//
-// Note, this method will panic in case of a bad witness (but RLP decoding will
-// sanitize it and fail before that).
+// Root returns the pre-state root from the first header, or an empty hash if
+// the witness contains no headers (RLP decoding rejects such witnesses, but a
+// hand-constructed one might still be bad).
 func (w *Witness) Root() common.Hash {
+	if len(w.Headers) == 0 {
+		return common.Hash{}
+	}
 	return w.Headers[0].Root
 }
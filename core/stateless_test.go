@@ -0,0 +1,145 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/stateless"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/program"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func TestVerifyStateless(t *testing.T) {
+	var (
+		key, _   = crypto.GenerateKey()
+		sender   = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.HexToAddress("0xc0de")
+		engine   = beacon.New(ethash.NewFaker())
+		signer   = types.LatestSigner(params.MergedTestChainConfig)
+		genesis  = &Genesis{
+			Config: params.MergedTestChainConfig,
+			Alloc: types.GenesisAlloc{
+				sender:   {Balance: big.NewInt(params.Ether)},
+				contract: {Code: program.New().Op(vm.NUMBER).Op(vm.NUMBER).Op(vm.SSTORE).Bytes()},
+			},
+		}
+	)
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 4, func(i int, b *BlockGen) {
+		b.SetPoS()
+		tx, _ := types.SignNewTx(key, signer, &types.LegacyTx{Nonce: b.TxNonce(sender), To: &common.Address{0x01}, Value: big.NewInt(1), Gas: params.TxGas, GasPrice: b.BaseFee()})
+		b.AddTx(tx)
+		tx, _ = types.SignNewTx(key, signer, &types.LegacyTx{Nonce: b.TxNonce(sender), To: &contract, Gas: 100_000, GasPrice: b.BaseFee()})
+		b.AddTx(tx)
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks[:3]); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	block := blocks[3]
+	witness, err := chain.InsertBlockWithoutSetHead(block, true)
+	if err != nil {
+		t.Fatalf("failed to insert block: %v", err)
+	}
+	// Round-trip the witness through the RLP encoding, as it's shipped to the
+	// stateless verifiers.
+	blob, err := rlp.EncodeToBytes(witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	witness = new(stateless.Witness)
+	if err := rlp.DecodeBytes(blob, witness); err != nil {
+		t.Fatal(err)
+	}
+	res, err := VerifyStateless(params.MergedTestChainConfig, vm.Config{}, block, witness)
+	if err != nil {
+		t.Fatalf("failed to verify block: %v", err)
+	}
+	if res.StateRoot != block.Root() || res.ReceiptRoot != block.ReceiptHash() || res.GasUsed != block.GasUsed() {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	// Drop a trie node from the witness, the missing node should be reported.
+	incomplete := witness.Copy()
+	for node := range incomplete.State {
+		if crypto.Keccak256Hash([]byte(node)) != incomplete.Root() {
+			delete(incomplete.State, node)
+			break
+		}
+	}
+	res, err = VerifyStateless(params.MergedTestChainConfig, vm.Config{}, block, incomplete)
+	if err == nil {
+		t.Fatal("expected error for incomplete witness")
+	}
+	if len(res.MissingNodes) != 1 || len(res.MissingCodes) != 0 {
+		t.Fatalf("unexpected missing data, nodes: %v, codes: %v", res.MissingNodes, res.MissingCodes)
+	}
+	// Drop the contract code from the witness, the missing code should be reported.
+	incomplete = witness.Copy()
+	for code := range incomplete.Codes {
+		delete(incomplete.Codes, code)
+	}
+	res, err = VerifyStateless(params.MergedTestChainConfig, vm.Config{}, block, incomplete)
+	if err == nil {
+		t.Fatal("expected error for incomplete witness")
+	}
+	if len(res.MissingNodes) != 0 || len(res.MissingCodes) != 1 {
+		t.Fatalf("unexpected missing data, nodes: %v, codes: %v", res.MissingNodes, res.MissingCodes)
+	}
+	// Drop the root node from the witness, the pre-state can't be opened at all
+	// and the root should be reported as missing.
+	incomplete = witness.Copy()
+	for node := range incomplete.State {
+		if crypto.Keccak256Hash([]byte(node)) == incomplete.Root() {
+			delete(incomplete.State, node)
+		}
+	}
+	res, err = VerifyStateless(params.MergedTestChainConfig, vm.Config{}, block, incomplete)
+	if err == nil {
+		t.Fatal("expected error for witness without root node")
+	}
+	if len(res.MissingNodes) != 1 || res.MissingNodes[0] != witness.Root() {
+		t.Fatalf("unexpected missing nodes: %v", res.MissingNodes)
+	}
+	// A witness without headers is rejected instead of crashing the verifier.
+	incomplete = witness.Copy()
+	incomplete.Headers = nil
+	if _, err := VerifyStateless(params.MergedTestChainConfig, vm.Config{}, block, incomplete); !errors.Is(err, errWitnessNoHeaders) {
+		t.Fatalf("unexpected error for witness without headers: %v", err)
+	}
+	if _, _, err := ExecuteStateless(params.MergedTestChainConfig, vm.Config{}, block, incomplete); !errors.Is(err, errWitnessNoHeaders) {
+		t.Fatalf("unexpected error for witness without headers: %v", err)
+	}
+	// Verifying the block against the witness of another block should fail.
+	if _, err := VerifyStateless(params.MergedTestChainConfig, vm.Config{}, blocks[2], witness); err == nil {
+		t.Fatal("expected error for mismatched witness")
+	}
+}
@@ -318,6 +318,12 @@ type BlobPool struct {
 	discoverFeed event.Feed // Event feed to send out new tx events on pool discovery (reorg excluded)
 	insertFeed   event.Feed // Event feed to send out new tx events on pool inclusion (reorg included)
 
+	events    []txpool.TxEvent // Lifecycle events waiting to be announced (protected by lock)
+	eventFeed event.Feed       // Event feed to send out the transaction lifecycle events
+	eventCh   chan struct{}    // Notification channel of lifecycle events waiting to be announced
+	quit      chan struct{}    // Termination channel of the event loop
+	wg        sync.WaitGroup   // Tracks the event loop
+
 	// txValidationFn defaults to txpool.ValidateTransaction, but can be
 	// overridden for testing purposes.
 	txValidationFn txpool.ValidationFunction
@@ -339,6 +345,8 @@ func New(config Config, chain BlockChain) *BlobPool {
 		lookup:         newLookup(),
 		index:          make(map[common.Address][]*blobTxMeta),
 		spent:          make(map[common.Address]*uint256.Int),
+		eventCh:        make(chan struct{}, 1),
+		quit:           make(chan struct{}),
 		txValidationFn: txpool.ValidateTransaction,
 	}
 }
@@ -436,6 +444,13 @@ func (p *BlobPool) Init(gasTip uint64, head *types.Header, reserve txpool.Addres
 	for p.stored > p.config.Datacap {
 		p.drop()
 	}
+	// Nobody can be subscribed yet, discard the lifecycle events of the startup
+	// and start announcing the ones of the pool operations
+	p.events = nil
+
+	p.wg.Add(1)
+	go p.eventLoop()
+
 	// Update the metrics and return the constructed pool
 	datacapGauge.Update(int64(p.config.Datacap))
 	p.updateStorageMetrics()
@@ -444,6 +459,9 @@ func (p *BlobPool) Init(gasTip uint64, head *types.Header, reserve txpool.Addres
 
 // Close closes down the underlying persistent store.
 func (p *BlobPool) Close() error {
+	close(p.quit)
+	p.wg.Wait()
+
 	var errs []error
 	if p.limbo != nil { // Close might be invoked due to error in constructor, before p,limbo is set
 		if err := p.limbo.Close(); err != nil {
@@ -534,12 +552,17 @@ func (p *BlobPool) recheck(addr common.Address, inclusions map[common.Hash]uint6
 			ids    []uint64
 			nonces []uint64
 		)
+		reason := txpool.DropStale
+		if gapped {
+			reason = txpool.DropGapped
+		}
 		for i := 0; i < len(txs); i++ {
 			ids = append(ids, txs[i].id)
 			nonces = append(nonces, txs[i].nonce)
 
 			p.stored -= uint64(txs[i].size)
 			p.lookup.untrack(txs[i])
+			p.recordDrop(addr, txs[i], reason)
 
 			// Included transactions blobs need to be moved to the limbo
 			if filled && inclusions != nil {
@@ -581,6 +604,7 @@ func (p *BlobPool) recheck(addr common.Address, inclusions map[common.Hash]uint6
 			p.spent[addr] = new(uint256.Int).Sub(p.spent[addr], txs[0].costCap)
 			p.stored -= uint64(txs[0].size)
 			p.lookup.untrack(txs[0])
+			p.recordDrop(addr, txs[0], txpool.DropStale)
 
 			// Included transactions blobs need to be moved to the limbo
 			if inclusions != nil {
@@ -637,6 +661,7 @@ func (p *BlobPool) recheck(addr common.Address, inclusions map[common.Hash]uint6
 			p.spent[addr] = new(uint256.Int).Sub(p.spent[addr], txs[i].costCap)
 			p.stored -= uint64(txs[i].size)
 			p.lookup.untrack(txs[i])
+			p.recordDrop(addr, txs[i], txpool.DropInvalid)
 
 			if err := p.store.Delete(id); err != nil {
 				log.Error("Failed to delete blob transaction", "from", addr, "id", id, "err", err)
@@ -659,6 +684,7 @@ func (p *BlobPool) recheck(addr common.Address, inclusions map[common.Hash]uint6
 			p.spent[addr] = new(uint256.Int).Sub(p.spent[addr], txs[j].costCap)
 			p.stored -= uint64(txs[j].size)
 			p.lookup.untrack(txs[j])
+			p.recordDrop(addr, txs[j], txpool.DropGapped)
 		}
 		txs = txs[:i]
 
@@ -697,6 +723,7 @@ func (p *BlobPool) recheck(addr common.Address, inclusions map[common.Hash]uint6
 			p.spent[addr] = new(uint256.Int).Sub(p.spent[addr], last.costCap)
 			p.stored -= uint64(last.size)
 			p.lookup.untrack(last)
+			p.recordDrop(addr, last, txpool.DropUnpayable)
 		}
 		if len(txs) == 0 {
 			delete(p.index, addr)
@@ -737,6 +764,7 @@ func (p *BlobPool) recheck(addr common.Address, inclusions map[common.Hash]uint6
 			p.spent[addr] = new(uint256.Int).Sub(p.spent[addr], last.costCap)
 			p.stored -= uint64(last.size)
 			p.lookup.untrack(last)
+			p.recordDrop(addr, last, txpool.DropOverflow)
 		}
 		p.index[addr] = txs
 
@@ -792,6 +820,7 @@ func (p *BlobPool) Reset(oldHead, newHead *types.Header) {
 	p.lock.Lock()
 	resetwaitHist.Update(time.Since(waitStart).Nanoseconds())
 	defer p.lock.Unlock()
+	defer p.sendEvents()
 
 	defer func(start time.Time) {
 		resettimeHist.Update(time.Since(start).Nanoseconds())
@@ -814,6 +843,8 @@ func (p *BlobPool) Reset(oldHead, newHead *types.Header) {
 			for _, tx := range txs {
 				if err := p.reinject(addr, tx.Hash()); err == nil {
 					adds = append(adds, tx.WithoutBlobTxSidecar())
+					p.recordEvent(txpool.NewTxEvent(txpool.TxAdded, tx, addr))
+					p.recordEvent(txpool.NewTxEvent(txpool.TxPromoted, tx, addr))
 				}
 			}
 			// Recheck the account's pooled transactions to drop included and
@@ -1024,6 +1055,7 @@ func (p *BlobPool) reinject(addr common.Address, txhash common.Hash) error {
 func (p *BlobPool) SetGasTip(tip *big.Int) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
+	defer p.sendEvents()
 
 	// Store the new minimum gas tip
 	old := p.gasTip
@@ -1042,6 +1074,7 @@ func (p *BlobPool) SetGasTip(tip *big.Int) {
 					p.spent[addr] = new(uint256.Int).Sub(p.spent[addr], txs[i].costCap)
 					p.stored -= uint64(tx.size)
 					p.lookup.untrack(tx)
+					p.recordDrop(addr, tx, txpool.DropUnderpriced)
 					txs[i] = nil
 
 					// Drop everything afterwards, no gaps allowed
@@ -1052,6 +1085,7 @@ func (p *BlobPool) SetGasTip(tip *big.Int) {
 						p.spent[addr] = new(uint256.Int).Sub(p.spent[addr], tx.costCap)
 						p.stored -= uint64(tx.size)
 						p.lookup.untrack(tx)
+						p.recordDrop(addr, tx, txpool.DropUnderpriced)
 						txs[i+1+j] = nil
 					}
 					// Clear out the dropped transactions from the index
@@ -1171,6 +1205,22 @@ func (p *BlobPool) validateTx(tx *types.Transaction) error {
 			return fmt.Errorf("%w: new tx blob gas fee cap %v < %v queued + %d%% replacement penalty", txpool.ErrReplaceUnderpriced, tx.BlobGasFeeCap(), prev.blobFeeCap, p.config.PriceBump)
 		}
 	}
+	// Ensure the transaction is permitted by the admission policy. Only the
+	// rejections are enforced, blob transactions can't be deprioritized or
+	// tagged.
+	if p.config.Policy != nil {
+		req := &txpool.PolicyRequest{
+			Tx:      tx,
+			From:    from,
+			Head:    p.head,
+			State:   p.state,
+			Pending: len(p.lookup.txIndex),
+			Pooled:  len(p.index[from]),
+		}
+		if _, err := txpool.ApplyPolicy(p.config.Policy, req); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -1266,6 +1316,19 @@ func (p *BlobPool) GetBlobs(vhashes []common.Hash) ([]*kzg4844.Blob, []*kzg4844.
 	return blobs, proofs
 }
 
+// Remove is not supported by the blob pool, as blob transactions can't be
+// submitted privately. It is just here to implement the txpool.SubPool interface.
+func (p *BlobPool) Remove(hash common.Hash) bool {
+	return false
+}
+
+// Tags is not supported by the blob pool, as it only enforces the rejections
+// of the admission policy. It is just here to implement the txpool.SubPool
+// interface.
+func (p *BlobPool) Tags(hash common.Hash) []string {
+	return nil
+}
+
 // Add inserts a set of blob transactions into the pool if they pass validation (both
 // consensus validity and pool restrictions).
 func (p *BlobPool) Add(txs []*types.Transaction, local bool, sync bool) []error {
@@ -1296,6 +1359,7 @@ func (p *BlobPool) add(tx *types.Transaction) (err error) {
 	p.lock.Lock()
 	addwaitHist.Update(time.Since(waitStart).Nanoseconds())
 	defer p.lock.Unlock()
+	defer p.sendEvents()
 
 	defer func(start time.Time) {
 		addtimeHist.Update(time.Since(start).Nanoseconds())
@@ -1317,6 +1381,8 @@ func (p *BlobPool) add(tx *types.Transaction) (err error) {
 			addOvercappedMeter.Mark(1)
 		case errors.Is(err, txpool.ErrReplaceUnderpriced):
 			addNoreplaceMeter.Mark(1)
+		case errors.Is(err, txpool.ErrPolicyRejected):
+			addPolicyMeter.Mark(1)
 		default:
 			addInvalidMeter.Mark(1)
 		}
@@ -1382,6 +1448,9 @@ func (p *BlobPool) add(tx *types.Transaction) (err error) {
 		p.lookup.untrack(prev)
 		p.lookup.track(meta)
 		p.stored += uint64(meta.size) - uint64(prev.size)
+
+		replaced := txpool.TxEvent{Type: txpool.TxReplaced, Hash: prev.hash, From: from, Nonce: prev.nonce, ReplacedBy: meta.hash}
+		p.recordEvent(replaced)
 	} else {
 		// Transaction extends previously scheduled ones
 		p.index[from] = append(p.index[from], meta)
@@ -1393,6 +1462,9 @@ func (p *BlobPool) add(tx *types.Transaction) (err error) {
 		p.lookup.track(meta)
 		p.stored += uint64(meta.size)
 	}
+	// Blob transactions are executable as soon as they enter the pool
+	p.recordEvent(txpool.NewTxEvent(txpool.TxAdded, tx, from))
+	p.recordEvent(txpool.NewTxEvent(txpool.TxPromoted, tx, from))
 	// Recompute the rolling eviction fields. In case of a replacement, this will
 	// recompute all subsequent fields. In case of an append, this will only do
 	// the fresh calculation.
@@ -1480,6 +1552,7 @@ func (p *BlobPool) drop() {
 	}
 	p.stored -= uint64(drop.size)
 	p.lookup.untrack(drop)
+	p.recordDrop(from, drop, txpool.DropOverflow)
 
 	// Remove the transaction from the pool's eviction heap:
 	//   - If the entire account was dropped, pop off the address
@@ -1655,6 +1728,55 @@ func (p *BlobPool) SubscribeTransactions(ch chan<- core.NewTxsEvent, reorgs bool
 	}
 }
 
+// SubscribeEvents registers a subscription for the lifecycle events of the
+// pooled transactions.
+func (p *BlobPool) SubscribeEvents(ch chan<- []txpool.TxEvent) event.Subscription {
+	return p.eventFeed.Subscribe(ch)
+}
+
+// recordEvent queues a lifecycle event to be announced at the end of the pool
+// operation.
+func (p *BlobPool) recordEvent(ev txpool.TxEvent) {
+	p.events = append(p.events, ev)
+}
+
+// recordDrop queues the removal event of a pooled transaction.
+func (p *BlobPool) recordDrop(addr common.Address, meta *blobTxMeta, reason txpool.DropReason) {
+	p.recordEvent(txpool.TxEvent{Type: txpool.TxDropped, Hash: meta.hash, From: addr, Nonce: meta.nonce, Reason: reason})
+}
+
+// sendEvents requests the queued lifecycle events to be announced. The events
+// are sent from a single background routine to keep them in order, without
+// blocking the pool on slow subscribers.
+func (p *BlobPool) sendEvents() {
+	select {
+	case p.eventCh <- struct{}{}:
+	default:
+	}
+}
+
+// eventLoop announces the queued lifecycle events to the subscribers whenever
+// requested.
+func (p *BlobPool) eventLoop() {
+	defer p.wg.Done()
+
+	for {
+		select {
+		case <-p.eventCh:
+			p.lock.Lock()
+			events := p.events
+			p.events = nil
+			p.lock.Unlock()
+
+			if len(events) > 0 {
+				p.eventFeed.Send(events)
+			}
+		case <-p.quit:
+			return
+		}
+	}
+}
+
 // Nonce returns the next nonce of an account, with all transactions executable
 // by the pool already applied on top.
 func (p *BlobPool) Nonce(addr common.Address) uint64 {
@@ -1699,6 +1821,65 @@ func (p *BlobPool) ContentFrom(addr common.Address) ([]*types.Transaction, []*ty
 	return []*types.Transaction{}, []*types.Transaction{}
 }
 
+// Explain retrieves the standing of the transactions of an address, sorted by
+// nonce, detailing why they may not be executable or may get evicted.
+//
+// The blob pool does not track gapped transactions, so all of them are reported
+// as pending, even if their fee caps don't allow them to be included yet.
+func (p *BlobPool) Explain(addr common.Address) []*txpool.TxExplanation {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	txs := p.index[addr]
+	if len(txs) == 0 {
+		return nil
+	}
+	var (
+		basefee = uint256.MustFromBig(eip1559.CalcBaseFee(p.chain.Config(), p.head))
+		blobfee = uint256.NewInt(params.BlobTxMinBlobGasprice)
+	)
+	if p.head.ExcessBlobGas != nil {
+		blobfee = uint256.MustFromBig(eip4844.CalcBlobFee(*p.head.ExcessBlobGas))
+	}
+	// The pool evicts the last transaction of the cheapest account, so estimate
+	// the eviction order by counting the transactions of the accounts that the
+	// eviction heap sorts before this one
+	var ahead uint64
+	for other, i := range p.evict.index {
+		if other != addr && p.evict.Less(i, p.evict.index[addr]) {
+			ahead += uint64(len(p.index[other]))
+		}
+	}
+	var (
+		balance = p.state.GetBalance(addr)
+		next    = p.state.GetNonce(addr)
+		cost    = new(uint256.Int)
+		gap     uint64
+	)
+	explanations := make([]*txpool.TxExplanation, 0, len(txs))
+	for i, meta := range txs {
+		if meta.nonce >= next {
+			gap += meta.nonce - next
+			next = meta.nonce + 1
+		}
+		cost.Add(cost, meta.costCap)
+
+		explanations = append(explanations, &txpool.TxExplanation{
+			Hash:                meta.hash,
+			Nonce:               meta.nonce,
+			Pending:             true,
+			NonceGap:            gap,
+			Cost:                new(uint256.Int).Set(cost),
+			InsufficientBalance: balance.Lt(cost),
+			FeeCapTooLow:        meta.execFeeCap.Lt(basefee),
+			BlobFeeCapTooLow:    meta.blobFeeCap.Lt(blobfee),
+			Evictable:           true,
+			EvictionRank:        ahead + uint64(len(txs)-1-i),
+		})
+	}
+	return explanations
+}
+
 // Locals retrieves the accounts currently considered local by the pool.
 //
 // There is no notion of local accounts in the blob pool.
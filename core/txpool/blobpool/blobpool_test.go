@@ -25,6 +25,7 @@ import (
 	"math/big"
 	"os"
 	"path/filepath"
+	"reflect"
 	"sync"
 	"testing"
 
@@ -1449,6 +1450,82 @@ func TestAdd(t *testing.T) {
 	}
 }
 
+// Tests that the pool explains the standing of an account's transactions.
+func TestExplain(t *testing.T) {
+	// Create a temporary folder for the persistent backend
+	storage, _ := os.MkdirTemp("", "blobpool-")
+	defer os.RemoveAll(storage)
+
+	os.MkdirAll(filepath.Join(storage, pendingTransactionStore), 0700)
+	store, _ := billy.Open(billy.Options{Path: filepath.Join(storage, pendingTransactionStore)}, newSlotter(), nil)
+
+	// Insert two well paying transactions from one account and an underpriced
+	// one from another
+	var (
+		key1, _ = crypto.GenerateKey()
+		key2, _ = crypto.GenerateKey()
+
+		addr1 = crypto.PubkeyToAddress(key1.PublicKey)
+		addr2 = crypto.PubkeyToAddress(key2.PublicKey)
+
+		tx10 = makeTx(0, 1, 1100, 110, key1)
+		tx11 = makeTx(1, 1, 1100, 110, key1)
+		tx20 = makeTx(0, 1, 800, 70, key2)
+	)
+	for _, tx := range []*types.Transaction{tx10, tx11, tx20} {
+		blob, _ := rlp.EncodeToBytes(tx)
+		store.Put(blob)
+	}
+	store.Close()
+
+	// Create a blob pool out of the pre-seeded data
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	statedb.AddBalance(addr1, uint256.NewInt(1_000_000_000), tracing.BalanceChangeUnspecified)
+	statedb.AddBalance(addr2, uint256.NewInt(1_000_000_000), tracing.BalanceChangeUnspecified)
+	statedb.Commit(0, true)
+
+	chain := &testBlockChain{
+		config:  params.MainnetChainConfig,
+		basefee: uint256.NewInt(1050),
+		blobfee: uint256.NewInt(105),
+		statedb: statedb,
+	}
+	pool := New(Config{Datadir: storage}, chain)
+	if err := pool.Init(1, chain.CurrentBlock(), makeAddressReserver()); err != nil {
+		t.Fatalf("failed to create blob pool: %v", err)
+	}
+	defer pool.Close()
+
+	cost := func(txs ...*types.Transaction) *uint256.Int {
+		total := new(uint256.Int)
+		for _, tx := range txs {
+			total.Add(total, uint256.MustFromBig(tx.Cost()))
+		}
+		return total
+	}
+	// The underpriced account is evicted first, the last transactions of the
+	// accounts preceding their first ones
+	want := []*txpool.TxExplanation{
+		{Hash: tx10.Hash(), Nonce: 0, Pending: true, Cost: cost(tx10), Evictable: true, EvictionRank: 2},
+		{Hash: tx11.Hash(), Nonce: 1, Pending: true, Cost: cost(tx10, tx11), Evictable: true, EvictionRank: 1},
+	}
+	if have := pool.Explain(addr1); !reflect.DeepEqual(have, want) {
+		for i := range have {
+			t.Logf("have %d: %+v", i, have[i])
+		}
+		t.Fatalf("explanation mismatch")
+	}
+	want = []*txpool.TxExplanation{
+		{Hash: tx20.Hash(), Nonce: 0, Pending: true, Cost: cost(tx20), FeeCapTooLow: true, BlobFeeCapTooLow: true, Evictable: true, EvictionRank: 0},
+	}
+	if have := pool.Explain(addr2); !reflect.DeepEqual(have, want) {
+		t.Fatalf("underpriced explanation mismatch: have %+v, want %+v", have[0], want[0])
+	}
+	if have := pool.Explain(common.Address{}); have != nil {
+		t.Fatalf("unknown account explanation mismatch: have %+v, want nil", have)
+	}
+}
+
 // fakeBilly is a billy.Database implementation which just drops data on the floor.
 type fakeBilly struct {
 	billy.Database
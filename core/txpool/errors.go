@@ -60,4 +60,12 @@ var (
 	// input transaction of non-blob type when a blob transaction from this sender
 	// remains pending (and vice-versa).
 	ErrAlreadyReserved = errors.New("address already reserved")
+
+	// ErrPrivateBlobTx is returned if a blob transaction is submitted privately.
+	// Blob transactions are meant to be propagated for the blobs to be available.
+	ErrPrivateBlobTx = errors.New("private blob transactions not supported")
+
+	// ErrPolicyRejected is returned if a transaction is rejected by the admission
+	// policy configured for the pool.
+	ErrPolicyRejected = errors.New("rejected by pool policy")
 )
@@ -0,0 +1,70 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// TxEventType is the kind of a transaction lifecycle event in the pool.
+type TxEventType string
+
+const (
+	TxAdded    TxEventType = "added"    // Transaction entered the pool
+	TxPromoted TxEventType = "promoted" // Transaction became executable
+	TxDemoted  TxEventType = "demoted"  // Transaction became non-executable, but is kept
+	TxReplaced TxEventType = "replaced" // Transaction was replaced by another with the same nonce
+	TxDropped  TxEventType = "dropped"  // Transaction was removed from the pool
+)
+
+// DropReason is the reason of a transaction being dropped from the pool.
+type DropReason string
+
+const (
+	DropStale       DropReason = "stale"       // Nonce already used on chain, usually by the transaction itself
+	DropUnderpriced DropReason = "underpriced" // Evicted by better paying transactions or below the minimum tip
+	DropUnpayable   DropReason = "unpayable"   // Cost exceeds the sender's balance or the block gas limit
+	DropOverflow    DropReason = "overflow"    // Exceeds the pool or the account capacity
+	DropLifetime    DropReason = "lifetime"    // Non-executable for longer than the pool lifetime
+	DropGapped      DropReason = "gapped"      // Nonce gap not permitted by the pool
+	DropInvalid     DropReason = "invalid"     // Invalidated by any other pool rule
+	DropRemoved     DropReason = "removed"     // Explicitly removed from the pool
+)
+
+// TxEvent is a lifecycle event of a transaction in the pool. Transactions are
+// announced when entering the pool, and every time they move between the
+// executable and non-executable sets until they're replaced or dropped.
+type TxEvent struct {
+	Type  TxEventType
+	Hash  common.Hash
+	From  common.Address
+	Nonce uint64
+
+	ReplacedBy common.Hash // Hash of the replacement transaction, set for TxReplaced
+	Reason     DropReason  // Reason of the removal, set for TxDropped
+}
+
+// NewTxEvent creates a lifecycle event of the given kind for a transaction.
+func NewTxEvent(kind TxEventType, tx *types.Transaction, from common.Address) TxEvent {
+	return TxEvent{
+		Type:  kind,
+		Hash:  tx.Hash(),
+		From:  from,
+		Nonce: tx.Nonce(),
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+)
+
+// TxExplanation describes the standing of a pooled transaction, detailing why
+// it might not be included in the next block or might get evicted from the
+// pool when it fills up.
+type TxExplanation struct {
+	Hash    common.Hash
+	Nonce   uint64
+	Pending bool // Whether the transaction is executable or queued
+
+	// NonceGap is the number of nonces missing between the account's state
+	// nonce and the transaction that need to be filled before it can execute.
+	NonceGap uint64
+
+	// Cost is the cumulative worst case cost of all the pooled transactions of
+	// the account up to and including this one. If it exceeds the balance, the
+	// transaction cannot execute even if all previous ones do.
+	Cost                *uint256.Int
+	InsufficientBalance bool
+
+	FeeCapTooLow     bool // Fee cap is below the base fee of the next block
+	BlobFeeCapTooLow bool // Blob fee cap is below the current blob fee
+
+	// Evictable is set if the transaction may be evicted in favour of better
+	// paying ones when the pool is full, EvictionRank being an estimate of the
+	// number of transactions that would be evicted before it.
+	Evictable    bool
+	EvictionRank uint64
+}
@@ -21,6 +21,7 @@ import (
 	"errors"
 	"math"
 	"math/big"
+	"slices"
 	"sort"
 	"sync"
 	"sync/atomic"
@@ -87,6 +88,7 @@ var (
 	invalidTxMeter     = metrics.NewRegisteredMeter("txpool/invalid", nil)
 	underpricedTxMeter = metrics.NewRegisteredMeter("txpool/underpriced", nil)
 	overflowedTxMeter  = metrics.NewRegisteredMeter("txpool/overflowed", nil)
+	policyTxMeter      = metrics.NewRegisteredMeter("txpool/policy", nil) // Rejected by the admission policy
 
 	// throttleTxMeter counts how many transactions are rejected due to too-many-changes between
 	// txpool reorgs.
@@ -128,6 +130,9 @@ type Config struct {
 	Journal   string           // Journal of local transactions to survive node restarts
 	Rejournal time.Duration    // Time interval to regenerate the local transaction journal
 
+	Snapshot         string        // Snapshot of all the pooled transactions to survive node restarts
+	SnapshotInterval time.Duration // Time interval to regenerate the transaction pool snapshot
+
 	PriceLimit uint64 // Minimum gas price to enforce for acceptance into the pool
 	PriceBump  uint64 // Minimum price bump percentage to replace an already existing transaction (nonce)
 
@@ -137,6 +142,8 @@ type Config struct {
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
 
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
+
+	Policy txpool.Policy `toml:"-"` // Admission policy consulted for new transactions
 }
 
 // DefaultConfig contains the default configurations for the transaction pool.
@@ -144,6 +151,8 @@ var DefaultConfig = Config{
 	Journal:   "transactions.rlp",
 	Rejournal: time.Hour,
 
+	SnapshotInterval: 10 * time.Minute,
+
 	PriceLimit: 1,
 	PriceBump:  10,
 
@@ -163,6 +172,10 @@ func (config *Config) sanitize() Config {
 		log.Warn("Sanitizing invalid txpool journal time", "provided", conf.Rejournal, "updated", time.Second)
 		conf.Rejournal = time.Second
 	}
+	if conf.SnapshotInterval < time.Second {
+		log.Warn("Sanitizing invalid txpool snapshot interval", "provided", conf.SnapshotInterval, "updated", time.Second)
+		conf.SnapshotInterval = time.Second
+	}
 	if conf.PriceLimit < 1 {
 		log.Warn("Sanitizing invalid txpool price limit", "provided", conf.PriceLimit, "updated", DefaultConfig.PriceLimit)
 		conf.PriceLimit = DefaultConfig.PriceLimit
@@ -217,6 +230,9 @@ type LegacyPool struct {
 	locals  *accountSet // Set of local transaction to exempt from eviction rules
 	journal *journal    // Journal of local transaction to back up to disk
 
+	snapshot      *txSnapshot                   // Snapshot of all the transactions to back up to disk
+	persistFilter func(*types.Transaction) bool // Filter of the transactions to include in the journal and snapshot
+
 	reserve txpool.AddressReserver       // Address reserver to ensure exclusivity across subpools
 	pending map[common.Address]*list     // All currently processable transactions
 	queue   map[common.Address]*list     // Queued but non-processable transactions
@@ -233,6 +249,10 @@ type LegacyPool struct {
 	initDoneCh      chan struct{}  // is closed once the pool is initialized (for tests)
 
 	changesSinceReorg int // A counter for how many drops we've performed in-between reorg.
+
+	events    []txpool.TxEvent // Lifecycle events waiting to be announced (protected by mu)
+	eventFeed event.Feed       // Event feed to announce the transaction lifecycle events
+	eventCh   chan struct{}    // Notification channel of lifecycle events waiting to be announced
 }
 
 type txpoolResetRequest struct {
@@ -261,17 +281,21 @@ func New(config Config, chain BlockChain) *LegacyPool {
 		reorgDoneCh:     make(chan chan struct{}),
 		reorgShutdownCh: make(chan struct{}),
 		initDoneCh:      make(chan struct{}),
+		eventCh:         make(chan struct{}, 1),
 	}
 	pool.locals = newAccountSet(pool.signer)
 	for _, addr := range config.Locals {
 		log.Info("Setting new local account", "address", addr)
 		pool.locals.add(addr)
 	}
-	pool.priced = newPricedList(pool.all)
+	pool.priced = newPricedList(pool.all, pool.config.Policy != nil)
 
 	if !config.NoLocals && config.Journal != "" {
 		pool.journal = newTxJournal(config.Journal)
 	}
+	if config.Snapshot != "" {
+		pool.snapshot = newTxSnapshot(config.Snapshot)
+	}
 	return pool
 }
 
@@ -311,10 +335,11 @@ func (pool *LegacyPool) Init(gasTip uint64, head *types.Header, reserve txpool.A
 	pool.currentState = statedb
 	pool.pendingNonces = newNoncer(statedb)
 
-	// Start the reorg loop early, so it can handle requests generated during
-	// journal loading.
-	pool.wg.Add(1)
+	// Start the reorg and event loops early, so they can handle requests
+	// generated during journal loading.
+	pool.wg.Add(2)
 	go pool.scheduleReorgLoop()
+	go pool.eventLoop()
 
 	// If local transactions and journaling is enabled, load from disk
 	if pool.journal != nil {
@@ -325,6 +350,21 @@ func (pool *LegacyPool) Init(gasTip uint64, head *types.Header, reserve txpool.A
 			log.Warn("Failed to rotate transaction journal", "err", err)
 		}
 	}
+	// If the pool snapshot is enabled, restore the remaining transactions. They
+	// are revalidated against the current head upon insertion. The local ones
+	// are restored as locals even if the journal is disabled, the ones already
+	// loaded from the journal are rejected as known.
+	if pool.snapshot != nil {
+		restored := make(map[common.Address]time.Time)
+		add := func(txs []*types.Transaction, local bool) []error {
+			errs := pool.Add(txs, local, true)
+			pool.restoreBeats(txs, errs, restored)
+			return errs
+		}
+		if err := pool.snapshot.load(add); err != nil {
+			log.Warn("Failed to load transaction pool snapshot", "err", err)
+		}
+	}
 	pool.wg.Add(1)
 	go pool.loop()
 	return nil
@@ -340,13 +380,15 @@ func (pool *LegacyPool) loop() {
 		prevPending, prevQueued, prevStales int
 
 		// Start the stats reporting and transaction eviction tickers
-		report  = time.NewTicker(statsReportInterval)
-		evict   = time.NewTicker(evictionInterval)
-		journal = time.NewTicker(pool.config.Rejournal)
+		report   = time.NewTicker(statsReportInterval)
+		evict    = time.NewTicker(evictionInterval)
+		journal  = time.NewTicker(pool.config.Rejournal)
+		snapshot = time.NewTicker(pool.config.SnapshotInterval)
 	)
 	defer report.Stop()
 	defer evict.Stop()
 	defer journal.Stop()
+	defer snapshot.Stop()
 
 	// Notify tests that the init phase is done
 	close(pool.initDoneCh)
@@ -380,12 +422,13 @@ func (pool *LegacyPool) loop() {
 				if time.Since(pool.beats[addr]) > pool.config.Lifetime {
 					list := pool.queue[addr].Flatten()
 					for _, tx := range list {
-						pool.removeTx(tx.Hash(), true, true)
+						pool.removeTx(tx.Hash(), true, true, txpool.DropLifetime)
 					}
 					queuedEvictionMeter.Mark(int64(len(list)))
 				}
 			}
 			pool.mu.Unlock()
+			pool.sendEvents()
 
 		// Handle local transaction journal rotation
 		case <-journal.C:
@@ -396,6 +439,10 @@ func (pool *LegacyPool) loop() {
 				}
 				pool.mu.Unlock()
 			}
+
+		// Handle transaction pool snapshot regeneration
+		case <-snapshot.C:
+			pool.writeSnapshot()
 		}
 	}
 }
@@ -409,10 +456,79 @@ func (pool *LegacyPool) Close() error {
 	if pool.journal != nil {
 		pool.journal.close()
 	}
+	pool.writeSnapshot()
 	log.Info("Transaction pool stopped")
 	return nil
 }
 
+// SetPersistFilter sets the filter deciding whether a transaction is included
+// in the local transaction journal and the pool snapshot, allowing to exclude
+// the transactions which must not survive restarts.
+func (pool *LegacyPool) SetPersistFilter(keep func(tx *types.Transaction) bool) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pool.persistFilter = keep
+}
+
+// persisted returns whether a transaction may be written to the journal or the
+// snapshot, according to the persist filter.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *LegacyPool) persisted(tx *types.Transaction) bool {
+	return pool.persistFilter == nil || pool.persistFilter(tx)
+}
+
+// restoreBeats seeds the heartbeats of the accounts with queued transactions
+// restored from the snapshot with the latest first seen time of them, instead
+// of the time of the restoration, so that the restarts don't extend the lifetime
+// of the queued transactions. The restored map tracks the latest times of the
+// accounts across the batches.
+func (pool *LegacyPool) restoreBeats(txs []*types.Transaction, errs []error, restored map[common.Address]time.Time) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	for i, tx := range txs {
+		if errs[i] != nil {
+			continue
+		}
+		from, _ := types.Sender(pool.signer, tx) // already validated
+		if beat, ok := restored[from]; !ok || tx.Time().After(beat) {
+			restored[from] = tx.Time()
+		}
+		if _, ok := pool.queue[from]; ok {
+			pool.beats[from] = restored[from]
+		}
+	}
+}
+
+// writeSnapshot regenerates the pool snapshot with all the pending and queued
+// transactions, if the snapshot is enabled.
+func (pool *LegacyPool) writeSnapshot() {
+	if pool.snapshot == nil {
+		return
+	}
+	pool.mu.RLock()
+	var entries []*snapshotEntry
+	for _, lists := range []map[common.Address]*list{pool.pending, pool.queue} {
+		for addr, list := range lists {
+			local := pool.locals.contains(addr)
+			for _, tx := range list.Flatten() {
+				entries = append(entries, newSnapshotEntry(tx, local))
+			}
+		}
+	}
+	keep := pool.persistFilter
+	pool.mu.RUnlock()
+
+	if keep != nil {
+		entries = slices.DeleteFunc(entries, func(entry *snapshotEntry) bool { return !keep(entry.Tx) })
+	}
+	if err := pool.snapshot.write(entries); err != nil {
+		log.Warn("Failed to write transaction pool snapshot", "err", err)
+	}
+}
+
 // Reset implements txpool.SubPool, allowing the legacy pool's internal state to be
 // kept in sync with the main transaction pool's internal state.
 func (pool *LegacyPool) Reset(oldHead, newHead *types.Header) {
@@ -430,11 +546,81 @@ func (pool *LegacyPool) SubscribeTransactions(ch chan<- core.NewTxsEvent, reorgs
 	return pool.txFeed.Subscribe(ch)
 }
 
+// SubscribeEvents registers a subscription for the lifecycle events of the
+// pooled transactions.
+func (pool *LegacyPool) SubscribeEvents(ch chan<- []txpool.TxEvent) event.Subscription {
+	return pool.eventFeed.Subscribe(ch)
+}
+
+// recordEvent queues a lifecycle event of a transaction to be announced after
+// the pool lock is released.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *LegacyPool) recordEvent(kind txpool.TxEventType, tx *types.Transaction) {
+	from, _ := types.Sender(pool.signer, tx) // already validated during insertion
+	pool.events = append(pool.events, txpool.NewTxEvent(kind, tx, from))
+}
+
+// recordReplace queues the replacement event of a transaction.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *LegacyPool) recordReplace(old *types.Transaction, tx *types.Transaction) {
+	from, _ := types.Sender(pool.signer, old) // already validated during insertion
+
+	ev := txpool.NewTxEvent(txpool.TxReplaced, old, from)
+	ev.ReplacedBy = tx.Hash()
+	pool.events = append(pool.events, ev)
+}
+
+// recordDrop queues the removal event of a transaction.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *LegacyPool) recordDrop(tx *types.Transaction, reason txpool.DropReason) {
+	from, _ := types.Sender(pool.signer, tx) // already validated during insertion
+
+	ev := txpool.NewTxEvent(txpool.TxDropped, tx, from)
+	ev.Reason = reason
+	pool.events = append(pool.events, ev)
+}
+
+// sendEvents requests the queued lifecycle events to be announced. The events
+// are sent from a single background routine to keep them in order, without
+// blocking the pool on slow subscribers.
+func (pool *LegacyPool) sendEvents() {
+	select {
+	case pool.eventCh <- struct{}{}:
+	default:
+	}
+}
+
+// eventLoop announces the queued lifecycle events to the subscribers whenever
+// requested.
+func (pool *LegacyPool) eventLoop() {
+	defer pool.wg.Done()
+
+	for {
+		select {
+		case <-pool.eventCh:
+			pool.mu.Lock()
+			events := pool.events
+			pool.events = nil
+			pool.mu.Unlock()
+
+			if len(events) > 0 {
+				pool.eventFeed.Send(events)
+			}
+		case <-pool.reorgShutdownCh:
+			return
+		}
+	}
+}
+
 // SetGasTip updates the minimum gas tip required by the transaction pool for a
 // new transaction, and drops all transactions below this threshold.
 func (pool *LegacyPool) SetGasTip(tip *big.Int) {
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
+	defer pool.sendEvents()
 
 	var (
 		newTip = uint256.MustFromBig(tip)
@@ -446,7 +632,7 @@ func (pool *LegacyPool) SetGasTip(tip *big.Int) {
 		// pool.priced is sorted by GasFeeCap, so we have to iterate through pool.all instead
 		drop := pool.all.RemotesBelowTip(tip)
 		for _, tx := range drop {
-			pool.removeTx(tx.Hash(), false, true)
+			pool.removeTx(tx.Hash(), false, true, txpool.DropUnderpriced)
 		}
 		pool.priced.Removed(len(drop))
 	}
@@ -519,6 +705,110 @@ func (pool *LegacyPool) ContentFrom(addr common.Address) ([]*types.Transaction,
 	return pending, queued
 }
 
+// Explain retrieves the standing of the transactions of an address, sorted by
+// nonce, detailing why they may not be executable or may get evicted.
+func (pool *LegacyPool) Explain(addr common.Address) []*txpool.TxExplanation {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	// Gather the transactions of the account, pending ones always preceding
+	// the queued ones nonce-wise
+	var (
+		txs     []*types.Transaction
+		pending int
+	)
+	if list, ok := pool.pending[addr]; ok {
+		txs = list.Flatten()
+		pending = len(txs)
+	}
+	if list, ok := pool.queue[addr]; ok {
+		txs = append(txs, list.Flatten()...)
+	}
+	if len(txs) == 0 {
+		return nil
+	}
+	var (
+		prices  = &priceHeap{policy: pool.priced.urgent.policy, baseFee: pool.priced.urgent.baseFee}
+		balance = pool.currentState.GetBalance(addr)
+		next    = pool.currentState.GetNonce(addr)
+		cost    = new(uint256.Int)
+		gap     uint64
+	)
+	explanations := make([]*txpool.TxExplanation, 0, len(txs))
+	for i, tx := range txs {
+		if nonce := tx.Nonce(); nonce >= next {
+			gap += nonce - next
+			next = nonce + 1
+		}
+		txcost, _ := uint256.FromBig(tx.Cost())
+		cost.Add(cost, txcost)
+
+		explanations = append(explanations, &txpool.TxExplanation{
+			Hash:                tx.Hash(),
+			Nonce:               tx.Nonce(),
+			Pending:             i < pending,
+			NonceGap:            gap,
+			Cost:                new(uint256.Int).Set(cost),
+			InsufficientBalance: balance.Lt(cost),
+			FeeCapTooLow:        prices.baseFee != nil && tx.GasFeeCapIntCmp(prices.baseFee) < 0,
+			Evictable:           pool.all.GetRemote(tx.Hash()) != nil,
+		})
+	}
+	pool.rankEvictions(prices, txs, explanations)
+	return explanations
+}
+
+// rankEvictions estimates the eviction order of the evictable transactions of
+// an account with the same pricing rules as the priced list, by counting the
+// remote transactions sorted before them. Instead of comparing every remote
+// transaction against every transaction of the account, the account's ones are
+// sorted by price and each remote transaction is binary searched among them.
+func (pool *LegacyPool) rankEvictions(prices *priceHeap, txs []*types.Transaction, explanations []*txpool.TxExplanation) {
+	less := func(a, b *types.Transaction) bool {
+		switch prices.cmp(a, b) {
+		case -1:
+			return true
+		case 1:
+			return false
+		default:
+			return a.Nonce() > b.Nonce()
+		}
+	}
+	var evictable []int
+	for i, explanation := range explanations {
+		if explanation.Evictable {
+			evictable = append(evictable, i)
+		}
+	}
+	if len(evictable) == 0 {
+		return
+	}
+	sort.Slice(evictable, func(i, j int) bool {
+		return less(txs[evictable[i]], txs[evictable[j]])
+	})
+	// Gather the remote transactions first, as the price comparison might need
+	// to access the lookup too
+	remotes := make([]*types.Transaction, 0, pool.all.RemoteCount())
+	pool.all.Range(func(hash common.Hash, tx *types.Transaction, local bool) bool {
+		remotes = append(remotes, tx)
+		return true
+	}, false, true) // Only iterate remotes
+
+	// Count the remote transactions sorted right before each of the account's
+	// ones, accumulating them afterwards into the ranks
+	counts := make([]uint64, len(evictable)+1)
+	for _, remote := range remotes {
+		counts[sort.Search(len(evictable), func(i int) bool {
+			return less(remote, txs[evictable[i]])
+		})]++
+	}
+	var rank uint64
+	for i, idx := range evictable {
+		rank += counts[i]
+		explanations[idx].EvictionRank = rank
+	}
+}
+
 // Pending retrieves all currently processable transactions, grouped by origin
 // account and sorted by nonce.
 //
@@ -585,9 +875,9 @@ func (pool *LegacyPool) Locals() []common.Address {
 	return pool.locals.flatten()
 }
 
-// local retrieves all currently known local transactions, grouped by origin
-// account and sorted by nonce. The returned transaction set is a copy and can be
-// freely modified by calling code.
+// local retrieves all currently known local transactions to be journaled,
+// grouped by origin account and sorted by nonce. The returned transaction set
+// is a copy and can be freely modified by calling code.
 func (pool *LegacyPool) local() map[common.Address]types.Transactions {
 	txs := make(map[common.Address]types.Transactions)
 	for addr := range pool.locals.accounts {
@@ -597,6 +887,11 @@ func (pool *LegacyPool) local() map[common.Address]types.Transactions {
 		if queued := pool.queue[addr]; queued != nil {
 			txs[addr] = append(txs[addr], queued.Flatten()...)
 		}
+		if list := slices.DeleteFunc(txs[addr], func(tx *types.Transaction) bool { return !pool.persisted(tx) }); len(list) > 0 {
+			txs[addr] = list
+		} else {
+			delete(txs, addr)
+		}
 	}
 	return txs
 }
@@ -662,6 +957,32 @@ func (pool *LegacyPool) validateTx(tx *types.Transaction) error {
 	return nil
 }
 
+// admitTx consults the admission policy about a transaction which passed the
+// validation rules of the pool.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *LegacyPool) admitTx(tx *types.Transaction, from common.Address, local bool) (txpool.PolicyResult, error) {
+	pending, queued := pool.stats()
+
+	var pooled int
+	if list := pool.pending[from]; list != nil {
+		pooled += list.Len()
+	}
+	if list := pool.queue[from]; list != nil {
+		pooled += list.Len()
+	}
+	return txpool.ApplyPolicy(pool.config.Policy, &txpool.PolicyRequest{
+		Tx:      tx,
+		From:    from,
+		Local:   local,
+		Head:    pool.currentHead.Load(),
+		State:   pool.currentState,
+		Pending: pending,
+		Queued:  queued,
+		Pooled:  pooled,
+	})
+}
+
 // add validates a transaction and inserts it into the non-executable queue for later
 // pending promotion and execution. If the transaction is a replacement for an already
 // pending or queued one, it overwrites the previous transaction if its price is higher.
@@ -690,6 +1011,27 @@ func (pool *LegacyPool) add(tx *types.Transaction, local bool) (replaced bool, e
 	// already validated by this point
 	from, _ := types.Sender(pool.signer, tx)
 
+	// If the transaction is not permitted by the admission policy, discard it
+	if pool.config.Policy != nil {
+		res, perr := pool.admitTx(tx, from, isLocal)
+		if perr != nil {
+			log.Trace("Discarding transaction rejected by policy", "hash", hash, "err", perr)
+			policyTxMeter.Mark(1)
+			return false, perr
+		}
+		// Track the policy decision before the pricing checks, as deprioritized
+		// transactions are considered cheaper than any other. Drop the decision
+		// if the transaction is rejected later on.
+		//
+		// Note, `err` here is the named error return, same as with the address
+		// reservation below.
+		pool.all.SetPolicy(hash, res)
+		defer func() {
+			if err != nil {
+				pool.all.SetPolicy(hash, txpool.PolicyResult{})
+			}
+		}()
+	}
 	// If the address is not yet known, request exclusivity to track the account
 	// only by this subpool until all transactions are evicted
 	var (
@@ -768,7 +1110,7 @@ func (pool *LegacyPool) add(tx *types.Transaction, local bool) (replaced bool, e
 			underpricedTxMeter.Mark(1)
 
 			sender, _ := types.Sender(pool.signer, tx)
-			dropped := pool.removeTx(tx.Hash(), false, sender != from) // Don't unreserve the sender of the tx being added if last from the acc
+			dropped := pool.removeTx(tx.Hash(), false, sender != from, txpool.DropUnderpriced) // Don't unreserve the sender of the tx being added if last from the acc
 
 			pool.changesSinceReorg += dropped
 		}
@@ -787,11 +1129,14 @@ func (pool *LegacyPool) add(tx *types.Transaction, local bool) (replaced bool, e
 			pool.all.Remove(old.Hash())
 			pool.priced.Removed(1)
 			pendingReplaceMeter.Mark(1)
+			pool.recordReplace(old, tx)
 		}
 		pool.all.Add(tx, isLocal)
 		pool.priced.Put(tx, isLocal)
 		pool.journalTx(from, tx)
 		pool.queueTxEvent(tx)
+		pool.recordEvent(txpool.TxAdded, tx)
+		pool.recordEvent(txpool.TxPromoted, tx)
 		log.Trace("Pooled new executable transaction", "hash", hash, "from", from, "to", tx.To())
 
 		// Successful promotion, bump the heartbeat
@@ -803,6 +1148,8 @@ func (pool *LegacyPool) add(tx *types.Transaction, local bool) (replaced bool, e
 	if err != nil {
 		return false, err
 	}
+	pool.recordEvent(txpool.TxAdded, tx)
+
 	// Mark local addresses and journal local transactions
 	if local && !pool.locals.contains(from) {
 		log.Info("Setting new local account", "address", from)
@@ -862,6 +1209,7 @@ func (pool *LegacyPool) enqueueTx(hash common.Hash, tx *types.Transaction, local
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed(1)
 		queuedReplaceMeter.Mark(1)
+		pool.recordReplace(old, tx)
 	} else {
 		// Nothing was replaced, bump the queued counter
 		queuedGauge.Inc(1)
@@ -885,8 +1233,8 @@ func (pool *LegacyPool) enqueueTx(hash common.Hash, tx *types.Transaction, local
 // journalTx adds the specified transaction to the local disk journal if it is
 // deemed to have been sent from a local account.
 func (pool *LegacyPool) journalTx(from common.Address, tx *types.Transaction) {
-	// Only journal if it's enabled and the transaction is local
-	if pool.journal == nil || !pool.locals.contains(from) {
+	// Only journal if it's enabled and the transaction is local and persisted
+	if pool.journal == nil || !pool.locals.contains(from) || !pool.persisted(tx) {
 		return
 	}
 	if err := pool.journal.insert(tx); err != nil {
@@ -911,6 +1259,7 @@ func (pool *LegacyPool) promoteTx(addr common.Address, hash common.Hash, tx *typ
 		pool.all.Remove(hash)
 		pool.priced.Removed(1)
 		pendingDiscardMeter.Mark(1)
+		pool.recordDrop(tx, txpool.DropUnderpriced)
 		return false
 	}
 	// Otherwise discard any previous transaction and mark this
@@ -918,12 +1267,14 @@ func (pool *LegacyPool) promoteTx(addr common.Address, hash common.Hash, tx *typ
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed(1)
 		pendingReplaceMeter.Mark(1)
+		pool.recordReplace(old, tx)
 	} else {
 		// Nothing was replaced, bump the pending counter
 		pendingGauge.Inc(1)
 	}
 	// Set the potentially new pending nonce and notify any subsystems of the new tx
 	pool.pendingNonces.set(addr, tx.Nonce()+1)
+	pool.recordEvent(txpool.TxPromoted, tx)
 
 	// Successful promotion, bump the heartbeat
 	pool.beats[addr] = time.Now()
@@ -1011,6 +1362,7 @@ func (pool *LegacyPool) Add(txs []*types.Transaction, local, sync bool) []error
 	pool.mu.Lock()
 	newErrs, dirtyAddrs := pool.addTxsLocked(news, local)
 	pool.mu.Unlock()
+	pool.sendEvents()
 
 	var nilSlot = 0
 	for _, err := range newErrs {
@@ -1090,6 +1442,26 @@ func (pool *LegacyPool) Has(hash common.Hash) bool {
 	return pool.all.Get(hash) != nil
 }
 
+// Tags returns the labels attached to a pooled transaction by the admission
+// policy.
+func (pool *LegacyPool) Tags(hash common.Hash) []string {
+	return pool.all.Tags(hash)
+}
+
+// Remove drops the transaction with the given hash from the pool, moving all
+// the subsequent transactions of the sender back to the future queue.
+func (pool *LegacyPool) Remove(hash common.Hash) bool {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	defer pool.sendEvents()
+
+	if pool.all.Get(hash) == nil {
+		return false
+	}
+	pool.removeTx(hash, true, true, txpool.DropRemoved)
+	return true
+}
+
 // removeTx removes a single transaction from the queue, moving all subsequent
 // transactions back to the future queue.
 //
@@ -1098,8 +1470,10 @@ func (pool *LegacyPool) Has(hash common.Hash) bool {
 // a tx being added, and it evicts a previously scheduled tx from the same account,
 // which could lead to a premature release of the lock.
 //
+// The reason is announced in the lifecycle event of the removed transaction.
+//
 // Returns the number of transactions removed from the pending queue.
-func (pool *LegacyPool) removeTx(hash common.Hash, outofbound bool, unreserve bool) int {
+func (pool *LegacyPool) removeTx(hash common.Hash, outofbound bool, unreserve bool, reason txpool.DropReason) int {
 	// Fetch the transaction we wish to delete
 	tx := pool.all.Get(hash)
 	if tx == nil {
@@ -1126,6 +1500,7 @@ func (pool *LegacyPool) removeTx(hash common.Hash, outofbound bool, unreserve bo
 	if outofbound {
 		pool.priced.Removed(1)
 	}
+	pool.recordDrop(tx, reason)
 	if pool.locals.contains(addr) {
 		localGauge.Dec(1)
 	}
@@ -1140,6 +1515,7 @@ func (pool *LegacyPool) removeTx(hash common.Hash, outofbound bool, unreserve bo
 			for _, tx := range invalids {
 				// Internal shuffle shouldn't touch the lookup set.
 				pool.enqueueTx(tx.Hash(), tx, false, false)
+				pool.recordEvent(txpool.TxDemoted, tx)
 			}
 			// Update the account nonce if needed
 			pool.pendingNonces.setIfLower(addr, tx.Nonce())
@@ -1327,6 +1703,7 @@ func (pool *LegacyPool) runReorg(done chan struct{}, reset *txpoolResetRequest,
 	dropBetweenReorgHistogram.Update(int64(pool.changesSinceReorg))
 	pool.changesSinceReorg = 0 // Reset change counter
 	pool.mu.Unlock()
+	pool.sendEvents()
 
 	// Notify subsystems for newly added transactions
 	for _, tx := range promoted {
@@ -1463,6 +1840,7 @@ func (pool *LegacyPool) promoteExecutables(accounts []common.Address) []*types.T
 		for _, tx := range forwards {
 			hash := tx.Hash()
 			pool.all.Remove(hash)
+			pool.recordDrop(tx, txpool.DropStale)
 		}
 		log.Trace("Removed old queued transactions", "count", len(forwards))
 		// Drop all transactions that are too costly (low balance or out of gas)
@@ -1470,6 +1848,7 @@ func (pool *LegacyPool) promoteExecutables(accounts []common.Address) []*types.T
 		for _, tx := range drops {
 			hash := tx.Hash()
 			pool.all.Remove(hash)
+			pool.recordDrop(tx, txpool.DropUnpayable)
 		}
 		log.Trace("Removed unpayable queued transactions", "count", len(drops))
 		queuedNofundsMeter.Mark(int64(len(drops)))
@@ -1492,6 +1871,7 @@ func (pool *LegacyPool) promoteExecutables(accounts []common.Address) []*types.T
 			for _, tx := range caps {
 				hash := tx.Hash()
 				pool.all.Remove(hash)
+				pool.recordDrop(tx, txpool.DropOverflow)
 				log.Trace("Removed cap-exceeding queued transaction", "hash", hash)
 			}
 			queuedRateLimitMeter.Mark(int64(len(caps)))
@@ -1557,6 +1937,7 @@ func (pool *LegacyPool) truncatePending() {
 						// Drop the transaction from the global pools too
 						hash := tx.Hash()
 						pool.all.Remove(hash)
+						pool.recordDrop(tx, txpool.DropOverflow)
 
 						// Update the account nonce to the dropped transaction
 						pool.pendingNonces.setIfLower(offenders[i], tx.Nonce())
@@ -1584,6 +1965,7 @@ func (pool *LegacyPool) truncatePending() {
 					// Drop the transaction from the global pools too
 					hash := tx.Hash()
 					pool.all.Remove(hash)
+					pool.recordDrop(tx, txpool.DropOverflow)
 
 					// Update the account nonce to the dropped transaction
 					pool.pendingNonces.setIfLower(addr, tx.Nonce())
@@ -1630,7 +2012,7 @@ func (pool *LegacyPool) truncateQueue() {
 		// Drop all transactions if they are less than the overflow
 		if size := uint64(list.Len()); size <= drop {
 			for _, tx := range list.Flatten() {
-				pool.removeTx(tx.Hash(), true, true)
+				pool.removeTx(tx.Hash(), true, true, txpool.DropOverflow)
 			}
 			drop -= size
 			queuedRateLimitMeter.Mark(int64(size))
@@ -1639,7 +2021,7 @@ func (pool *LegacyPool) truncateQueue() {
 		// Otherwise drop only last few transactions
 		txs := list.Flatten()
 		for i := len(txs) - 1; i >= 0 && drop > 0; i-- {
-			pool.removeTx(txs[i].Hash(), true, true)
+			pool.removeTx(txs[i].Hash(), true, true, txpool.DropOverflow)
 			drop--
 			queuedRateLimitMeter.Mark(1)
 		}
@@ -1664,6 +2046,7 @@ func (pool *LegacyPool) demoteUnexecutables() {
 		for _, tx := range olds {
 			hash := tx.Hash()
 			pool.all.Remove(hash)
+			pool.recordDrop(tx, txpool.DropStale)
 			log.Trace("Removed old pending transaction", "hash", hash)
 		}
 		// Drop all transactions that are too costly (low balance or out of gas), and queue any invalids back for later
@@ -1672,6 +2055,7 @@ func (pool *LegacyPool) demoteUnexecutables() {
 			hash := tx.Hash()
 			log.Trace("Removed unpayable pending transaction", "hash", hash)
 			pool.all.Remove(hash)
+			pool.recordDrop(tx, txpool.DropUnpayable)
 		}
 		pendingNofundsMeter.Mark(int64(len(drops)))
 
@@ -1681,6 +2065,7 @@ func (pool *LegacyPool) demoteUnexecutables() {
 
 			// Internal shuffle shouldn't touch the lookup set.
 			pool.enqueueTx(hash, tx, false, false)
+			pool.recordEvent(txpool.TxDemoted, tx)
 		}
 		pendingGauge.Dec(int64(len(olds) + len(drops) + len(invalids)))
 		if pool.locals.contains(addr) {
@@ -1695,6 +2080,7 @@ func (pool *LegacyPool) demoteUnexecutables() {
 
 				// Internal shuffle shouldn't touch the lookup set.
 				pool.enqueueTx(hash, tx, false, false)
+				pool.recordEvent(txpool.TxDemoted, tx)
 			}
 			pendingGauge.Dec(int64(len(gapped)))
 		}
@@ -1801,13 +2187,18 @@ type lookup struct {
 	lock    sync.RWMutex
 	locals  map[common.Hash]*types.Transaction
 	remotes map[common.Hash]*types.Transaction
+
+	deprioritized map[common.Hash]struct{} // Transactions deprioritized by the admission policy
+	tags          map[common.Hash][]string // Labels attached by the admission policy
 }
 
 // newLookup returns a new lookup structure.
 func newLookup() *lookup {
 	return &lookup{
-		locals:  make(map[common.Hash]*types.Transaction),
-		remotes: make(map[common.Hash]*types.Transaction),
+		locals:        make(map[common.Hash]*types.Transaction),
+		remotes:       make(map[common.Hash]*types.Transaction),
+		deprioritized: make(map[common.Hash]struct{}),
+		tags:          make(map[common.Hash][]string),
 	}
 }
 
@@ -1926,6 +2317,45 @@ func (t *lookup) Remove(hash common.Hash) {
 
 	delete(t.locals, hash)
 	delete(t.remotes, hash)
+	delete(t.deprioritized, hash)
+	delete(t.tags, hash)
+}
+
+// SetPolicy records the decision of the admission policy about a transaction.
+// It may be called before the transaction is added, so that its priority is
+// already known when making room for it.
+func (t *lookup) SetPolicy(hash common.Hash, res txpool.PolicyResult) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if res.Deprioritize {
+		t.deprioritized[hash] = struct{}{}
+	} else {
+		delete(t.deprioritized, hash)
+	}
+	if len(res.Tags) > 0 {
+		t.tags[hash] = res.Tags
+	} else {
+		delete(t.tags, hash)
+	}
+}
+
+// Deprioritized returns whether a transaction was deprioritized by the
+// admission policy.
+func (t *lookup) Deprioritized(hash common.Hash) bool {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	_, ok := t.deprioritized[hash]
+	return ok
+}
+
+// Tags returns the labels attached to a transaction by the admission policy.
+func (t *lookup) Tags(hash common.Hash) []string {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.tags[hash]
 }
 
 // RemoteToLocals migrates the transactions belongs to the given locals to locals
@@ -1991,7 +2421,7 @@ func (pool *LegacyPool) Clear() {
 	}
 
 	pool.all = newLookup()
-	pool.priced = newPricedList(pool.all)
+	pool.priced = newPricedList(pool.all, pool.config.Policy != nil)
 	pool.pending = make(map[common.Address]*list)
 	pool.queue = make(map[common.Address]*list)
 
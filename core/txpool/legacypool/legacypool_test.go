@@ -24,6 +24,9 @@ import (
 	"math/big"
 	"math/rand"
 	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -479,7 +482,7 @@ func TestChainFork(t *testing.T) {
 	if _, err := pool.add(tx, false); err != nil {
 		t.Error("didn't expect error", err)
 	}
-	pool.removeTx(tx.Hash(), true, true)
+	pool.removeTx(tx.Hash(), true, true, txpool.DropRemoved)
 
 	// reset the pool's internal state
 	resetState()
@@ -2457,6 +2460,398 @@ func testJournaling(t *testing.T, nolocals bool) {
 	pool.Close()
 }
 
+// Tests that the transactions of local accounts excluded by the persist filter
+// are neither journaled upon insertion nor upon journal rotation.
+func TestJournalingPersistFilter(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	blockchain := newTestBlockChain(params.TestChainConfig, 1000000, statedb, new(event.Feed))
+
+	config := testTxPoolConfig
+	config.Journal = filepath.Join(t.TempDir(), "transactions.rlp")
+	config.Rejournal = time.Hour
+
+	// Create a local account submitting a public and a private transaction, the
+	// latter as remote, like the private submissions are
+	local, _ := crypto.GenerateKey()
+	var (
+		public  = pricedTransaction(0, 100000, big.NewInt(1), local)
+		private = pricedTransaction(1, 100000, big.NewInt(1), local)
+	)
+	keep := func(tx *types.Transaction) bool {
+		return tx.Hash() != private.Hash()
+	}
+	restart := func(pool *LegacyPool) *LegacyPool {
+		if pool != nil {
+			pool.Close()
+		}
+		pool = New(config, blockchain)
+		pool.Init(config.PriceLimit, blockchain.CurrentBlock(), makeAddressReserver())
+		pool.SetPersistFilter(keep)
+		return pool
+	}
+	pool := restart(nil)
+	testAddBalance(pool, crypto.PubkeyToAddress(local.PublicKey), big.NewInt(1000000000))
+
+	if err := pool.addLocal(public); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	if err := pool.addRemoteSync(private); err != nil {
+		t.Fatalf("failed to add private transaction: %v", err)
+	}
+	if pending, _ := pool.Stats(); pending != 2 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 2)
+	}
+	// Restart the pool and ensure only the public transaction is reloaded
+	pool = restart(pool)
+	if pool.Get(public.Hash()) == nil {
+		t.Fatalf("public transaction missing from journal")
+	}
+	if pool.Get(private.Hash()) != nil {
+		t.Fatalf("private transaction restored from journal")
+	}
+	// Resubmit the private transaction, rotate the journal and ensure it's still
+	// not reloaded after a restart
+	if err := pool.addRemoteSync(private); err != nil {
+		t.Fatalf("failed to add private transaction: %v", err)
+	}
+	pool.mu.Lock()
+	if err := pool.journal.rotate(pool.local()); err != nil {
+		t.Fatalf("failed to rotate journal: %v", err)
+	}
+	pool.mu.Unlock()
+
+	pool = restart(pool)
+	defer pool.Close()
+
+	if pool.Get(public.Hash()) == nil {
+		t.Fatalf("public transaction missing from rotated journal")
+	}
+	if pool.Get(private.Hash()) != nil {
+		t.Fatalf("private transaction restored from rotated journal")
+	}
+}
+
+// Tests that the remote transactions are persisted into the pool snapshot and
+// restored with their first seen times, dropping the ones not valid any more
+// and the ones excluded by the persist filter.
+func TestSnapshot(t *testing.T) {
+	t.Parallel()
+
+	// Create the original pool to inject transaction into the snapshot
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	blockchain := newTestBlockChain(params.TestChainConfig, 1000000, statedb, new(event.Feed))
+
+	config := testTxPoolConfig
+	config.Snapshot = filepath.Join(t.TempDir(), "snapshot.rlp")
+	config.SnapshotInterval = time.Second
+
+	pool := New(config, blockchain)
+	pool.Init(config.PriceLimit, blockchain.CurrentBlock(), makeAddressReserver())
+
+	// Create three remote accounts, one of them being filtered out, and a local
+	// one, which is not journaled as the journal is disabled
+	stale, _ := crypto.GenerateKey()
+	gapped, _ := crypto.GenerateKey()
+	filtered, _ := crypto.GenerateKey()
+	local, _ := crypto.GenerateKey()
+
+	testAddBalance(pool, crypto.PubkeyToAddress(stale.PublicKey), big.NewInt(1000000000))
+	testAddBalance(pool, crypto.PubkeyToAddress(gapped.PublicKey), big.NewInt(1000000000))
+	testAddBalance(pool, crypto.PubkeyToAddress(filtered.PublicKey), big.NewInt(1000000000))
+	testAddBalance(pool, crypto.PubkeyToAddress(local.PublicKey), big.NewInt(1000000000))
+
+	txs := []*types.Transaction{
+		pricedTransaction(0, 100000, big.NewInt(1), stale),
+		pricedTransaction(1, 100000, big.NewInt(1), stale),
+		pricedTransaction(2, 100000, big.NewInt(1), gapped),
+		pricedTransaction(0, 100000, big.NewInt(1), filtered),
+	}
+	for i, tx := range txs {
+		tx.SetTime(time.Unix(int64(i+1), 0))
+		if err := pool.addRemoteSync(tx); err != nil {
+			t.Fatalf("failed to add remote transaction %d: %v", i, err)
+		}
+	}
+	localTx := pricedTransaction(0, 100000, big.NewInt(1), local)
+	localTx.SetTime(time.Unix(int64(len(txs)+1), 0))
+	if err := pool.addLocal(localTx); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	pending, queued := pool.Stats()
+	if pending != 4 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 4)
+	}
+	if queued != 1 {
+		t.Fatalf("queued transactions mismatched: have %d, want %d", queued, 1)
+	}
+	pool.SetPersistFilter(func(tx *types.Transaction) bool {
+		return tx.Hash() != txs[3].Hash()
+	})
+	// Terminate the old pool, bump the nonce of the first account, create a new
+	// pool and ensure the still valid transactions survive
+	pool.Close()
+	statedb.SetNonce(crypto.PubkeyToAddress(stale.PublicKey), 1)
+	blockchain = newTestBlockChain(params.TestChainConfig, 1000000, statedb, new(event.Feed))
+
+	pool = New(config, blockchain)
+	pool.Init(config.PriceLimit, blockchain.CurrentBlock(), makeAddressReserver())
+	defer pool.Close()
+
+	pending, queued = pool.Stats()
+	if pending != 2 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 2)
+	}
+	if queued != 1 {
+		t.Fatalf("queued transactions mismatched: have %d, want %d", queued, 1)
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	for i, tx := range txs {
+		have := pool.Get(tx.Hash())
+		switch i {
+		case 0, 3:
+			if have != nil {
+				t.Errorf("transaction %d restored from snapshot", i)
+			}
+		default:
+			if have == nil {
+				t.Errorf("transaction %d missing from snapshot", i)
+			} else if !have.Time().Equal(tx.Time()) {
+				t.Errorf("transaction %d time mismatch: have %v, want %v", i, have.Time(), tx.Time())
+			}
+		}
+	}
+	// Ensure the local transaction is restored as local, and the heartbeat of
+	// the queued account is restored instead of being reset
+	if pool.Get(localTx.Hash()) == nil {
+		t.Errorf("local transaction missing from snapshot")
+	}
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	if !pool.locals.contains(crypto.PubkeyToAddress(local.PublicKey)) {
+		t.Errorf("local account not restored as local")
+	}
+	if beat := pool.beats[crypto.PubkeyToAddress(gapped.PublicKey)]; !beat.Equal(txs[2].Time()) {
+		t.Errorf("queued account heartbeat mismatch: have %v, want %v", beat, txs[2].Time())
+	}
+}
+
+// Tests that the admission policy can reject, deprioritize and tag transactions,
+// with deprioritized transactions being evicted first regardless of their price.
+func TestAdmissionPolicy(t *testing.T) {
+	t.Parallel()
+
+	// Create a number of test accounts, the first blocked, the second deprioritized
+	// and the third tagged by the policy
+	keys := make([]*ecdsa.PrivateKey, 6)
+	for i := 0; i < len(keys); i++ {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	var (
+		blocked = crypto.PubkeyToAddress(keys[0].PublicKey)
+		demoted = crypto.PubkeyToAddress(keys[1].PublicKey)
+		tagged  = crypto.PubkeyToAddress(keys[2].PublicKey)
+	)
+	policy := func(req *txpool.PolicyRequest) (txpool.PolicyResult, error) {
+		switch req.From {
+		case blocked:
+			return txpool.PolicyResult{}, errors.New("sender not allowed")
+		case demoted:
+			return txpool.PolicyResult{Deprioritize: true}, nil
+		case tagged:
+			return txpool.PolicyResult{Tags: []string{"tagged"}}, nil
+		}
+		return txpool.PolicyResult{}, nil
+	}
+	// Create the pool to test the policy enforcement with
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	blockchain := newTestBlockChain(params.TestChainConfig, 1000000, statedb, new(event.Feed))
+
+	config := testTxPoolConfig
+	config.GlobalSlots = 2
+	config.GlobalQueue = 2
+	config.Policy = txpool.PolicyFunc(policy)
+
+	pool := New(config, blockchain)
+	pool.Init(config.PriceLimit, blockchain.CurrentBlock(), makeAddressReserver())
+	defer pool.Close()
+
+	for _, key := range keys {
+		testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+	}
+	// Ensure the transactions of the blocked sender are rejected
+	if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(1), keys[0])); !errors.Is(err, txpool.ErrPolicyRejected) {
+		t.Fatalf("adding blocked transaction error mismatch: have %v, want %v", err, txpool.ErrPolicyRejected)
+	}
+	// Fill up the pool with a deprioritized expensive transaction and cheap ones
+	expensive := pricedTransaction(0, 100000, big.NewInt(10), keys[1])
+	if err := pool.addRemoteSync(expensive); err != nil {
+		t.Fatalf("failed to add deprioritized transaction: %v", err)
+	}
+	cheap := pricedTransaction(0, 100000, big.NewInt(1), keys[2])
+	if err := pool.addRemoteSync(cheap); err != nil {
+		t.Fatalf("failed to add tagged transaction: %v", err)
+	}
+	for i := 3; i < 5; i++ {
+		if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(1), keys[i])); err != nil {
+			t.Fatalf("failed to add transaction %d: %v", i, err)
+		}
+	}
+	if tags := pool.Tags(cheap.Hash()); !slices.Equal(tags, []string{"tagged"}) {
+		t.Fatalf("transaction tags mismatch: have %v, want %v", tags, []string{"tagged"})
+	}
+	// Ensure a new transaction evicts the deprioritized one, even if cheaper
+	if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(2), keys[5])); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	if pool.Get(expensive.Hash()) != nil {
+		t.Fatalf("deprioritized transaction not evicted")
+	}
+	if pool.all.Deprioritized(expensive.Hash()) {
+		t.Fatalf("evicted transaction still tracked as deprioritized")
+	}
+	if pool.Get(cheap.Hash()) == nil {
+		t.Fatalf("cheap transaction evicted instead of deprioritized one")
+	}
+	// Ensure a deprioritized transaction can't evict any other one
+	rejected := pricedTransaction(0, 100000, big.NewInt(20), keys[1])
+	if err := pool.addRemoteSync(rejected); !errors.Is(err, txpool.ErrUnderpriced) {
+		t.Fatalf("adding deprioritized transaction error mismatch: have %v, want %v", err, txpool.ErrUnderpriced)
+	}
+	if pool.all.Deprioritized(rejected.Hash()) {
+		t.Fatalf("rejected transaction still tracked as deprioritized")
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that the lifecycle events of the pooled transactions are announced in
+// the order they happen.
+func TestLifecycleEvents(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupPool()
+	defer pool.Close()
+
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	testAddBalance(pool, from, big.NewInt(1000000000))
+
+	events := make(chan []txpool.TxEvent, 32)
+	sub := pool.SubscribeEvents(events)
+	defer sub.Unsubscribe()
+
+	// Add a gapped transaction, fill the gap, replace one and remove another
+	tx0 := pricedTransaction(0, 100000, big.NewInt(1), key)
+	tx1 := pricedTransaction(1, 100000, big.NewInt(1), key)
+	tx2 := pricedTransaction(2, 100000, big.NewInt(1), key)
+	tx0b := pricedTransaction(0, 100000, big.NewInt(2), key)
+
+	for _, tx := range []*types.Transaction{tx0, tx2, tx1, tx0b} {
+		if err := pool.addRemoteSync(tx); err != nil {
+			t.Fatalf("failed to add transaction %x: %v", tx.Hash(), err)
+		}
+	}
+	if !pool.Remove(tx1.Hash()) {
+		t.Fatalf("failed to remove transaction")
+	}
+	want := []txpool.TxEvent{
+		{Type: txpool.TxAdded, Hash: tx0.Hash(), From: from, Nonce: 0},
+		{Type: txpool.TxPromoted, Hash: tx0.Hash(), From: from, Nonce: 0},
+		{Type: txpool.TxAdded, Hash: tx2.Hash(), From: from, Nonce: 2},
+		{Type: txpool.TxAdded, Hash: tx1.Hash(), From: from, Nonce: 1},
+		{Type: txpool.TxPromoted, Hash: tx1.Hash(), From: from, Nonce: 1},
+		{Type: txpool.TxPromoted, Hash: tx2.Hash(), From: from, Nonce: 2},
+		{Type: txpool.TxReplaced, Hash: tx0.Hash(), From: from, Nonce: 0, ReplacedBy: tx0b.Hash()},
+		{Type: txpool.TxAdded, Hash: tx0b.Hash(), From: from, Nonce: 0},
+		{Type: txpool.TxPromoted, Hash: tx0b.Hash(), From: from, Nonce: 0},
+		{Type: txpool.TxDropped, Hash: tx1.Hash(), From: from, Nonce: 1, Reason: txpool.DropRemoved},
+		{Type: txpool.TxDemoted, Hash: tx2.Hash(), From: from, Nonce: 2},
+	}
+	var have []txpool.TxEvent
+	for len(have) < len(want) {
+		select {
+		case batch := <-events:
+			have = append(have, batch...)
+		case <-time.After(time.Second):
+			t.Fatalf("event count mismatch: have %d, want %d", len(have), len(want))
+		}
+	}
+	if !reflect.DeepEqual(have, want) {
+		t.Fatalf("event mismatch:\nhave %+v\nwant %+v", have, want)
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that the pool explains the standing of an account's transactions.
+func TestExplain(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupPool()
+	defer pool.Close()
+
+	// Create an account with an executable, an underpriced and a gapped but
+	// unaffordable transaction
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	testAddBalance(pool, from, big.NewInt(3000000))
+
+	var (
+		tx0 = pricedTransaction(0, 100000, big.NewInt(20), key)
+		tx1 = pricedTransaction(1, 100000, big.NewInt(5), key)
+		tx3 = pricedTransaction(3, 100000, big.NewInt(20), key)
+	)
+	for _, err := range pool.addRemotesSync([]*types.Transaction{tx0, tx1, tx3}) {
+		if err != nil {
+			t.Fatalf("failed to add transaction: %v", err)
+		}
+	}
+	// Add a cheaper remote and a local transaction from other accounts
+	cheap, _ := crypto.GenerateKey()
+	testAddBalance(pool, crypto.PubkeyToAddress(cheap.PublicKey), big.NewInt(1000000000))
+	if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(1), cheap)); err != nil {
+		t.Fatalf("failed to add cheap transaction: %v", err)
+	}
+	local, _ := crypto.GenerateKey()
+	testAddBalance(pool, crypto.PubkeyToAddress(local.PublicKey), big.NewInt(1000000000))
+	if err := pool.addLocal(pricedTransaction(0, 100000, big.NewInt(1), local)); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	pool.mu.Lock()
+	pool.priced.SetBaseFee(big.NewInt(10))
+	pool.mu.Unlock()
+
+	cost := func(txs ...*types.Transaction) *uint256.Int {
+		total := new(uint256.Int)
+		for _, tx := range txs {
+			total.Add(total, uint256.MustFromBig(tx.Cost()))
+		}
+		return total
+	}
+	want := []*txpool.TxExplanation{
+		{Hash: tx0.Hash(), Nonce: 0, Pending: true, Cost: cost(tx0), Evictable: true, EvictionRank: 3},
+		{Hash: tx1.Hash(), Nonce: 1, Pending: true, Cost: cost(tx0, tx1), FeeCapTooLow: true, Evictable: true, EvictionRank: 1},
+		{Hash: tx3.Hash(), Nonce: 3, Pending: false, NonceGap: 1, Cost: cost(tx0, tx1, tx3), InsufficientBalance: true, Evictable: true, EvictionRank: 2},
+	}
+	if have := pool.Explain(from); !reflect.DeepEqual(have, want) {
+		for i := range have {
+			t.Logf("have %d: %+v", i, have[i])
+		}
+		t.Fatalf("explanation mismatch")
+	}
+	if have := pool.Explain(crypto.PubkeyToAddress(local.PublicKey)); len(have) != 1 || have[0].Evictable || have[0].EvictionRank != 0 {
+		t.Fatalf("local transaction explanation mismatch: have %+v", have)
+	}
+	if have := pool.Explain(common.Address{}); have != nil {
+		t.Fatalf("unknown account explanation mismatch: have %+v, want nil", have)
+	}
+}
+
 // TestStatusCheck tests that the pool can correctly retrieve the
 // pending status of individual transactions.
 func TestStatusCheck(t *testing.T) {
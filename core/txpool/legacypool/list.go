@@ -473,8 +473,10 @@ func (l *list) subTotalCost(txs []*types.Transaction) {
 // priceHeap is a heap.Interface implementation over transactions for retrieving
 // price-sorted transactions to discard when the pool fills up. If baseFee is set
 // then the heap is sorted based on the effective tip based on the given base fee.
-// If baseFee is nil then the sorting is based on gasFeeCap.
+// If baseFee is nil then the sorting is based on gasFeeCap. Transactions that are
+// deprioritized by the admission policy are always sorted before the others.
 type priceHeap struct {
+	policy  *lookup  // Lookup to retrieve the admission policy decisions from, nil if no policy is configured
 	baseFee *big.Int // heap should always be re-sorted after baseFee is changed
 	list    []*types.Transaction
 }
@@ -494,6 +496,15 @@ func (h *priceHeap) Less(i, j int) bool {
 }
 
 func (h *priceHeap) cmp(a, b *types.Transaction) int {
+	// Deprioritized transactions are cheaper than any other
+	if h.policy != nil {
+		if da, db := h.policy.Deprioritized(a.Hash()), h.policy.Deprioritized(b.Hash()); da != db {
+			if da {
+				return -1
+			}
+			return 1
+		}
+	}
 	if h.baseFee != nil {
 		// Compare effective tips if baseFee is specified
 		if c := a.EffectiveGasTipCmp(b, h.baseFee); c != 0 {
@@ -548,11 +559,15 @@ const (
 	floatingRatio = 1
 )
 
-// newPricedList creates a new price-sorted transaction heap.
-func newPricedList(all *lookup) *pricedList {
-	return &pricedList{
-		all: all,
+// newPricedList creates a new price-sorted transaction heap. The admission policy
+// decisions are only consulted by the heaps if a policy is configured, sparing
+// the lookup access on every comparison otherwise.
+func newPricedList(all *lookup, policy bool) *pricedList {
+	l := &pricedList{all: all}
+	if policy {
+		l.urgent.policy, l.floating.policy = all, all
 	}
+	return l
 }
 
 // Put inserts a new transaction into the heap.
@@ -0,0 +1,147 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package legacypool
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// snapshotEntry is a transaction stored in the pool snapshot, along with the
+// time it was first seen and whether it was tracked as local.
+type snapshotEntry struct {
+	Tx    *types.Transaction
+	Time  uint64 // Unix time in nanoseconds
+	Local bool   `rlp:"optional"`
+}
+
+// newSnapshotEntry creates a snapshot entry of the given transaction.
+func newSnapshotEntry(tx *types.Transaction, local bool) *snapshotEntry {
+	return &snapshotEntry{Tx: tx, Time: uint64(tx.Time().UnixNano()), Local: local}
+}
+
+// txSnapshot is a periodically regenerated dump of all the transactions in the
+// pool, local and remote ones alike, with the aim of restoring the view of the
+// mempool after node restarts.
+type txSnapshot struct {
+	path string // Filesystem path to store the transactions at
+}
+
+// newTxSnapshot creates a new transaction pool snapshot at the given path.
+func newTxSnapshot(path string) *txSnapshot {
+	return &txSnapshot{
+		path: path,
+	}
+}
+
+// load parses the pool snapshot from disk, restoring the first seen times of
+// the transactions and loading them into the pool in batches, the local and
+// remote ones separately. The transactions are validated against the current
+// head by the pool, the ones not valid any more are dropped.
+func (snap *txSnapshot) load(add func(txs []*types.Transaction, local bool) []error) error {
+	input, err := os.Open(snap.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		// Skip the parsing if the snapshot file doesn't exist at all
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	var (
+		stream          = rlp.NewStream(bufio.NewReader(input), 0)
+		total, dropped  int
+		failure         error
+		locals, remotes types.Transactions
+	)
+	loadBatch := func(txs types.Transactions, local bool) {
+		for _, err := range add(txs, local) {
+			if err != nil {
+				log.Trace("Failed to add snapshotted transaction", "err", err)
+				dropped++
+			}
+		}
+	}
+	for {
+		entry := new(snapshotEntry)
+		if err = stream.Decode(entry); err != nil {
+			if err != io.EOF {
+				failure = err
+			}
+			if locals.Len() > 0 {
+				loadBatch(locals, true)
+			}
+			if remotes.Len() > 0 {
+				loadBatch(remotes, false)
+			}
+			break
+		}
+		total++
+		entry.Tx.SetTime(time.Unix(0, int64(entry.Time)))
+
+		if entry.Local {
+			if locals = append(locals, entry.Tx); locals.Len() > 1024 {
+				loadBatch(locals, true)
+				locals = locals[:0]
+			}
+		} else {
+			if remotes = append(remotes, entry.Tx); remotes.Len() > 1024 {
+				loadBatch(remotes, false)
+				remotes = remotes[:0]
+			}
+		}
+	}
+	log.Info("Loaded transaction pool snapshot", "transactions", total, "dropped", dropped)
+	return failure
+}
+
+// write regenerates the pool snapshot with the given entries.
+func (snap *txSnapshot) write(entries []*snapshotEntry) error {
+	output, err := os.OpenFile(snap.path+".new", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(output)
+	for _, entry := range entries {
+		if err := rlp.Encode(writer, entry); err != nil {
+			output.Close()
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		output.Close()
+		return err
+	}
+	if err := output.Close(); err != nil {
+		return err
+	}
+	// Replace the previous snapshot with the newly generated one
+	if err := os.Rename(snap.path+".new", snap.path); err != nil {
+		return err
+	}
+	log.Debug("Regenerated transaction pool snapshot", "transactions", len(entries))
+	return nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// PolicyRequest contains the information passed to an admission policy about
+// a transaction entering the pool.
+type PolicyRequest struct {
+	Tx    *types.Transaction // Transaction being admitted into the pool
+	From  common.Address     // Sender of the transaction
+	Local bool               // Whether the transaction was submitted locally
+
+	Head  *types.Header  // Chain head the pool is currently validating against
+	State *state.StateDB // State of the current head, must not be modified
+
+	Pending int // Number of executable transactions in the subpool
+	Queued  int // Number of non-executable transactions in the subpool
+	Pooled  int // Number of transactions of the sender in the subpool
+}
+
+// PolicyResult is the decision of an admission policy about a transaction it
+// accepted into the pool.
+type PolicyResult struct {
+	// Deprioritize requests the transaction to be evicted before any other one
+	// when the pool fills up, regardless of its price.
+	Deprioritize bool
+
+	// Tags are arbitrary labels attached to the transaction while it resides
+	// in the pool, retrievable via TxPool.Tags.
+	Tags []string
+}
+
+// Policy is an admission hook which the pools consult for every transaction
+// that passed their own validation rules, allowing nodes embedding the pool
+// to enforce custom rules, e.g. sender allowlists or rate limits.
+//
+// The policy is called with the pool lock held, so it should be fast and must
+// not call back into the pool.
+type Policy interface {
+	// Admit decides whether the transaction may enter the pool. A non-nil error
+	// rejects the transaction, otherwise the result is applied to it.
+	Admit(req *PolicyRequest) (PolicyResult, error)
+}
+
+// PolicyFunc is an adapter to allow the use of ordinary functions as pool
+// admission policies.
+type PolicyFunc func(req *PolicyRequest) (PolicyResult, error)
+
+// Admit implements Policy, calling f(req).
+func (f PolicyFunc) Admit(req *PolicyRequest) (PolicyResult, error) {
+	return f(req)
+}
+
+// ApplyPolicy runs the admission policy on the transaction, wrapping any
+// rejection into ErrPolicyRejected. A nil policy accepts everything.
+func ApplyPolicy(policy Policy, req *PolicyRequest) (PolicyResult, error) {
+	if policy == nil {
+		return PolicyResult{}, nil
+	}
+	res, err := policy.Admit(req)
+	if err != nil {
+		return PolicyResult{}, fmt.Errorf("%w: %w", ErrPolicyRejected, err)
+	}
+	return res, nil
+}
@@ -134,6 +134,15 @@ type SubPool interface {
 	// to a later point to batch multiple ones together.
 	Add(txs []*types.Transaction, local bool, sync bool) []error
 
+	// Remove drops the transaction with the given hash from the pool, returning
+	// whether it was found. The subsequent transactions of the same sender may
+	// become non-executable.
+	Remove(hash common.Hash) bool
+
+	// Tags returns the labels attached to a pooled transaction by the admission
+	// policy, or nil if the transaction is unknown or untagged.
+	Tags(hash common.Hash) []string
+
 	// Pending retrieves all currently processable transactions, grouped by origin
 	// account and sorted by nonce.
 	//
@@ -146,6 +155,10 @@ type SubPool interface {
 	// or also for reorged out ones.
 	SubscribeTransactions(ch chan<- core.NewTxsEvent, reorgs bool) event.Subscription
 
+	// SubscribeEvents subscribes to the lifecycle events of the pooled
+	// transactions, delivered in batches in the order they happened.
+	SubscribeEvents(ch chan<- []TxEvent) event.Subscription
+
 	// Nonce returns the next nonce of an account, with all transactions executable
 	// by the pool already applied on top.
 	Nonce(addr common.Address) uint64
@@ -162,6 +175,10 @@ type SubPool interface {
 	// pending as well as queued transactions of this address, grouped by nonce.
 	ContentFrom(addr common.Address) ([]*types.Transaction, []*types.Transaction)
 
+	// Explain retrieves the standing of the transactions of an address, sorted
+	// by nonce, detailing why they may not be executable or may get evicted.
+	Explain(addr common.Address) []*TxExplanation
+
 	// Locals retrieves the accounts currently considered local by the pool.
 	Locals() []common.Address
 
@@ -71,6 +71,11 @@ type TxPool struct {
 	reservations map[common.Address]SubPool // Map with the account to pool reservations
 	reserveLock  sync.Mutex                 // Lock protecting the account reservations
 
+	chain BlockChain // Chain to determine the expiry of private transactions
+
+	private     map[common.Hash]uint64 // Private transactions mapped to their expiry block
+	privateLock sync.RWMutex           // Lock protecting the private transactions
+
 	subs event.SubscriptionScope // Subscription scope to unsubscribe all on shutdown
 	quit chan chan error         // Quit channel to tear down the head updater
 	term chan struct{}           // Termination channel to detect a closed pool
@@ -89,6 +94,8 @@ func New(gasTip uint64, chain BlockChain, subpools []SubPool) (*TxPool, error) {
 	pool := &TxPool{
 		subpools:     subpools,
 		reservations: make(map[common.Address]SubPool),
+		chain:        chain,
+		private:      make(map[common.Hash]uint64),
 		quit:         make(chan chan error),
 		term:         make(chan struct{}),
 		sync:         make(chan chan error),
@@ -224,6 +231,7 @@ func (p *TxPool) loop(head *types.Header, chain BlockChain) {
 					for _, subpool := range p.subpools {
 						subpool.Reset(oldHead, newHead)
 					}
+					p.expirePrivate(newHead)
 					resetDone <- newHead
 				}(oldHead, newHead)
 
@@ -368,6 +376,96 @@ func (p *TxPool) Add(txs []*types.Transaction, local bool, sync bool) []error {
 	return errs
 }
 
+// AddPrivate enqueues a batch of transactions into the pool like Add, but marks
+// them as private: they are available for the local block production, but never
+// announced or served to the network. Unless included earlier, the transactions
+// are dropped from the pool once lifetime blocks have passed.
+//
+// Blob transactions are not supported, as they are meant for public propagation.
+func (p *TxPool) AddPrivate(txs []*types.Transaction, lifetime uint64, sync bool) []error {
+	// Mark the transactions private before adding them, to prevent them from
+	// being announced as soon as they enter the subpools.
+	var (
+		expiry = p.chain.CurrentBlock().Number.Uint64() + lifetime
+		marked = make([]bool, len(txs))
+		errs   = make([]error, len(txs))
+		adds   = make([]*types.Transaction, 0, len(txs))
+		splits = make([]int, 0, len(txs))
+	)
+	p.privateLock.Lock()
+	for i, tx := range txs {
+		if tx.Type() == types.BlobTxType {
+			errs[i] = ErrPrivateBlobTx
+			continue
+		}
+		if _, ok := p.private[tx.Hash()]; !ok {
+			p.private[tx.Hash()] = expiry
+			marked[i] = true
+		}
+		adds = append(adds, tx)
+		splits = append(splits, i)
+	}
+	p.privateLock.Unlock()
+
+	// Add the transactions as remote ones. The ones of local senders are still
+	// tracked as local, the persist filter of the subpools keeps them out of the
+	// journal, otherwise they would lose their privacy across restarts.
+	for j, err := range p.Add(adds, false, sync) {
+		errs[splits[j]] = err
+	}
+	// Unmark the rejected transactions, unless they were already private
+	p.privateLock.Lock()
+	for i, tx := range txs {
+		if marked[i] && errs[i] != nil {
+			delete(p.private, tx.Hash())
+		}
+	}
+	p.privateLock.Unlock()
+	return errs
+}
+
+// IsPrivate returns an indicator whether the transaction with the given hash
+// was submitted privately, and must not be propagated to the network.
+func (p *TxPool) IsPrivate(hash common.Hash) bool {
+	p.privateLock.RLock()
+	defer p.privateLock.RUnlock()
+
+	_, ok := p.private[hash]
+	return ok
+}
+
+// expirePrivate drops the private transactions whose lifetime is over from the
+// pool, and forgets the ones which left the pool already.
+func (p *TxPool) expirePrivate(head *types.Header) {
+	var expired []common.Hash
+
+	p.privateLock.Lock()
+	for hash, expiry := range p.private {
+		if !p.Has(hash) {
+			delete(p.private, hash)
+		} else if expiry <= head.Number.Uint64() {
+			expired = append(expired, hash)
+		}
+	}
+	p.privateLock.Unlock()
+
+	// Remove the expired transactions before unmarking them, otherwise they
+	// might be served to the network in between.
+	for _, hash := range expired {
+		for _, subpool := range p.subpools {
+			if subpool.Remove(hash) {
+				log.Debug("Dropped expired private transaction", "hash", hash)
+				break
+			}
+		}
+	}
+	p.privateLock.Lock()
+	for _, hash := range expired {
+		delete(p.private, hash)
+	}
+	p.privateLock.Unlock()
+}
+
 // Pending retrieves all currently processable transactions, grouped by origin
 // account and sorted by nonce.
 //
@@ -393,6 +491,16 @@ func (p *TxPool) SubscribeTransactions(ch chan<- core.NewTxsEvent, reorgs bool)
 	return p.subs.Track(event.JoinSubscriptions(subs...))
 }
 
+// SubscribeEvents registers a subscription for the lifecycle events of the
+// transactions in all the subpools.
+func (p *TxPool) SubscribeEvents(ch chan<- []TxEvent) event.Subscription {
+	subs := make([]event.Subscription, len(p.subpools))
+	for i, subpool := range p.subpools {
+		subs[i] = subpool.SubscribeEvents(ch)
+	}
+	return p.subs.Track(event.JoinSubscriptions(subs...))
+}
+
 // Nonce returns the next nonce of an account, with all transactions executable
 // by the pool already applied on top.
 func (p *TxPool) Nonce(addr common.Address) uint64 {
@@ -453,6 +561,17 @@ func (p *TxPool) ContentFrom(addr common.Address) ([]*types.Transaction, []*type
 	return []*types.Transaction{}, []*types.Transaction{}
 }
 
+// Explain retrieves the standing of the transactions of an address, sorted by
+// nonce, detailing why they may not be executable or may get evicted.
+func (p *TxPool) Explain(addr common.Address) []*TxExplanation {
+	for _, subpool := range p.subpools {
+		if explanations := subpool.Explain(addr); len(explanations) != 0 {
+			return explanations
+		}
+	}
+	return []*TxExplanation{}
+}
+
 // Locals retrieves the accounts currently considered local by the pool.
 func (p *TxPool) Locals() []common.Address {
 	// Retrieve the locals from each subpool and deduplicate them
@@ -481,6 +600,17 @@ func (p *TxPool) Status(hash common.Hash) TxStatus {
 	return TxStatusUnknown
 }
 
+// Tags returns the labels attached to a pooled transaction by the admission
+// policy of its subpool.
+func (p *TxPool) Tags(hash common.Hash) []string {
+	for _, subpool := range p.subpools {
+		if tags := subpool.Tags(hash); tags != nil {
+			return tags
+		}
+	}
+	return nil
+}
+
 // Sync is a helper method for unit tests or simulator runs where the chain events
 // are arriving in quick succession, without any time in between them to run the
 // internal background reset operations. This method will run an explicit reset
@@ -0,0 +1,171 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool_test
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/txpool/legacypool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// waitFor polls the condition until it's satisfied, as the pool is reset on a
+// background thread after the chain head events.
+func waitFor(t *testing.T, pool *txpool.TxPool, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); !cond(); {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %s", what)
+		}
+		pool.Sync()
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPrivateTransactions(t *testing.T) {
+	t.Parallel()
+
+	var (
+		keyA, _ = crypto.GenerateKey()
+		keyB, _ = crypto.GenerateKey()
+		addrA   = crypto.PubkeyToAddress(keyA.PublicKey)
+		addrB   = crypto.PubkeyToAddress(keyB.PublicKey)
+		config  = params.MergedTestChainConfig
+		signer  = types.LatestSigner(config)
+		engine  = beacon.New(ethash.NewFaker())
+		genesis = &core.Genesis{
+			Config: config,
+			Alloc: types.GenesisAlloc{
+				addrA: {Balance: big.NewInt(params.Ether)},
+				addrB: {Balance: big.NewInt(params.Ether)},
+			},
+		}
+	)
+	transfer := func(key *ecdsa.PrivateKey, nonce uint64) *types.Transaction {
+		return types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   config.ChainID,
+			Nonce:     nonce,
+			GasTipCap: big.NewInt(params.GWei),
+			GasFeeCap: big.NewInt(10 * params.GWei),
+			Gas:       params.TxGas,
+			To:        &common.Address{0xaa},
+		})
+	}
+	var (
+		privA  = transfer(keyA, 0)
+		privB  = transfer(keyB, 0)
+		public = transfer(keyA, 1)
+	)
+	// The first block includes the private transaction of A only
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, engine, 3, func(i int, b *core.BlockGen) {
+		b.SetPoS()
+		if i == 0 {
+			b.AddTx(privA)
+		}
+	})
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	txconfig := legacypool.DefaultConfig
+	txconfig.Journal = ""
+	pool, err := txpool.New(txconfig.PriceLimit, chain, []txpool.SubPool{legacypool.New(txconfig, chain)})
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	defer pool.Close()
+
+	// Add the private transactions expiring at block 2, along with a public
+	// and a few rejected ones.
+	blob := types.MustSignNewTx(keyB, signer, &types.BlobTx{
+		ChainID:    uint256.MustFromBig(config.ChainID),
+		GasTipCap:  uint256.NewInt(params.GWei),
+		GasFeeCap:  uint256.NewInt(10 * params.GWei),
+		Gas:        params.TxGas,
+		BlobFeeCap: uint256.NewInt(params.GWei),
+		BlobHashes: []common.Hash{{0x01}},
+	})
+	underpriced := types.MustSignNewTx(keyB, signer, &types.DynamicFeeTx{
+		ChainID:   config.ChainID,
+		Nonce:     1,
+		GasFeeCap: big.NewInt(0),
+		Gas:       params.TxGas,
+		To:        &common.Address{0xaa},
+	})
+	errs := pool.AddPrivate([]*types.Transaction{privA, blob, privB, underpriced}, 2, true)
+	if errs[0] != nil || errs[2] != nil {
+		t.Fatalf("failed to add private transactions: %v", errs)
+	}
+	if !errors.Is(errs[1], txpool.ErrPrivateBlobTx) {
+		t.Fatalf("unexpected blob transaction error: %v", errs[1])
+	}
+	if errs[3] == nil {
+		t.Fatal("underpriced transaction accepted")
+	}
+	if err := pool.Add([]*types.Transaction{public}, false, true)[0]; err != nil {
+		t.Fatalf("failed to add public transaction: %v", err)
+	}
+	for _, tx := range []*types.Transaction{privA, privB} {
+		if !pool.IsPrivate(tx.Hash()) || !pool.Has(tx.Hash()) {
+			t.Fatalf("private transaction %x not tracked", tx.Hash())
+		}
+	}
+	for _, tx := range []*types.Transaction{public, blob, underpriced} {
+		if pool.IsPrivate(tx.Hash()) {
+			t.Fatalf("transaction %x marked private", tx.Hash())
+		}
+	}
+	// Resubmitting a private transaction must not unmark it
+	if err := pool.AddPrivate([]*types.Transaction{privA}, 2, true)[0]; !errors.Is(err, txpool.ErrAlreadyKnown) {
+		t.Fatalf("unexpected resubmission error: %v", err)
+	}
+	if !pool.IsPrivate(privA.Hash()) {
+		t.Fatal("resubmitted private transaction unmarked")
+	}
+	// The included private transaction is forgotten, the other one is retained
+	if _, err := chain.InsertChain(blocks[:1]); err != nil {
+		t.Fatalf("failed to insert block: %v", err)
+	}
+	waitFor(t, pool, "inclusion", func() bool { return !pool.Has(privA.Hash()) && !pool.IsPrivate(privA.Hash()) })
+	if !pool.IsPrivate(privB.Hash()) || !pool.Has(privB.Hash()) {
+		t.Fatal("private transaction dropped before expiry")
+	}
+	// The remaining private transaction expires at block 2
+	if _, err := chain.InsertChain(blocks[1:2]); err != nil {
+		t.Fatalf("failed to insert block: %v", err)
+	}
+	waitFor(t, pool, "expiry", func() bool { return !pool.Has(privB.Hash()) && !pool.IsPrivate(privB.Hash()) })
+	if !pool.Has(public.Hash()) {
+		t.Fatal("public transaction dropped")
+	}
+}
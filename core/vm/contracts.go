@@ -24,6 +24,7 @@ import (
 	"maps"
 	"math"
 	"math/big"
+	"slices"
 
 	"github.com/consensys/gnark-crypto/ecc"
 	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
@@ -174,21 +175,31 @@ func init() {
 }
 
 func activePrecompiledContracts(rules params.Rules) PrecompiledContracts {
+	fork, contracts := forkPrecompiledContracts(rules)
+	if len(rules.Precompiles) > 0 {
+		contracts = withCustomPrecompiles(fork, contracts, rules)
+	}
+	return contracts
+}
+
+// forkPrecompiledContracts returns the name of the fork and the precompiled
+// contracts of the Ethereum protocol enabled by the rules.
+func forkPrecompiledContracts(rules params.Rules) (string, PrecompiledContracts) {
 	switch {
 	case rules.IsVerkle:
-		return PrecompiledContractsVerkle
+		return "verkle", PrecompiledContractsVerkle
 	case rules.IsPrague:
-		return PrecompiledContractsPrague
+		return "prague", PrecompiledContractsPrague
 	case rules.IsCancun:
-		return PrecompiledContractsCancun
+		return "cancun", PrecompiledContractsCancun
 	case rules.IsBerlin:
-		return PrecompiledContractsBerlin
+		return "berlin", PrecompiledContractsBerlin
 	case rules.IsIstanbul:
-		return PrecompiledContractsIstanbul
+		return "istanbul", PrecompiledContractsIstanbul
 	case rules.IsByzantium:
-		return PrecompiledContractsByzantium
+		return "byzantium", PrecompiledContractsByzantium
 	default:
-		return PrecompiledContractsHomestead
+		return "homestead", PrecompiledContractsHomestead
 	}
 }
 
@@ -199,6 +210,21 @@ func ActivePrecompiledContracts(rules params.Rules) PrecompiledContracts {
 
 // ActivePrecompiles returns the precompile addresses enabled with the current configuration.
 func ActivePrecompiles(rules params.Rules) []common.Address {
+	addresses := forkPrecompiles(rules)
+	if len(rules.Precompiles) == 0 {
+		return addresses
+	}
+	// Never append to the shared per-fork lists.
+	addresses = slices.Clone(addresses)
+	for _, p := range rules.Precompiles {
+		addresses = append(addresses, p.Address)
+	}
+	return addresses
+}
+
+// forkPrecompiles returns the addresses of the precompiled contracts of the
+// Ethereum protocol enabled by the rules.
+func forkPrecompiles(rules params.Rules) []common.Address {
 	switch {
 	case rules.IsPrague:
 		return PrecompiledAddressesPrague
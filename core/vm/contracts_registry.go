@@ -0,0 +1,141 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// customPrecompiles holds the implementations of custom precompiled contracts,
+// keyed by the name chain configurations refer to them with.
+//
+// customPrecompileSets caches the protocol precompile sets extended with the
+// custom ones, keyed by the fork and the active custom precompiles, to avoid
+// rebuilding them for every EVM.
+var (
+	customPrecompiles     = make(map[string]PrecompiledContract)
+	customPrecompileSets  = make(map[string]PrecompiledContracts)
+	customPrecompilesLock sync.RWMutex
+)
+
+func init() {
+	params.SetPrecompileRegistry(func(name string) bool {
+		_, ok := RegisteredPrecompile(name)
+		return ok
+	})
+}
+
+// RegisterPrecompile registers the implementation of a custom precompiled
+// contract under the given name. The contract is not active on any chain until
+// it is scheduled in the chain configuration, see params.PrecompileConfig.
+//
+// Registration is meant to happen during initialization, before any chain is
+// processed. It panics if the name is already in use.
+func RegisterPrecompile(name string, p PrecompiledContract) {
+	customPrecompilesLock.Lock()
+	defer customPrecompilesLock.Unlock()
+
+	if name == "" {
+		panic("vm: precompile name is empty")
+	}
+	if _, ok := customPrecompiles[name]; ok {
+		panic(fmt.Sprintf("vm: precompile %q registered twice", name))
+	}
+	customPrecompiles[name] = p
+	customPrecompileSets = make(map[string]PrecompiledContracts)
+}
+
+// RegisteredPrecompile returns the custom precompiled contract registered under
+// the given name.
+func RegisteredPrecompile(name string) (PrecompiledContract, bool) {
+	customPrecompilesLock.RLock()
+	defer customPrecompilesLock.RUnlock()
+
+	p, ok := customPrecompiles[name]
+	return p, ok
+}
+
+// RegisteredPrecompiles returns the names of all registered custom precompiled
+// contracts, in sorted order.
+func RegisteredPrecompiles() []string {
+	customPrecompilesLock.RLock()
+	defer customPrecompilesLock.RUnlock()
+
+	names := make([]string, 0, len(customPrecompiles))
+	for name := range customPrecompiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CheckPrecompiles verifies that all custom precompiles scheduled by the chain
+// configuration are registered, and that none of them shadows a precompile of
+// the Ethereum protocol.
+func CheckPrecompiles(config *params.ChainConfig) error {
+	for _, p := range config.Precompiles {
+		if _, ok := RegisteredPrecompile(p.Name); !ok {
+			return fmt.Errorf("precompile %q at %v is not registered", p.Name, p.Address)
+		}
+		if _, ok := PrecompiledContractsPrague[p.Address]; ok {
+			return fmt.Errorf("precompile %q at %v collides with a protocol precompile", p.Name, p.Address)
+		}
+	}
+	return nil
+}
+
+// withCustomPrecompiles returns the given fork's set of precompiled contracts,
+// extended with the custom precompiles enabled by the rules. The returned set
+// is shared and must not be modified.
+//
+// Precompiles which are not registered are skipped, chain configurations are
+// expected to be validated via params.ChainConfig.CheckConfigForkOrder on load.
+func withCustomPrecompiles(fork string, contracts PrecompiledContracts, rules params.Rules) PrecompiledContracts {
+	var key strings.Builder
+	key.WriteString(fork)
+	for _, cfg := range rules.Precompiles {
+		key.WriteString(",")
+		key.WriteString(cfg.Name)
+		key.WriteString("@")
+		key.Write(cfg.Address[:])
+	}
+	customPrecompilesLock.RLock()
+	extended, ok := customPrecompileSets[key.String()]
+	customPrecompilesLock.RUnlock()
+	if ok {
+		return extended
+	}
+	customPrecompilesLock.Lock()
+	defer customPrecompilesLock.Unlock()
+
+	extended = make(PrecompiledContracts, len(contracts)+len(rules.Precompiles))
+	for addr, p := range contracts {
+		extended[addr] = p
+	}
+	for _, cfg := range rules.Precompiles {
+		if p, ok := customPrecompiles[cfg.Name]; ok {
+			extended[cfg.Address] = p
+		}
+	}
+	customPrecompileSets[key.String()] = extended
+	return extended
+}
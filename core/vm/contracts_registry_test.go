@@ -0,0 +1,136 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// reverser is a custom precompile returning its input in reverse order, at a
+// cost of one gas per byte.
+type reverser struct{}
+
+func (reverser) RequiredGas(input []byte) uint64 { return uint64(len(input)) }
+
+func (reverser) Run(input []byte) ([]byte, error) {
+	out := slices.Clone(input)
+	slices.Reverse(out)
+	return out, nil
+}
+
+func init() {
+	RegisterPrecompile("test-reverser", reverser{})
+}
+
+func TestCustomPrecompile(t *testing.T) {
+	var (
+		addr   = common.HexToAddress("0x0b00000000000000000000000000000000000001")
+		config = *params.MergedTestChainConfig
+		time   = uint64(100)
+	)
+	config.Precompiles = []*params.PrecompileConfig{{Name: "test-reverser", Address: addr, Time: &time}}
+	if err := CheckPrecompiles(&config); err != nil {
+		t.Fatal(err)
+	}
+	// The precompile must not be visible before its activation.
+	before := config.Rules(common.Big0, true, time-1)
+	if slices.Contains(ActivePrecompiles(before), addr) {
+		t.Fatal("precompile active before activation time")
+	}
+	if _, ok := ActivePrecompiledContracts(before)[addr]; ok {
+		t.Fatal("precompile active before activation time")
+	}
+	after := config.Rules(common.Big0, true, time)
+	if !slices.Contains(ActivePrecompiles(after), addr) {
+		t.Fatal("precompile not in active addresses")
+	}
+	if _, ok := ActivePrecompiledContracts(after)[addr]; !ok {
+		t.Fatal("precompile not in active contracts")
+	}
+	// The shared per-fork address lists must not be modified.
+	if slices.Contains(PrecompiledAddressesPrague, addr) {
+		t.Fatal("custom precompile leaked into protocol precompiles")
+	}
+	// Call the precompile through the EVM.
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	vmctx := BlockContext{
+		CanTransfer: func(StateDB, common.Address, *uint256.Int) bool { return true },
+		Transfer:    func(StateDB, common.Address, common.Address, *uint256.Int) {},
+		BlockNumber: big.NewInt(1),
+		Time:        time,
+		Random:      &common.Hash{},
+	}
+	evm := NewEVM(vmctx, statedb, &config, Config{})
+	ret, gas, err := evm.Call(AccountRef(common.Address{}), addr, []byte{1, 2, 3}, 100, new(uint256.Int))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ret, []byte{3, 2, 1}) {
+		t.Errorf("wrong output: have %x", ret)
+	}
+	if gas != 97 {
+		t.Errorf("wrong gas left: have %d, want 97", gas)
+	}
+	// The extended precompile set must be reused across EVMs.
+	if other := NewEVM(vmctx, statedb, &config, Config{}); reflect.ValueOf(other.precompiles).Pointer() != reflect.ValueOf(evm.precompiles).Pointer() {
+		t.Error("extended precompile set not cached")
+	}
+}
+
+// Tests that chain configurations scheduling unregistered precompiles are
+// rejected on load, and that the EVM doesn't crash on them regardless.
+func TestUnregisteredPrecompile(t *testing.T) {
+	var (
+		addr   = common.HexToAddress("0x0b00000000000000000000000000000000000002")
+		config = *params.MergedTestChainConfig
+		time   = uint64(0)
+	)
+	config.Precompiles = []*params.PrecompileConfig{{Name: "unknown", Address: addr, Time: &time}}
+	if err := config.CheckConfigForkOrder(); err == nil {
+		t.Fatal("expected error for unregistered precompile")
+	}
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	vmctx := BlockContext{
+		BlockNumber: big.NewInt(1),
+		Random:      &common.Hash{},
+	}
+	if _, ok := NewEVM(vmctx, statedb, &config, Config{}).precompile(addr); ok {
+		t.Fatal("unregistered precompile active")
+	}
+}
+
+func TestCheckPrecompiles(t *testing.T) {
+	config := *params.MergedTestChainConfig
+	config.Precompiles = []*params.PrecompileConfig{{Name: "unknown", Address: common.Address{0xb}}}
+	if err := CheckPrecompiles(&config); err == nil {
+		t.Error("expected error for unregistered precompile")
+	}
+	config.Precompiles = []*params.PrecompileConfig{{Name: "test-reverser", Address: common.BytesToAddress([]byte{0x1})}}
+	if err := CheckPrecompiles(&config); err == nil {
+		t.Error("expected error for precompile shadowing ecrecover")
+	}
+}
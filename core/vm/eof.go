@@ -74,6 +74,68 @@ type Container struct {
 	dataSize          int // might be more than len(data)
 }
 
+// CodeSection is a single code section of an EOF container together with its
+// entry in the type section.
+type CodeSection struct {
+	Inputs         uint8
+	Outputs        uint8
+	MaxStackHeight uint16
+	Code           []byte
+}
+
+// NewContainer assembles an EOF container from its parts. The dataSize may
+// exceed the length of data, which is how a subcontainer with a truncated data
+// section is represented.
+func NewContainer(sections []CodeSection, subContainers []*Container, data []byte, dataSize int) *Container {
+	c := &Container{
+		types:         make([]*functionMetadata, len(sections)),
+		codeSections:  make([][]byte, len(sections)),
+		subContainers: subContainers,
+		data:          data,
+		dataSize:      dataSize,
+	}
+	for i, section := range sections {
+		c.types[i] = &functionMetadata{
+			inputs:         section.Inputs,
+			outputs:        section.Outputs,
+			maxStackHeight: section.MaxStackHeight,
+		}
+		c.codeSections[i] = section.Code
+	}
+	return c
+}
+
+// CodeSections returns the code sections of the container along with their
+// type signatures.
+func (c *Container) CodeSections() []CodeSection {
+	sections := make([]CodeSection, len(c.codeSections))
+	for i, code := range c.codeSections {
+		sections[i] = CodeSection{
+			Inputs:         c.types[i].inputs,
+			Outputs:        c.types[i].outputs,
+			MaxStackHeight: c.types[i].maxStackHeight,
+			Code:           code,
+		}
+	}
+	return sections
+}
+
+// SubContainers returns the containers nested within the container.
+func (c *Container) SubContainers() []*Container {
+	return c.subContainers
+}
+
+// Data returns the contents of the data section. It may be shorter than the
+// declared DataSize.
+func (c *Container) Data() []byte {
+	return c.data
+}
+
+// DataSize returns the data section size declared in the container header.
+func (c *Container) DataSize() int {
+	return c.dataSize
+}
+
 // functionMetadata is an EOF function signature.
 type functionMetadata struct {
 	inputs         uint8
@@ -373,29 +435,41 @@ func (c *Container) validateSubContainer(jt *JumpTable, refBy int) error {
 			for idx, reference := range res.visitedSubContainers {
 				// Make sure subcontainers are only ever referenced by either EOFCreate or ReturnContract
 				if ref, ok := subContainerVisited[idx]; ok && ref != reference {
-					return errors.New("section referenced by both EOFCreate and ReturnContract")
+					return newValidationError(index, -1, 0, -1, -1, errors.New("section referenced by both EOFCreate and ReturnContract"))
 				}
 				subContainerVisited[idx] = reference
 			}
 			if refBy == refByReturnContract && res.isInitCode {
-				return errIncompatibleContainerKind
+				return newValidationError(index, -1, 0, -1, -1, errIncompatibleContainerKind)
 			}
 			if refBy == refByEOFCreate && res.isRuntime {
-				return errIncompatibleContainerKind
+				return newValidationError(index, -1, 0, -1, -1, errIncompatibleContainerKind)
 			}
 		}
 		toVisit = toVisit[1:]
 	}
 	// Make sure every code section is visited at least once.
 	if len(visited) != len(c.codeSections) {
-		return errUnreachableCode
+		section := 0
+		for ; section < len(c.codeSections); section++ {
+			if _, ok := visited[section]; !ok {
+				break
+			}
+		}
+		return newValidationError(section, -1, 0, -1, -1, errUnreachableCode)
 	}
 	for idx, container := range c.subContainers {
 		reference, ok := subContainerVisited[idx]
 		if !ok {
-			return errOrphanedSubcontainer
+			err := newValidationError(-1, -1, 0, -1, -1, errOrphanedSubcontainer)
+			err.Path = []int{idx}
+			return err
 		}
 		if err := container.validateSubContainer(jt, reference); err != nil {
+			var verr *ValidationError
+			if errors.As(err, &verr) {
+				verr.Path = append([]int{idx}, verr.Path...)
+			}
 			return err
 		}
 	}
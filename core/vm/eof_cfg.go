@@ -0,0 +1,215 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+// EdgeKind denotes how control is transferred between two basic blocks.
+type EdgeKind string
+
+const (
+	EdgeFallthrough EdgeKind = "fallthrough" // sequential flow, including a not-taken RJUMPI
+	EdgeRjump       EdgeKind = "rjump"
+	EdgeRjumpi      EdgeKind = "rjumpi"
+	EdgeRjumpv      EdgeKind = "rjumpv"
+)
+
+// ContainerGraph is the static control-flow graph of an EOF container, as
+// computed by the validator.
+type ContainerGraph struct {
+	Sections      []*SectionGraph
+	SubContainers []*ContainerGraph
+}
+
+// SectionGraph is the control-flow graph of a single code section.
+type SectionGraph struct {
+	Section        int
+	Inputs         uint8
+	Outputs        uint8
+	MaxStackHeight uint16
+	Blocks         []*BasicBlock
+	Edges          []FlowEdge // Control flow between blocks of this section
+	Calls          []CallEdge // CALLF and JUMPF into other sections
+}
+
+// BasicBlock is a sequence of instructions which is only entered at its first
+// instruction and only left after its last one.
+type BasicBlock struct {
+	Start        int // Offset of the first instruction
+	End          int // Offset following the last instruction
+	Instructions []Instruction
+}
+
+// StackMin returns the minimum stack height when entering the block.
+func (b *BasicBlock) StackMin() int {
+	return b.Instructions[0].StackMin
+}
+
+// StackMax returns the maximum stack height when entering the block.
+func (b *BasicBlock) StackMax() int {
+	return b.Instructions[0].StackMax
+}
+
+// Instruction is an instruction within a basic block, along with the bounds of
+// the stack height before it is executed.
+type Instruction struct {
+	Pos       int
+	Op        OpCode
+	Immediate []byte
+	StackMin  int
+	StackMax  int
+}
+
+// FlowEdge is a control-flow edge between two basic blocks, identified by
+// their index in the section.
+type FlowEdge struct {
+	From int
+	To   int
+	Kind EdgeKind
+}
+
+// CallEdge is a CALLF or JUMPF from a basic block to another code section.
+type CallEdge struct {
+	Block   int
+	Pos     int
+	Op      OpCode
+	Section int
+}
+
+// ControlFlowGraph validates the container and returns the control-flow graph
+// of all of its code sections, including those of nested containers.
+func (c *Container) ControlFlowGraph(jt *JumpTable, isInitCode bool) (*ContainerGraph, error) {
+	if err := c.ValidateCode(jt, isInitCode); err != nil {
+		return nil, err
+	}
+	return c.controlFlowGraph(jt), nil
+}
+
+// controlFlowGraph assembles the control-flow graph of a container which has
+// already passed validation.
+func (c *Container) controlFlowGraph(jt *JumpTable) *ContainerGraph {
+	graph := new(ContainerGraph)
+	for i, code := range c.codeSections {
+		_, bounds, err := validateControlFlow(code, i, c.types, jt)
+		if err != nil {
+			panic(err) // can't happen for a validated container
+		}
+		graph.Sections = append(graph.Sections, sectionGraph(code, i, c.types[i], bounds))
+	}
+	for _, sub := range c.subContainers {
+		graph.SubContainers = append(graph.SubContainers, sub.controlFlowGraph(jt))
+	}
+	return graph
+}
+
+// instructionSize returns the size of the instruction at pos, including its
+// immediates.
+func instructionSize(code []byte, pos int) int {
+	op := OpCode(code[pos])
+	if op == RJUMPV {
+		return 2 + 2*(int(code[pos+1])+1)
+	}
+	return 1 + int(immediates[op])
+}
+
+// jumpDestinations returns the destinations of a relative jump at pos.
+func jumpDestinations(code []byte, pos int) []int {
+	switch OpCode(code[pos]) {
+	case RJUMP, RJUMPI:
+		return []int{pos + 3 + parseInt16(code[pos+1:])}
+	case RJUMPV:
+		var (
+			count = int(code[pos+1]) + 1
+			next  = pos + 2 + 2*count
+			dests = make([]int, 0, count)
+		)
+		for i := 0; i < count; i++ {
+			dests = append(dests, next+parseInt16(code[pos+2+2*i:]))
+		}
+		return dests
+	}
+	return nil
+}
+
+func sectionGraph(code []byte, section int, meta *functionMetadata, bounds *stackBounds) *SectionGraph {
+	graph := &SectionGraph{
+		Section:        section,
+		Inputs:         meta.inputs,
+		Outputs:        meta.outputs,
+		MaxStackHeight: meta.maxStackHeight,
+	}
+	// Find the first instruction of each block: the entry, every jump
+	// destination and every instruction following a jump or terminal.
+	leaders := map[int]bool{0: true}
+	for pos := 0; pos < len(code); pos += instructionSize(code, pos) {
+		op := OpCode(code[pos])
+		for _, dest := range jumpDestinations(code, pos) {
+			leaders[dest] = true
+		}
+		if op == RJUMP || op == RJUMPI || op == RJUMPV || terminals[op] {
+			leaders[pos+instructionSize(code, pos)] = true
+		}
+	}
+	// Split the code into blocks.
+	blockAt := make(map[int]int)
+	for pos := 0; pos < len(code); pos += instructionSize(code, pos) {
+		if leaders[pos] {
+			blockAt[pos] = len(graph.Blocks)
+			graph.Blocks = append(graph.Blocks, &BasicBlock{Start: pos})
+		}
+		var (
+			block     = graph.Blocks[len(graph.Blocks)-1]
+			size      = instructionSize(code, pos)
+			_, lo, hi = bounds.get(pos)
+		)
+		block.Instructions = append(block.Instructions, Instruction{
+			Pos:       pos,
+			Op:        OpCode(code[pos]),
+			Immediate: code[pos+1 : pos+size],
+			StackMin:  lo,
+			StackMax:  hi,
+		})
+		block.End = pos + size
+	}
+	// Connect the blocks.
+	for i, block := range graph.Blocks {
+		for _, in := range block.Instructions {
+			if in.Op == CALLF || in.Op == JUMPF {
+				target, _ := parseUint16(in.Immediate)
+				graph.Calls = append(graph.Calls, CallEdge{Block: i, Pos: in.Pos, Op: in.Op, Section: target})
+			}
+		}
+		last := block.Instructions[len(block.Instructions)-1]
+		switch {
+		case last.Op == RJUMP:
+			graph.Edges = append(graph.Edges, FlowEdge{From: i, To: blockAt[jumpDestinations(code, last.Pos)[0]], Kind: EdgeRjump})
+		case last.Op == RJUMPI || last.Op == RJUMPV:
+			graph.Edges = append(graph.Edges, FlowEdge{From: i, To: blockAt[block.End], Kind: EdgeFallthrough})
+			kind, seen := EdgeRjumpi, make(map[int]bool)
+			if last.Op == RJUMPV {
+				kind = EdgeRjumpv
+			}
+			for _, dest := range jumpDestinations(code, last.Pos) {
+				if !seen[dest] {
+					seen[dest] = true
+					graph.Edges = append(graph.Edges, FlowEdge{From: i, To: blockAt[dest], Kind: kind})
+				}
+			}
+		case !terminals[last.Op]:
+			graph.Edges = append(graph.Edges, FlowEdge{From: i, To: blockAt[block.End], Kind: EdgeFallthrough})
+		}
+	}
+	return graph
+}
@@ -0,0 +1,77 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestControlFlowGraph(t *testing.T) {
+	container := &Container{
+		types: []*functionMetadata{
+			{inputs: 0, outputs: 0x80, maxStackHeight: 2},
+			{inputs: 1, outputs: 1, maxStackHeight: 1},
+		},
+		codeSections: [][]byte{
+			{
+				byte(CALLER),             // 0
+				byte(RJUMPI), 0x00, 0x04, // 1
+				byte(CALLER),            // 4
+				byte(RJUMP), 0x00, 0x00, // 5
+				byte(CALLER),            // 8
+				byte(CALLF), 0x00, 0x01, // 9
+				byte(POP),  // 12
+				byte(STOP), // 13
+			},
+			{
+				byte(RETF),
+			},
+		},
+		data: []byte{},
+	}
+	graph, err := container.ControlFlowGraph(&eofInstructionSet, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(graph.Sections) != 2 {
+		t.Fatalf("wrong number of sections: %d", len(graph.Sections))
+	}
+	section := graph.Sections[0]
+	var blocks [][3]int
+	for _, block := range section.Blocks {
+		blocks = append(blocks, [3]int{block.Start, block.StackMin(), block.StackMax()})
+	}
+	if want := [][3]int{{0, 0, 0}, {4, 0, 0}, {8, 0, 1}}; !reflect.DeepEqual(blocks, want) {
+		t.Errorf("wrong blocks: have %v, want %v", blocks, want)
+	}
+	wantEdges := []FlowEdge{
+		{From: 0, To: 1, Kind: EdgeFallthrough},
+		{From: 0, To: 2, Kind: EdgeRjumpi},
+		{From: 1, To: 2, Kind: EdgeRjump},
+	}
+	if !reflect.DeepEqual(section.Edges, wantEdges) {
+		t.Errorf("wrong edges: have %v, want %v", section.Edges, wantEdges)
+	}
+	wantCalls := []CallEdge{{Block: 2, Pos: 9, Op: CALLF, Section: 1}}
+	if !reflect.DeepEqual(section.Calls, wantCalls) {
+		t.Errorf("wrong calls: have %v, want %v", section.Calls, wantCalls)
+	}
+	if in := section.Blocks[2].Instructions[1]; in.StackMin != 1 || in.StackMax != 2 {
+		t.Errorf("wrong stack bounds at CALLF: have [%d, %d], want [1, 2]", in.StackMin, in.StackMax)
+	}
+}
@@ -22,36 +22,52 @@ import (
 	"github.com/ethereum/go-ethereum/params"
 )
 
-func validateControlFlow(code []byte, section int, metadata []*functionMetadata, jt *JumpTable) (int, error) {
+// stackBounds holds the minimum and maximum stack height before each instruction
+// of a code section.
+type stackBounds struct {
+	// The max slice is a bit peculiar. We use `0` to denote not set. Therefore,
+	// we use `1` to represent the value `0`, and so on. So if the caller wants
+	// to store `1` as max bound, we internally store it as `2`.
+	max []uint16
+	min []uint16
+}
+
+// get returns the stack bounds before the instruction at pos, and whether they
+// have been set, i.e. whether pos is the start of a reachable instruction.
+func (b *stackBounds) get(pos int) (ok bool, min, max int) {
+	maxi := b.max[pos]
+	if maxi == 0 { // Not yet set
+		return false, 0, 0
+	}
+	return true, int(b.min[pos]), int(maxi - 1)
+}
+
+// validateControlFlow validates the stack and control flow of a code section,
+// returning the number of reachable instructions and their stack bounds.
+func validateControlFlow(code []byte, section int, metadata []*functionMetadata, jt *JumpTable) (int, *stackBounds, error) {
 	var (
 		maxStackHeight = int(metadata[section].inputs)
 		visitCount     = 0
 		next           = make([]int, 0, 1)
 	)
-	var (
-		stackBoundsMax = make([]uint16, len(code))
-		stackBoundsMin = make([]uint16, len(code))
-	)
+	bounds := &stackBounds{
+		max: make([]uint16, len(code)),
+		min: make([]uint16, len(code)),
+	}
 	setBounds := func(pos, min, maxi int) {
-		// The stackboundMax slice is a bit peculiar. We use `0` to denote
-		// not set. Therefore, we use `1` to represent the value `0`, and so on.
-		// So if the caller wants to store `1` as max bound, we internally store it as
-		// `2`.
-		if stackBoundsMax[pos] == 0 { // Not yet set
+		if bounds.max[pos] == 0 { // Not yet set
 			visitCount++
 		}
 		if maxi < 65535 {
-			stackBoundsMax[pos] = uint16(maxi + 1)
+			bounds.max[pos] = uint16(maxi + 1)
 		}
-		stackBoundsMin[pos] = uint16(min)
+		bounds.min[pos] = uint16(min)
 		maxStackHeight = max(maxStackHeight, maxi)
 	}
-	getStackMaxMin := func(pos int) (ok bool, min, max int) {
-		maxi := stackBoundsMax[pos]
-		if maxi == 0 { // Not yet set
-			return false, 0, 0
-		}
-		return true, int(stackBoundsMin[pos]), int(maxi - 1)
+	getStackMaxMin := bounds.get
+	fail := func(pos int, err error) (int, *stackBounds, error) {
+		_, min, max := getStackMaxMin(pos)
+		return 0, nil, newValidationError(section, pos, OpCode(code[pos]), min, max, err)
 	}
 	// set the initial stack bounds
 	setBounds(0, int(metadata[section].inputs), int(metadata[section].inputs))
@@ -61,7 +77,7 @@ func validateControlFlow(code []byte, section int, metadata []*functionMetadata,
 		op := OpCode(code[pos])
 		ok, currentStackMin, currentStackMax := getStackMaxMin(pos)
 		if !ok {
-			return 0, errUnreachableCode
+			return 0, nil, newValidationError(section, pos, op, -1, -1, errUnreachableCode)
 		}
 
 		switch op {
@@ -69,10 +85,10 @@ func validateControlFlow(code []byte, section int, metadata []*functionMetadata,
 			arg, _ := parseUint16(code[pos+1:])
 			newSection := metadata[arg]
 			if err := newSection.checkInputs(currentStackMin); err != nil {
-				return 0, fmt.Errorf("%w: at pos %d", err, pos)
+				return fail(pos, fmt.Errorf("%w: at pos %d", err, pos))
 			}
 			if err := newSection.checkStackMax(currentStackMax); err != nil {
-				return 0, fmt.Errorf("%w: at pos %d", err, pos)
+				return fail(pos, fmt.Errorf("%w: at pos %d", err, pos))
 			}
 			delta := newSection.stackDelta()
 			currentStackMax += delta
@@ -84,14 +100,14 @@ func validateControlFlow(code []byte, section int, metadata []*functionMetadata,
 			In other words: RETF must unambiguously return all items remaining on the stack.
 			*/
 			if currentStackMax != currentStackMin {
-				return 0, fmt.Errorf("%w: max %d, min %d, at pos %d", errInvalidOutputs, currentStackMax, currentStackMin, pos)
+				return fail(pos, fmt.Errorf("%w: max %d, min %d, at pos %d", errInvalidOutputs, currentStackMax, currentStackMin, pos))
 			}
 			numOutputs := int(metadata[section].outputs)
 			if numOutputs >= maxOutputItems {
-				return 0, fmt.Errorf("%w: at pos %d", errInvalidNonReturningFlag, pos)
+				return fail(pos, fmt.Errorf("%w: at pos %d", errInvalidNonReturningFlag, pos))
 			}
 			if numOutputs != currentStackMin {
-				return 0, fmt.Errorf("%w: have %d, want %d, at pos %d", errInvalidOutputs, numOutputs, currentStackMin, pos)
+				return fail(pos, fmt.Errorf("%w: have %d, want %d, at pos %d", errInvalidOutputs, numOutputs, currentStackMin, pos))
 			}
 			qualifiedExit = true
 		case JUMPF:
@@ -99,43 +115,43 @@ func validateControlFlow(code []byte, section int, metadata []*functionMetadata,
 			newSection := metadata[arg]
 
 			if err := newSection.checkStackMax(currentStackMax); err != nil {
-				return 0, fmt.Errorf("%w: at pos %d", err, pos)
+				return fail(pos, fmt.Errorf("%w: at pos %d", err, pos))
 			}
 
 			if newSection.outputs == 0x80 {
 				if err := newSection.checkInputs(currentStackMin); err != nil {
-					return 0, fmt.Errorf("%w: at pos %d", err, pos)
+					return fail(pos, fmt.Errorf("%w: at pos %d", err, pos))
 				}
 			} else {
 				if currentStackMax != currentStackMin {
-					return 0, fmt.Errorf("%w: max %d, min %d, at pos %d", errInvalidOutputs, currentStackMax, currentStackMin, pos)
+					return fail(pos, fmt.Errorf("%w: max %d, min %d, at pos %d", errInvalidOutputs, currentStackMax, currentStackMin, pos))
 				}
 				wantStack := int(metadata[section].outputs) - newSection.stackDelta()
 				if currentStackMax != wantStack {
-					return 0, fmt.Errorf("%w: at pos %d", errInvalidOutputs, pos)
+					return fail(pos, fmt.Errorf("%w: at pos %d", errInvalidOutputs, pos))
 				}
 			}
 			qualifiedExit = qualifiedExit || newSection.outputs < maxOutputItems
 		case DUPN:
 			arg := int(code[pos+1]) + 1
 			if want, have := arg, currentStackMin; want > have {
-				return 0, fmt.Errorf("%w: at pos %d", ErrStackUnderflow{stackLen: have, required: want}, pos)
+				return fail(pos, fmt.Errorf("%w: at pos %d", ErrStackUnderflow{stackLen: have, required: want}, pos))
 			}
 		case SWAPN:
 			arg := int(code[pos+1]) + 1
 			if want, have := arg+1, currentStackMin; want > have {
-				return 0, fmt.Errorf("%w: at pos %d", ErrStackUnderflow{stackLen: have, required: want}, pos)
+				return fail(pos, fmt.Errorf("%w: at pos %d", ErrStackUnderflow{stackLen: have, required: want}, pos))
 			}
 		case EXCHANGE:
 			arg := int(code[pos+1])
 			n := arg>>4 + 1
 			m := arg&0x0f + 1
 			if want, have := n+m+1, currentStackMin; want > have {
-				return 0, fmt.Errorf("%w: at pos %d", ErrStackUnderflow{stackLen: have, required: want}, pos)
+				return fail(pos, fmt.Errorf("%w: at pos %d", ErrStackUnderflow{stackLen: have, required: want}, pos))
 			}
 		default:
 			if want, have := jt[op].minStack, currentStackMin; want > have {
-				return 0, fmt.Errorf("%w: at pos %d", ErrStackUnderflow{stackLen: have, required: want}, pos)
+				return fail(pos, fmt.Errorf("%w: at pos %d", ErrStackUnderflow{stackLen: have, required: want}, pos))
 			}
 		}
 		if !terminals[op] && op != CALLF {
@@ -153,10 +169,10 @@ func validateControlFlow(code []byte, section int, metadata []*functionMetadata,
 			if nextPos+1 < pos {
 				ok, nextMin, nextMax := getStackMaxMin(nextPos + 1)
 				if !ok {
-					return 0, errInvalidBackwardJump
+					return fail(pos, errInvalidBackwardJump)
 				}
 				if nextMax != currentStackMax || nextMin != currentStackMin {
-					return 0, errInvalidMaxStackHeight
+					return fail(pos, errInvalidMaxStackHeight)
 				}
 			} else {
 				ok, nextMin, nextMax := getStackMaxMin(nextPos + 1)
@@ -190,7 +206,7 @@ func validateControlFlow(code []byte, section int, metadata []*functionMetadata,
 			for _, instr := range next {
 				nextPC := instr + 1
 				if nextPC >= len(code) {
-					return 0, fmt.Errorf("%w: end with %s, pos %d", errInvalidCodeTermination, op, pos)
+					return fail(pos, fmt.Errorf("%w: end with %s, pos %d", errInvalidCodeTermination, op, pos))
 				}
 				if nextPC > pos {
 					// target reached via forward jump or seq flow
@@ -204,13 +220,13 @@ func validateControlFlow(code []byte, section int, metadata []*functionMetadata,
 					// target reached via backwards jump
 					ok, nextMin, nextMax := getStackMaxMin(nextPC)
 					if !ok {
-						return 0, errInvalidBackwardJump
+						return fail(pos, errInvalidBackwardJump)
 					}
 					if currentStackMax != nextMax {
-						return 0, fmt.Errorf("%w want %d as current max got %d at pos %d,", errInvalidBackwardJump, currentStackMax, nextMax, pos)
+						return fail(pos, fmt.Errorf("%w want %d as current max got %d at pos %d,", errInvalidBackwardJump, currentStackMax, nextMax, pos))
 					}
 					if currentStackMin != nextMin {
-						return 0, fmt.Errorf("%w want %d as current min got %d at pos %d,", errInvalidBackwardJump, currentStackMin, nextMin, pos)
+						return fail(pos, fmt.Errorf("%w want %d as current min got %d at pos %d,", errInvalidBackwardJump, currentStackMin, nextMin, pos))
 					}
 				}
 			}
@@ -223,13 +239,13 @@ func validateControlFlow(code []byte, section int, metadata []*functionMetadata,
 		}
 	}
 	if qualifiedExit != (metadata[section].outputs < maxOutputItems) {
-		return 0, fmt.Errorf("%w no RETF or qualified JUMPF", errInvalidNonReturningFlag)
+		return 0, nil, newValidationError(section, -1, 0, -1, -1, fmt.Errorf("%w no RETF or qualified JUMPF", errInvalidNonReturningFlag))
 	}
 	if maxStackHeight >= int(params.StackLimit) {
-		return 0, ErrStackOverflow{maxStackHeight, int(params.StackLimit)}
+		return 0, nil, newValidationError(section, -1, 0, -1, -1, ErrStackOverflow{maxStackHeight, int(params.StackLimit)})
 	}
 	if maxStackHeight != int(metadata[section].maxStackHeight) {
-		return 0, fmt.Errorf("%w in code section %d: have %d, want %d", errInvalidMaxStackHeight, section, maxStackHeight, metadata[section].maxStackHeight)
+		return 0, nil, newValidationError(section, -1, 0, -1, -1, fmt.Errorf("%w in code section %d: have %d, want %d", errInvalidMaxStackHeight, section, maxStackHeight, metadata[section].maxStackHeight))
 	}
-	return visitCount, nil
+	return visitCount, bounds, nil
 }
@@ -72,6 +72,50 @@ type validationResult struct {
 	isRuntime            bool
 }
 
+// ValidationError is returned when a container fails EOF code validation. Besides
+// the error itself, it carries the location of the violation, for diagnostics.
+type ValidationError struct {
+	Path     []int  // indices of the subcontainers leading to the offending container
+	Section  int    // index of the offending code section, -1 if not section-specific
+	Pos      int    // byte offset within the code section, -1 if unknown
+	Op       OpCode // opcode at Pos
+	StackMin int    // minimum stack height before executing Op, -1 if unknown
+	StackMax int    // maximum stack height before executing Op, -1 if unknown
+	err      error
+}
+
+func newValidationError(section, pos int, op OpCode, stackMin, stackMax int, err error) *ValidationError {
+	return &ValidationError{
+		Section:  section,
+		Pos:      pos,
+		Op:       op,
+		StackMin: stackMin,
+		StackMax: stackMax,
+		err:      err,
+	}
+}
+
+func (e *ValidationError) Error() string {
+	return e.err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.err
+}
+
+// Rule returns the violated validation rule, which is the innermost error
+// wrapped by the validation error.
+func (e *ValidationError) Rule() error {
+	err := e.err
+	for {
+		inner := errors.Unwrap(err)
+		if inner == nil {
+			return err
+		}
+		err = inner
+	}
+}
+
 // validateCode validates the code parameter against the EOF v1 validity requirements.
 func validateCode(code []byte, section int, container *Container, jt *JumpTable, isInitCode bool) (*validationResult, error) {
 	var (
@@ -80,6 +124,7 @@ func validateCode(code []byte, section int, container *Container, jt *JumpTable,
 		// non-immediate values). This is used at the end to determine
 		// if each instruction is reachable.
 		count                = 0
+		last                 = 0 // position of the most recent instruction
 		op                   OpCode
 		analysis             bitvec
 		visitedCode          map[int]struct{}
@@ -95,39 +140,40 @@ func validateCode(code []byte, section int, container *Container, jt *JumpTable,
 	//   will not cause a stack overflow.
 	for i < len(code) {
 		count++
+		last = i
 		op = OpCode(code[i])
 		if jt[op].undefined {
-			return nil, fmt.Errorf("%w: op %s, pos %d", errUndefinedInstruction, op, i)
+			return nil, newValidationError(section, i, op, -1, -1, fmt.Errorf("%w: op %s, pos %d", errUndefinedInstruction, op, i))
 		}
 		size := int(immediates[op])
 		if size != 0 && len(code) <= i+size {
-			return nil, fmt.Errorf("%w: op %s, pos %d", errTruncatedImmediate, op, i)
+			return nil, newValidationError(section, i, op, -1, -1, fmt.Errorf("%w: op %s, pos %d", errTruncatedImmediate, op, i))
 		}
 		switch op {
 		case RJUMP, RJUMPI:
 			if err := checkDest(code, &analysis, i+1, i+3, len(code)); err != nil {
-				return nil, err
+				return nil, newValidationError(section, i, op, -1, -1, err)
 			}
 		case RJUMPV:
 			max_size := int(code[i+1])
 			length := max_size + 1
 			if len(code) <= i+length {
-				return nil, fmt.Errorf("%w: jump table truncated, op %s, pos %d", errTruncatedImmediate, op, i)
+				return nil, newValidationError(section, i, op, -1, -1, fmt.Errorf("%w: jump table truncated, op %s, pos %d", errTruncatedImmediate, op, i))
 			}
 			offset := i + 2
 			for j := 0; j < length; j++ {
 				if err := checkDest(code, &analysis, offset+j*2, offset+(length*2), len(code)); err != nil {
-					return nil, err
+					return nil, newValidationError(section, i, op, -1, -1, err)
 				}
 			}
 			i += 2 * max_size
 		case CALLF:
 			arg, _ := parseUint16(code[i+1:])
 			if arg >= len(container.types) {
-				return nil, fmt.Errorf("%w: arg %d, last %d, pos %d", errInvalidSectionArgument, arg, len(container.types), i)
+				return nil, newValidationError(section, i, op, -1, -1, fmt.Errorf("%w: arg %d, last %d, pos %d", errInvalidSectionArgument, arg, len(container.types), i))
 			}
 			if container.types[arg].outputs == 0x80 {
-				return nil, fmt.Errorf("%w: section %v", errInvalidCallArgument, arg)
+				return nil, newValidationError(section, i, op, -1, -1, fmt.Errorf("%w: section %v", errInvalidCallArgument, arg))
 			}
 			if visitedCode == nil {
 				visitedCode = make(map[int]struct{})
@@ -136,10 +182,10 @@ func validateCode(code []byte, section int, container *Container, jt *JumpTable,
 		case JUMPF:
 			arg, _ := parseUint16(code[i+1:])
 			if arg >= len(container.types) {
-				return nil, fmt.Errorf("%w: arg %d, last %d, pos %d", errInvalidSectionArgument, arg, len(container.types), i)
+				return nil, newValidationError(section, i, op, -1, -1, fmt.Errorf("%w: arg %d, last %d, pos %d", errInvalidSectionArgument, arg, len(container.types), i))
 			}
 			if container.types[arg].outputs != 0x80 && container.types[arg].outputs > container.types[section].outputs {
-				return nil, fmt.Errorf("%w: arg %d, last %d, pos %d", errInvalidOutputs, arg, len(container.types), i)
+				return nil, newValidationError(section, i, op, -1, -1, fmt.Errorf("%w: arg %d, last %d, pos %d", errInvalidOutputs, arg, len(container.types), i))
 			}
 			if visitedCode == nil {
 				visitedCode = make(map[int]struct{})
@@ -149,50 +195,50 @@ func validateCode(code []byte, section int, container *Container, jt *JumpTable,
 			arg, _ := parseUint16(code[i+1:])
 			// TODO why are we checking this? We should just pad
 			if arg+32 > len(container.data) {
-				return nil, fmt.Errorf("%w: arg %d, last %d, pos %d", errInvalidDataloadNArgument, arg, len(container.data), i)
+				return nil, newValidationError(section, i, op, -1, -1, fmt.Errorf("%w: arg %d, last %d, pos %d", errInvalidDataloadNArgument, arg, len(container.data), i))
 			}
 		case RETURNCONTRACT:
 			if !isInitCode {
-				return nil, errIncompatibleContainerKind
+				return nil, newValidationError(section, i, op, -1, -1, errIncompatibleContainerKind)
 			}
 			arg := int(code[i+1])
 			if arg >= len(container.subContainers) {
-				return nil, fmt.Errorf("%w: arg %d, last %d, pos %d", errUnreachableCode, arg, len(container.subContainers), i)
+				return nil, newValidationError(section, i, op, -1, -1, fmt.Errorf("%w: arg %d, last %d, pos %d", errUnreachableCode, arg, len(container.subContainers), i))
 			}
 			if visitedSubcontainers == nil {
 				visitedSubcontainers = make(map[int]int)
 			}
 			// We need to store per subcontainer how it was referenced
 			if v, ok := visitedSubcontainers[arg]; ok && v != refByReturnContract {
-				return nil, fmt.Errorf("section already referenced, arg :%d", arg)
+				return nil, newValidationError(section, i, op, -1, -1, fmt.Errorf("section already referenced, arg :%d", arg))
 			}
 			if hasStop {
-				return nil, errStopAndReturnContract
+				return nil, newValidationError(section, i, op, -1, -1, errStopAndReturnContract)
 			}
 			hasReturnContract = true
 			visitedSubcontainers[arg] = refByReturnContract
 		case EOFCREATE:
 			arg := int(code[i+1])
 			if arg >= len(container.subContainers) {
-				return nil, fmt.Errorf("%w: arg %d, last %d, pos %d", errUnreachableCode, arg, len(container.subContainers), i)
+				return nil, newValidationError(section, i, op, -1, -1, fmt.Errorf("%w: arg %d, last %d, pos %d", errUnreachableCode, arg, len(container.subContainers), i))
 			}
 			if ct := container.subContainers[arg]; len(ct.data) != ct.dataSize {
-				return nil, fmt.Errorf("%w: container %d, have %d, claimed %d, pos %d", errEOFCreateWithTruncatedSection, arg, len(ct.data), ct.dataSize, i)
+				return nil, newValidationError(section, i, op, -1, -1, fmt.Errorf("%w: container %d, have %d, claimed %d, pos %d", errEOFCreateWithTruncatedSection, arg, len(ct.data), ct.dataSize, i))
 			}
 			if visitedSubcontainers == nil {
 				visitedSubcontainers = make(map[int]int)
 			}
 			// We need to store per subcontainer how it was referenced
 			if v, ok := visitedSubcontainers[arg]; ok && v != refByEOFCreate {
-				return nil, fmt.Errorf("section already referenced, arg :%d", arg)
+				return nil, newValidationError(section, i, op, -1, -1, fmt.Errorf("section already referenced, arg :%d", arg))
 			}
 			visitedSubcontainers[arg] = refByEOFCreate
 		case STOP, RETURN:
 			if isInitCode {
-				return nil, errStopInInitCode
+				return nil, newValidationError(section, i, op, -1, -1, errStopInInitCode)
 			}
 			if hasReturnContract {
-				return nil, errStopAndReturnContract
+				return nil, newValidationError(section, i, op, -1, -1, errStopAndReturnContract)
 			}
 			hasStop = true
 		}
@@ -201,13 +247,23 @@ func validateCode(code []byte, section int, container *Container, jt *JumpTable,
 	// Code sections may not "fall through" and require proper termination.
 	// Therefore, the last instruction must be considered terminal or RJUMP.
 	if !terminals[op] && op != RJUMP {
-		return nil, fmt.Errorf("%w: end with %s, pos %d", errInvalidCodeTermination, op, i)
+		return nil, newValidationError(section, last, op, -1, -1, fmt.Errorf("%w: end with %s, pos %d", errInvalidCodeTermination, op, i))
 	}
-	if paths, err := validateControlFlow(code, section, container.types, jt); err != nil {
+	paths, bounds, err := validateControlFlow(code, section, container.types, jt)
+	if err != nil {
 		return nil, err
-	} else if paths != count {
-		// TODO(matt): return actual position of unreachable code
-		return nil, errUnreachableCode
+	}
+	if paths != count {
+		// Report the first instruction not visited by the control flow validation
+		if analysis == nil {
+			analysis = eofCodeBitmap(code)
+		}
+		for pos := 0; pos < len(code); pos++ {
+			if ok, _, _ := bounds.get(pos); !ok && analysis.codeSegment(uint64(pos)) {
+				return nil, newValidationError(section, pos, OpCode(code[pos]), -1, -1, errUnreachableCode)
+			}
+		}
+		return nil, newValidationError(section, -1, 0, -1, -1, errUnreachableCode)
 	}
 	return &validationResult{
 		visitedCode:          visitedCode,
@@ -258,6 +258,66 @@ func TestValidateCode(t *testing.T) {
 	}
 }
 
+func TestValidationErrorLocation(t *testing.T) {
+	for i, test := range []struct {
+		code     []byte
+		metadata []*functionMetadata
+		want     ValidationError
+		rule     string
+	}{
+		{
+			// Stack underflow after a conditional jump merges two stack heights.
+			code: []byte{
+				byte(CALLER),
+				byte(CALLER),
+				byte(RJUMPI), 0x00, 0x01,
+				byte(CALLER),
+				byte(ADD),
+				byte(ADD),
+				byte(STOP),
+			},
+			metadata: []*functionMetadata{{inputs: 0, outputs: 0x80, maxStackHeight: 2}},
+			want:     ValidationError{Section: 0, Pos: 6, Op: ADD, StackMin: 1, StackMax: 2},
+			rule:     "stack underflow",
+		},
+		{
+			code: []byte{
+				byte(CALLER),
+				byte(POP),
+			},
+			metadata: []*functionMetadata{{inputs: 0, outputs: 0x80, maxStackHeight: 1}},
+			want:     ValidationError{Section: 0, Pos: 1, Op: POP, StackMin: -1, StackMax: -1},
+			rule:     errInvalidCodeTermination.Error(),
+		},
+		{
+			// Code jumped over is never reached.
+			code: []byte{
+				byte(RJUMP), 0x00, 0x02,
+				byte(CALLER),
+				byte(POP),
+				byte(STOP),
+			},
+			metadata: []*functionMetadata{{inputs: 0, outputs: 0x80, maxStackHeight: 1}},
+			want:     ValidationError{Section: 0, Pos: 3, Op: CALLER, StackMin: -1, StackMax: -1},
+			rule:     errUnreachableCode.Error(),
+		},
+	} {
+		container := &Container{types: test.metadata}
+		_, err := validateCode(test.code, 0, container, &eofInstructionSet, false)
+		var verr *ValidationError
+		if !errors.As(err, &verr) {
+			t.Fatalf("test %d: expected validation error, got %v", i, err)
+		}
+		if verr.Section != test.want.Section || verr.Pos != test.want.Pos || verr.Op != test.want.Op ||
+			verr.StackMin != test.want.StackMin || verr.StackMax != test.want.StackMax {
+			t.Errorf("test %d: wrong location: have %+v, want %+v", i, *verr, test.want)
+		}
+		if rule := verr.Rule().Error(); rule != test.rule {
+			t.Errorf("test %d: wrong rule: have %v, want %v", i, rule, test.rule)
+		}
+	}
+}
+
 // BenchmarkRJUMPI tries to benchmark the RJUMPI opcode validation
 // For this we do a bunch of RJUMPIs that jump backwards (in a potential infinite loop).
 func BenchmarkRJUMPI(b *testing.B) {
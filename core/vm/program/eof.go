@@ -0,0 +1,226 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package program
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// NonReturning is the outputs value of an EOF code section which never returns
+// to its caller.
+const NonReturning = 0x80
+
+// Rjump implements RJUMP, with an offset relative to the next instruction.
+func (p *Program) Rjump(offset int16) *Program {
+	p.Op(vm.RJUMP)
+	return p.Append([]byte{byte(uint16(offset) >> 8), byte(offset)})
+}
+
+// RjumpIf implements RJUMPI, with an offset relative to the next instruction.
+func (p *Program) RjumpIf(offset int16, condition any) *Program {
+	p.Push(condition)
+	p.Op(vm.RJUMPI)
+	return p.Append([]byte{byte(uint16(offset) >> 8), byte(offset)})
+}
+
+// RjumpV implements RJUMPV with the given jump table. The offsets are relative
+// to the instruction following the table.
+func (p *Program) RjumpV(index any, offsets ...int16) *Program {
+	if len(offsets) == 0 || len(offsets) > 256 {
+		panic("RJUMPV requires between 1 and 256 offsets")
+	}
+	p.Push(index)
+	p.Op(vm.RJUMPV)
+	p.add(byte(len(offsets) - 1))
+	for _, offset := range offsets {
+		p.Append([]byte{byte(uint16(offset) >> 8), byte(offset)})
+	}
+	return p
+}
+
+// CallF implements CALLF into the given code section.
+func (p *Program) CallF(section uint16) *Program {
+	p.Op(vm.CALLF)
+	return p.Append([]byte{byte(section >> 8), byte(section)})
+}
+
+// JumpF implements JUMPF into the given code section.
+func (p *Program) JumpF(section uint16) *Program {
+	p.Op(vm.JUMPF)
+	return p.Append([]byte{byte(section >> 8), byte(section)})
+}
+
+// RetF implements RETF.
+func (p *Program) RetF() *Program {
+	return p.Op(vm.RETF)
+}
+
+// DataLoadN implements DATALOADN, loading 32 bytes from the data section at
+// the given offset.
+func (p *Program) DataLoadN(offset uint16) *Program {
+	p.Op(vm.DATALOADN)
+	return p.Append([]byte{byte(offset >> 8), byte(offset)})
+}
+
+// EOFCreate implements EOFCREATE of the given subcontainer. The value, salt
+// and input area are pushed to the stack first.
+func (p *Program) EOFCreate(container uint8, value, salt, inOffset, inSize any) *Program {
+	p.Push(inSize).Push(inOffset).Push(salt).Push(value)
+	p.Op(vm.EOFCREATE)
+	return p.add(container)
+}
+
+// ReturnContract implements RETURNCONTRACT of the given subcontainer, appending
+// the memory area [offset, offset+size) as auxiliary data.
+func (p *Program) ReturnContract(container uint8, offset, size any) *Program {
+	p.Push(size).Push(offset)
+	p.Op(vm.RETURNCONTRACT)
+	return p.add(container)
+}
+
+// ExtCall is a convenience function to make an EXTCALL. Gas is not passed
+// explicitly, as EXTCALL always forwards all available gas.
+func (p *Program) ExtCall(address, inOffset, inSize, value any) *Program {
+	p.Push(value).Push(inSize).Push(inOffset).Push(address)
+	return p.Op(vm.EXTCALL)
+}
+
+// ExtDelegateCall is a convenience function to make an EXTDELEGATECALL.
+func (p *Program) ExtDelegateCall(address, inOffset, inSize any) *Program {
+	p.Push(inSize).Push(inOffset).Push(address)
+	return p.Op(vm.EXTDELEGATECALL)
+}
+
+// ExtStaticCall is a convenience function to make an EXTSTATICCALL.
+func (p *Program) ExtStaticCall(address, inOffset, inSize any) *Program {
+	p.Push(inSize).Push(inOffset).Push(address)
+	return p.Op(vm.EXTSTATICCALL)
+}
+
+// Container is a builder for EOF containers. The header, including all section
+// sizes, is computed when the container is assembled. As with Program, misuse
+// typically causes panics.
+type Container struct {
+	sections  []vm.CodeSection
+	subs      []*Container
+	data      []byte
+	dataSize  int // -1 means len(data)
+	initcode  bool
+	unchecked bool
+}
+
+// NewContainer creates a new, empty EOF container builder.
+func NewContainer() *Container {
+	return &Container{dataSize: -1}
+}
+
+// Code adds a code section with the given type signature. Use NonReturning as
+// outputs for sections which never return.
+func (c *Container) Code(inputs, outputs uint8, maxStackHeight uint16, code *Program) *Container {
+	c.sections = append(c.sections, vm.CodeSection{
+		Inputs:         inputs,
+		Outputs:        outputs,
+		MaxStackHeight: maxStackHeight,
+		Code:           code.Bytes(),
+	})
+	return c
+}
+
+// Data sets the contents of the data section.
+func (c *Container) Data(data []byte) *Container {
+	c.data = data
+	return c
+}
+
+// DataSize overrides the data section size declared in the header. This can
+// be used for subcontainers whose data section is filled in by RETURNCONTRACT.
+func (c *Container) DataSize(size int) *Container {
+	c.dataSize = size
+	return c
+}
+
+// SubContainer adds a nested container, which can be referenced by EOFCREATE
+// or RETURNCONTRACT through its index.
+func (c *Container) SubContainer(sub *Container) *Container {
+	c.subs = append(c.subs, sub)
+	return c
+}
+
+// Initcode marks the container as initcode, i.e. a container which is to be
+// deployed through a creation transaction or EOFCREATE.
+func (c *Container) Initcode() *Container {
+	c.initcode = true
+	return c
+}
+
+// Unchecked disables validation of the container when it is assembled, which
+// allows constructing deliberately invalid containers.
+func (c *Container) Unchecked() *Container {
+	c.unchecked = true
+	return c
+}
+
+// Build assembles the container. Unless the container is marked Unchecked,
+// it is validated against the EOF rule set and an error is returned if the
+// validation fails.
+func (c *Container) Build() (*vm.Container, error) {
+	container := c.build()
+	if c.unchecked {
+		return container, nil
+	}
+	// Round-trip through the binary encoding, so the header is validated too.
+	var (
+		parsed vm.Container
+		jt     = vm.NewEOFInstructionSetForTesting()
+	)
+	if err := parsed.UnmarshalBinary(container.MarshalBinary(), c.initcode); err != nil {
+		return nil, err
+	}
+	if err := parsed.ValidateCode(&jt, c.initcode); err != nil {
+		return nil, err
+	}
+	return container, nil
+}
+
+func (c *Container) build() *vm.Container {
+	subs := make([]*vm.Container, len(c.subs))
+	for i, sub := range c.subs {
+		subs[i] = sub.build()
+	}
+	dataSize := c.dataSize
+	if dataSize < 0 {
+		dataSize = len(c.data)
+	}
+	return vm.NewContainer(c.sections, subs, c.data, dataSize)
+}
+
+// Bytes returns the binary encoding of the container. It panics if the
+// container fails validation.
+func (c *Container) Bytes() []byte {
+	container, err := c.Build()
+	if err != nil {
+		panic(fmt.Sprintf("invalid container: %v", err))
+	}
+	return container.MarshalBinary()
+}
+
+// Hex returns the binary encoding of the container as a hex string.
+func (c *Container) Hex() string {
+	return fmt.Sprintf("%02x", c.Bytes())
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package program
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+func TestEOFOps(t *testing.T) {
+	for i, tc := range []struct {
+		prog *Program
+		want string
+	}{
+		{New().Rjump(-3), "e0fffd"},
+		{New().RjumpIf(2, 1), "6001e10002"},
+		{New().RjumpV(0, 1, -1), "6000e2010001ffff"},
+		{New().CallF(1).JumpF(0x102).RetF(), "e30001e50102e4"},
+		{New().DataLoadN(0x20), "d10020"},
+		{New().EOFCreate(1, 0, 0, 0, 0), "6000600060006000ec01"},
+		{New().ReturnContract(0, 0, 32), "60206000ee00"},
+		{New().ExtCall(common.HexToAddress("0x1337"), 0, 0, 1), "600160006000611337f8"},
+		{New().ExtStaticCall(common.HexToAddress("0x1337"), 0, 0), "60006000611337fb"},
+	} {
+		if have := tc.prog.Hex(); have != tc.want {
+			t.Errorf("test %d: have %v want %v", i, have, tc.want)
+		}
+	}
+}
+
+func TestEOFContainer(t *testing.T) {
+	// A factory, deploying a contract whose runtime reads its data section,
+	// which is extended by the initcode.
+	runtime := NewContainer().
+		Code(0, NonReturning, 2, New().DataLoadN(0).Push(0).Op(vm.SSTORE, vm.STOP)).
+		Data(make([]byte, 32)).
+		DataSize(64)
+	initcode := NewContainer().
+		Code(0, NonReturning, 2, New().Push(0x2a).Push(0).Op(vm.MSTORE).ReturnContract(0, 0, 32)).
+		SubContainer(runtime)
+	factory := NewContainer().
+		Code(0, NonReturning, 4, New().EOFCreate(0, 0, 0, 0, 0).CallF(1).Op(vm.STOP)).
+		Code(1, 0, 4, New().Op(vm.POP).ExtCall(common.Address{}, 0, 0, 0).Op(vm.POP).RetF()).
+		SubContainer(initcode)
+
+	code := factory.Bytes()
+	var c vm.Container
+	if err := c.UnmarshalBinary(code, false); err != nil {
+		t.Fatal(err)
+	}
+	jt := vm.NewEOFInstructionSetForTesting()
+	if err := c.ValidateCode(&jt, false); err != nil {
+		t.Fatal(err)
+	}
+	if have, want := len(c.CodeSections()), 2; have != want {
+		t.Fatalf("wrong number of code sections: have %d want %d", have, want)
+	}
+	if have, want := c.SubContainers()[0].SubContainers()[0].DataSize(), 64; have != want {
+		t.Fatalf("wrong runtime data size: have %d want %d", have, want)
+	}
+}
+
+func TestEOFContainerInvalid(t *testing.T) {
+	for i, c := range []*Container{
+		// Wrong max stack height.
+		NewContainer().Code(0, NonReturning, 0, New().Push(1).Op(vm.STOP)),
+		// DATALOADN beyond the data section.
+		NewContainer().Code(0, NonReturning, 1, New().DataLoadN(1).Op(vm.STOP)).Data(make([]byte, 32)),
+		// RETURNCONTRACT in runtime code.
+		NewContainer().Code(0, NonReturning, 2, New().ReturnContract(0, 0, 0)).
+			SubContainer(NewContainer().Code(0, NonReturning, 0, New().Op(vm.INVALID))),
+		// Unreferenced subcontainer.
+		NewContainer().Code(0, NonReturning, 0, New().Op(vm.STOP)).
+			SubContainer(NewContainer().Code(0, NonReturning, 0, New().Op(vm.INVALID))),
+	} {
+		if _, err := c.Build(); err == nil {
+			t.Errorf("test %d: expected validation error", i)
+		}
+		if _, err := c.Unchecked().Build(); err != nil {
+			t.Errorf("test %d: unexpected error for unchecked container: %v", i, err)
+		}
+	}
+}
@@ -23,8 +23,10 @@ import (
 	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state/pruner"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/rlp"
 )
@@ -141,3 +143,51 @@ func (api *AdminAPI) ImportChain(file string) (bool, error) {
 	}
 	return true, nil
 }
+
+// PruneStateArgs represents the arguments for pruning the stale state.
+type PruneStateArgs struct {
+	BloomSize  *uint64 `json:"bloomSize"`  // Megabytes of memory allocated to the bloom filter
+	BatchSize  *int    `json:"batchSize"`  // Maximum number of trie nodes deleted in a batch
+	BatchDelay *string `json:"batchDelay"` // Pause after each batch deletion, e.g. "100ms"
+}
+
+// PruneState starts pruning the stale state in the background, retaining the
+// recent states covered by the snapshot layers. It's only supported by the
+// hash-based state scheme.
+func (api *AdminAPI) PruneState(args *PruneStateArgs) (bool, error) {
+	if api.eth.handler.snapSync.Load() {
+		return false, errors.New("state pruning is not allowed during snap sync")
+	}
+	config := pruner.DefaultOnlineConfig
+	if args != nil {
+		if args.BloomSize != nil {
+			config.BloomSize = *args.BloomSize
+		}
+		if args.BatchSize != nil {
+			config.BatchSize = *args.BatchSize
+		}
+		if args.BatchDelay != nil {
+			delay, err := time.ParseDuration(*args.BatchDelay)
+			if err != nil {
+				return false, err
+			}
+			config.BatchDelay = delay
+		}
+	}
+	if err := api.eth.statePruner.Start(api.eth.BlockChain().CurrentBlock().Root, config); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// PruneStateStatus returns the progress of the running state pruning, or the
+// last one if none is running.
+func (api *AdminAPI) PruneStateStatus() pruner.OnlineStatus {
+	return api.eth.statePruner.Status()
+}
+
+// StopPruneState interrupts the running state pruning. The trie nodes already
+// deleted are not restored, it's safe to start the pruning again later.
+func (api *AdminAPI) StopPruneState() bool {
+	return api.eth.statePruner.Stop()
+}
@@ -26,13 +26,17 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/core/stateless"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/trie/heatmap"
 )
 
 // DebugAPI is the collection of Ethereum full node APIs for debugging the
@@ -443,3 +447,328 @@ func (api *DebugAPI) GetTrieFlushInterval() (string, error) {
 	}
 	return api.eth.blockchain.GetTrieFlushInterval().String(), nil
 }
+
+// defaultHeatmapContracts is the default number of the most accessed storage
+// tries included in the trie heatmap report.
+const defaultHeatmapContracts = 20
+
+// StartTrieHeatmap starts sampling one in every rate trie node accesses, which
+// discards the samples collected previously.
+func (api *DebugAPI) StartTrieHeatmap(rate uint64) error {
+	if rate == 0 {
+		return errors.New("sample rate must be positive")
+	}
+	heatmap.Start(rate)
+	return nil
+}
+
+// StopTrieHeatmap stops sampling the trie node accesses. The collected samples
+// are retained until the next start.
+func (api *DebugAPI) StopTrieHeatmap() {
+	heatmap.Stop()
+}
+
+// TrieHeatmap returns the summary of the sampled trie node accesses, containing
+// the accesses by trie depth, the cache hit ratios by depth and the top n most
+// accessed storage tries.
+func (api *DebugAPI) TrieHeatmap(n *int) (*heatmap.Report, error) {
+	top := defaultHeatmapContracts
+	if n != nil {
+		top = *n
+	}
+	report := heatmap.Collect(top)
+	if report == nil {
+		return nil, errors.New("trie heatmap is not started")
+	}
+	return report, nil
+}
+
+// ExecutionWitnessResult is the result of ExecutionWitness, containing the
+// witness in both JSON and RLP forms, along with its size statistics.
+type ExecutionWitnessResult struct {
+	Witness *stateless.Witness      `json:"witness"`
+	RLP     hexutil.Bytes           `json:"rlp"`
+	Stats   *stateless.WitnessStats `json:"stats"`
+}
+
+// ExecutionWitness re-executes the given block on top of its parent state and
+// returns the execution witness, which contains all the headers, codes and
+// trie nodes required to execute the block statelessly.
+func (api *DebugAPI) ExecutionWitness(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*ExecutionWitnessResult, error) {
+	block, err := api.eth.APIBackend.BlockByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block %v not found", blockNrOrHash)
+	}
+	if block.NumberU64() == 0 {
+		return nil, errors.New("no witness for genesis")
+	}
+	parent := api.eth.blockchain.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, fmt.Errorf("parent %#x not found", block.ParentHash())
+	}
+	statedb, release, err := api.eth.stateAtBlock(ctx, parent, 0, nil, true, false)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	witness, err := stateless.NewWitness(block.Header(), api.eth.blockchain)
+	if err != nil {
+		return nil, err
+	}
+	// The witness is populated by the prefetcher with the trie nodes accessed
+	// during the execution and the state root computation.
+	statedb.StartPrefetcher("debug", witness)
+	defer statedb.StopPrefetcher()
+
+	res, err := api.eth.blockchain.Processor().Process(block, statedb, vm.Config{})
+	if err != nil {
+		return nil, err
+	}
+	if err := api.eth.blockchain.Validator().ValidateState(block, statedb, res, false); err != nil {
+		return nil, err
+	}
+	blob, err := rlp.EncodeToBytes(witness)
+	if err != nil {
+		return nil, err
+	}
+	return &ExecutionWitnessResult{Witness: witness, RLP: blob, Stats: witness.Stats()}, nil
+}
+
+const (
+	// StateStreamDefaultChunk is the default number of items (accounts and
+	// storage slots together) delivered in a state stream chunk.
+	StateStreamDefaultChunk = 4096
+
+	// StateStreamMaxChunk is the maximum number of items allowed in a chunk.
+	StateStreamMaxChunk = 65536
+)
+
+// StateCursor is the position of a state stream. The account is the hash of
+// the first account to be delivered. If the storage is set, the account was
+// already partially delivered and the stream continues with its storage slots
+// starting at the given hash.
+type StateCursor struct {
+	Account common.Hash  `json:"account"`
+	Storage *common.Hash `json:"storage,omitempty"`
+}
+
+// StateStreamConfig are the optional parameters of a state stream.
+type StateStreamConfig struct {
+	Start     *StateCursor `json:"start"`     // Position to resume the stream from
+	ChunkSize int          `json:"chunkSize"` // Maximum number of items in a chunk
+	NoStorage bool         `json:"noStorage"` // Skip the storage slots of accounts
+}
+
+// StreamAccount is an account delivered in a state stream chunk, along with
+// its storage slots ordered by hash. The storage of a large account is split
+// across multiple chunks, in which case the account is repeated in each of
+// them with the next batch of slots.
+type StreamAccount struct {
+	Hash     common.Hash    `json:"hash"`
+	Nonce    hexutil.Uint64 `json:"nonce"`
+	Balance  *hexutil.Big   `json:"balance"`
+	Root     common.Hash    `json:"root"`
+	CodeHash common.Hash    `json:"codeHash"`
+	Storage  []StreamSlot   `json:"storage,omitempty"`
+}
+
+// StreamSlot is a storage slot delivered in a state stream chunk.
+type StreamSlot struct {
+	Hash  common.Hash `json:"hash"`
+	Value common.Hash `json:"value"`
+}
+
+// StateChunk is a batch of accounts delivered by a state stream. Next is the
+// position to resume the stream from after this chunk, nil if the state was
+// delivered completely. If the iteration fails, the stream is terminated by a
+// chunk with the error set and no accounts, pointing to the first undelivered
+// item.
+type StateChunk struct {
+	Root     common.Hash      `json:"root"`
+	Accounts []*StreamAccount `json:"accounts"`
+	Next     *StateCursor     `json:"next"`
+	Error    string           `json:"error,omitempty"`
+}
+
+// StateStream iterates the flat state snapshot of the given block and streams
+// all the accounts along with their storage slots in chunks ordered by hash.
+// Each chunk carries the cursor which can be used to resume an interrupted
+// stream in a new subscription.
+//
+// The state of the block must be covered by the snapshot. Note the snapshot
+// only retains the recent states, the state of a block becomes unavailable
+// once it's flattened into the persistent layer, which terminates the stream
+// with an error.
+func (api *DebugAPI) StateStream(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, config *StateStreamConfig) (*rpc.Subscription, error) {
+	snaps := api.eth.blockchain.Snapshots()
+	if snaps == nil {
+		return nil, errors.New("state snapshot is not enabled")
+	}
+	header, err := api.eth.APIBackend.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, fmt.Errorf("block %v not found", blockNrOrHash)
+	}
+	if config == nil {
+		config = new(StateStreamConfig)
+	}
+	limit := config.ChunkSize
+	if limit <= 0 {
+		limit = StateStreamDefaultChunk
+	}
+	if limit > StateStreamMaxChunk {
+		limit = StateStreamMaxChunk
+	}
+	var start StateCursor
+	if config.Start != nil {
+		start = *config.Start
+	}
+	// Open the iterator ahead to reject the unavailable states immediately
+	it, err := snaps.AccountIterator(header.Root, start.Account)
+	if err != nil {
+		return nil, err
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		it.Release()
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+
+	go func() {
+		defer it.Release()
+
+		stream := &stateStream{
+			snaps:     snaps,
+			root:      header.Root,
+			limit:     limit,
+			noStorage: config.NoStorage,
+			send: func(chunk *StateChunk) bool {
+				select {
+				case <-sub.Err():
+					return false
+				default:
+				}
+				return notifier.Notify(sub.ID, chunk) == nil
+			},
+		}
+		stream.run(it, start)
+	}()
+	return sub, nil
+}
+
+// stateStream assembles the chunks of a state stream.
+type stateStream struct {
+	snaps     *snapshot.Tree
+	root      common.Hash
+	limit     int                    // Maximum number of items in a chunk
+	noStorage bool                   // Whether the storage slots are skipped
+	send      func(*StateChunk) bool // Delivers a chunk, false if the stream is closed
+	chunk     *StateChunk            // Chunk being assembled
+	items     int                    // Number of items in the current chunk
+	pos       *StateCursor           // Position of the first item in the current chunk
+}
+
+// run iterates the state from the given cursor, delivering the chunks until
+// the iteration is complete or the stream is closed.
+func (s *stateStream) run(it snapshot.AccountIterator, start StateCursor) {
+	s.chunk, s.pos = &StateChunk{Root: s.root}, &start
+	for it.Next() {
+		hash := it.Hash()
+		if s.items >= s.limit && !s.flush(&StateCursor{Account: hash}) {
+			return
+		}
+		acc, err := types.FullAccount(it.Account())
+		if err != nil {
+			s.fail(err)
+			return
+		}
+		account := &StreamAccount{
+			Hash:     hash,
+			Nonce:    hexutil.Uint64(acc.Nonce),
+			Balance:  (*hexutil.Big)(acc.Balance.ToBig()),
+			Root:     acc.Root,
+			CodeHash: common.BytesToHash(acc.CodeHash),
+		}
+		s.chunk.Accounts = append(s.chunk.Accounts, account)
+		s.items++
+
+		if s.noStorage || acc.Root == types.EmptyRootHash {
+			continue
+		}
+		var seek common.Hash
+		if hash == start.Account && start.Storage != nil {
+			seek = *start.Storage
+		}
+		if !s.storage(account, seek) {
+			return
+		}
+	}
+	if err := it.Error(); err != nil {
+		s.fail(err)
+		return
+	}
+	s.flush(nil)
+}
+
+// storage appends the storage slots of the given account to the chunks, from
+// the slot with the given hash. False is returned if the stream is terminated.
+func (s *stateStream) storage(account *StreamAccount, seek common.Hash) bool {
+	it, err := s.snaps.StorageIterator(s.root, account.Hash, seek)
+	if err != nil {
+		s.fail(err)
+		return false
+	}
+	defer it.Release()
+
+	for it.Next() {
+		hash := it.Hash()
+		if s.items >= s.limit {
+			if !s.flush(&StateCursor{Account: account.Hash, Storage: &hash}) {
+				return false
+			}
+			// Repeat the account in the next chunk for the remaining slots
+			cpy := *account
+			cpy.Storage = nil
+			account = &cpy
+			s.chunk.Accounts = append(s.chunk.Accounts, account)
+			s.items++
+		}
+		_, content, _, err := rlp.Split(it.Slot())
+		if err != nil {
+			s.fail(err)
+			return false
+		}
+		account.Storage = append(account.Storage, StreamSlot{Hash: hash, Value: common.BytesToHash(content)})
+		s.items++
+	}
+	if err := it.Error(); err != nil {
+		s.fail(err)
+		return false
+	}
+	return true
+}
+
+// flush delivers the current chunk with the given resume position and starts
+// a new one. False is returned if the stream is closed.
+func (s *stateStream) flush(next *StateCursor) bool {
+	s.chunk.Next = next
+	if !s.send(s.chunk) {
+		return false
+	}
+	s.chunk, s.items, s.pos = &StateChunk{Root: s.root}, 0, next
+	return true
+}
+
+// fail terminates the stream with the given error. The partially assembled
+// chunk is discarded, the cursor in the error chunk points to its beginning.
+func (s *stateStream) fail(err error) {
+	log.Debug("State stream failed", "root", s.root, "err", err)
+	s.send(&StateChunk{Root: s.root, Next: s.pos, Error: err.Error()})
+}
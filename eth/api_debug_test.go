@@ -18,19 +18,34 @@ package eth
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"math/big"
 	"reflect"
 	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/stateless"
 	"github.com/ethereum/go-ethereum/core/tracing"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/program"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
 	"github.com/ethereum/go-ethereum/triedb"
 	"github.com/holiman/uint256"
 )
@@ -224,3 +239,267 @@ func TestStorageRangeAt(t *testing.T) {
 		}
 	}
 }
+
+func TestExecutionWitness(t *testing.T) {
+	t.Parallel()
+
+	var (
+		key, _   = crypto.GenerateKey()
+		sender   = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.HexToAddress("0xc0de")
+		engine   = beacon.New(ethash.NewFaker())
+		signer   = types.LatestSigner(params.MergedTestChainConfig)
+		genesis  = &core.Genesis{
+			Config: params.MergedTestChainConfig,
+			Alloc: types.GenesisAlloc{
+				sender:   {Balance: big.NewInt(params.Ether)},
+				contract: {Code: program.New().Op(vm.NUMBER).Op(vm.NUMBER).Op(vm.SSTORE).Bytes()},
+			},
+		}
+	)
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, engine, 8, func(i int, b *core.BlockGen) {
+		b.SetPoS()
+		tx, _ := types.SignNewTx(key, signer, &types.LegacyTx{Nonce: b.TxNonce(sender), To: &contract, Gas: 100_000, GasPrice: b.BaseFee()})
+		b.AddTx(tx)
+	})
+	// Run the chain in archive mode, the historical states are available.
+	config := core.DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	config.TrieDirtyDisabled = true
+
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), config, genesis, nil, engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	eth := &Ethereum{blockchain: chain}
+	eth.APIBackend = &EthAPIBackend{eth: eth}
+	api := NewDebugAPI(eth)
+
+	for _, block := range []*types.Block{blocks[0], blocks[3], blocks[7]} {
+		res, err := api.ExecutionWitness(context.Background(), rpc.BlockNumberOrHashWithHash(block.Hash(), false))
+		if err != nil {
+			t.Fatalf("block %d: failed to produce witness: %v", block.Number(), err)
+		}
+		if stats := res.Stats; stats.Codes != 1 || stats.StorageTries > 1 || stats.Unreachable != 0 ||
+			stats.AccountTrie.Nodes+stats.StorageTrie.Nodes != len(res.Witness.State) || stats.Size != uint64(len(res.RLP)) {
+			t.Fatalf("block %d: unexpected witness stats: %+v", block.Number(), stats)
+		}
+		// Both forms of the witness must be sufficient to execute the block.
+		decoded := new(stateless.Witness)
+		if err := rlp.DecodeBytes(res.RLP, decoded); err != nil {
+			t.Fatal(err)
+		}
+		blob, err := json.Marshal(res.Witness)
+		if err != nil {
+			t.Fatal(err)
+		}
+		unmarshalled := new(stateless.Witness)
+		if err := json.Unmarshal(blob, unmarshalled); err != nil {
+			t.Fatal(err)
+		}
+		for _, witness := range []*stateless.Witness{decoded, unmarshalled} {
+			if _, err := core.VerifyStateless(params.MergedTestChainConfig, vm.Config{}, block, witness); err != nil {
+				t.Fatalf("block %d: failed to verify witness: %v", block.Number(), err)
+			}
+		}
+	}
+	if _, err := api.ExecutionWitness(context.Background(), rpc.BlockNumberOrHashWithNumber(0)); err == nil {
+		t.Fatal("expected error for genesis witness")
+	}
+}
+
+// expectedState collects the accounts and storage slots of the given state by
+// iterating the tries, in the format of the state stream.
+func expectedState(t *testing.T, db *triedb.Database, root common.Hash) []*StreamAccount {
+	tr, err := trie.NewStateTrie(trie.StateTrieID(root), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var accounts []*StreamAccount
+	it := trie.NewIterator(tr.MustNodeIterator(nil))
+	for it.Next() {
+		var acc types.StateAccount
+		if err := rlp.DecodeBytes(it.Value, &acc); err != nil {
+			t.Fatal(err)
+		}
+		account := &StreamAccount{
+			Hash:     common.BytesToHash(it.Key),
+			Nonce:    hexutil.Uint64(acc.Nonce),
+			Balance:  (*hexutil.Big)(acc.Balance.ToBig()),
+			Root:     acc.Root,
+			CodeHash: common.BytesToHash(acc.CodeHash),
+		}
+		if acc.Root != types.EmptyRootHash {
+			st, err := trie.NewStateTrie(trie.StorageTrieID(root, account.Hash, acc.Root), db)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sit := trie.NewIterator(st.MustNodeIterator(nil))
+			for sit.Next() {
+				_, content, _, err := rlp.Split(sit.Value)
+				if err != nil {
+					t.Fatal(err)
+				}
+				account.Storage = append(account.Storage, StreamSlot{Hash: common.BytesToHash(sit.Key), Value: common.BytesToHash(content)})
+			}
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts
+}
+
+// streamState subscribes to the state stream and collects the chunks until
+// the stream is complete.
+func streamState(t *testing.T, client *rpc.Client, config *StateStreamConfig) []*StateChunk {
+	ch := make(chan *StateChunk)
+	sub, err := client.Subscribe(context.Background(), "debug", ch, "stateStream", rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), config)
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	var chunks []*StateChunk
+	for {
+		select {
+		case chunk := <-ch:
+			if chunk.Error != "" {
+				t.Fatalf("stream failed: %v", chunk.Error)
+			}
+			chunks = append(chunks, chunk)
+			if chunk.Next == nil {
+				return chunks
+			}
+		case err := <-sub.Err():
+			t.Fatalf("subscription failed: %v", err)
+		case <-time.After(10 * time.Second):
+			t.Fatal("state stream timed out")
+		}
+	}
+}
+
+// mergeChunks reassembles the accounts split across the chunks, leaving the
+// chunks untouched.
+func mergeChunks(chunks []*StateChunk) []*StreamAccount {
+	var accounts []*StreamAccount
+	for _, chunk := range chunks {
+		for _, account := range chunk.Accounts {
+			if n := len(accounts); n > 0 && accounts[n-1].Hash == account.Hash {
+				accounts[n-1].Storage = append(accounts[n-1].Storage, account.Storage...)
+				continue
+			}
+			cpy := *account
+			cpy.Storage = slices.Clone(account.Storage)
+			accounts = append(accounts, &cpy)
+		}
+	}
+	return accounts
+}
+
+func TestStateStream(t *testing.T) {
+	t.Parallel()
+
+	for _, scheme := range []string{rawdb.HashScheme, rawdb.PathScheme} {
+		t.Run(scheme, func(t *testing.T) {
+			testStateStream(t, scheme)
+		})
+	}
+}
+
+func testStateStream(t *testing.T, scheme string) {
+	var (
+		key, _   = crypto.GenerateKey()
+		sender   = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.HexToAddress("0xc0de")
+		engine   = beacon.New(ethash.NewFaker())
+		signer   = types.LatestSigner(params.MergedTestChainConfig)
+		genesis  = &core.Genesis{
+			Config: params.MergedTestChainConfig,
+			Alloc: types.GenesisAlloc{
+				sender: {Balance: big.NewInt(params.Ether)},
+				contract: {
+					Code:    program.New().Op(vm.NUMBER).Op(vm.NUMBER).Op(vm.SSTORE).Bytes(),
+					Storage: make(map[common.Hash]common.Hash),
+				},
+			},
+		}
+	)
+	for i := 0; i < 20; i++ {
+		genesis.Alloc[common.BigToAddress(big.NewInt(int64(0x1000+i)))] = types.Account{Balance: big.NewInt(int64(i + 1))}
+	}
+	for i := 0; i < 50; i++ {
+		genesis.Alloc[contract].Storage[common.BigToHash(big.NewInt(int64(0x100+i)))] = common.BigToHash(big.NewInt(int64(i + 1)))
+	}
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, engine, 4, func(i int, b *core.BlockGen) {
+		b.SetPoS()
+		tx, _ := types.SignNewTx(key, signer, &types.LegacyTx{Nonce: b.TxNonce(sender), To: &contract, Gas: 100_000, GasPrice: b.BaseFee()})
+		b.AddTx(tx)
+	})
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), core.DefaultCacheConfigWithScheme(scheme), genesis, nil, engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	eth := &Ethereum{blockchain: chain}
+	eth.APIBackend = &EthAPIBackend{eth: eth}
+
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("debug", NewDebugAPI(eth)); err != nil {
+		t.Fatal(err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	want := expectedState(t, chain.TrieDB(), chain.CurrentBlock().Root)
+
+	// The whole state in a single chunk
+	chunks := streamState(t, client, nil)
+	if len(chunks) != 1 {
+		t.Fatalf("unexpected number of chunks, want 1, got %d", len(chunks))
+	}
+	if chunks[0].Root != chain.CurrentBlock().Root {
+		t.Fatalf("unexpected root, want %x, got %x", chain.CurrentBlock().Root, chunks[0].Root)
+	}
+	if got := mergeChunks(chunks); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected state, want %s, got %s", dumper.Sdump(want), dumper.Sdump(got))
+	}
+	// The state split into small chunks, with the storage spanning multiple
+	// chunks. Every chunk must respect the limit.
+	chunks = streamState(t, client, &StateStreamConfig{ChunkSize: 7})
+	for i, chunk := range chunks {
+		items := len(chunk.Accounts)
+		for _, account := range chunk.Accounts {
+			items += len(account.Storage)
+		}
+		if items > 7 {
+			t.Fatalf("chunk %d: too many items %d", i, items)
+		}
+	}
+	if got := mergeChunks(chunks); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected chunked state, want %s, got %s", dumper.Sdump(want), dumper.Sdump(got))
+	}
+	// Resuming from any cursor must deliver the same remaining chunks
+	for i := 0; i < len(chunks)-1; i++ {
+		resumed := streamState(t, client, &StateStreamConfig{ChunkSize: 7, Start: chunks[i].Next})
+		if !reflect.DeepEqual(resumed, chunks[i+1:]) {
+			t.Fatalf("chunk %d: unexpected resumed stream, want %s, got %s", i, dumper.Sdump(chunks[i+1:]), dumper.Sdump(resumed))
+		}
+	}
+	// Skipping the storage
+	chunks = streamState(t, client, &StateStreamConfig{NoStorage: true})
+	got := mergeChunks(chunks)
+	if len(got) != len(want) {
+		t.Fatalf("unexpected number of accounts, want %d, got %d", len(want), len(got))
+	}
+	for _, account := range got {
+		if len(account.Storage) != 0 {
+			t.Fatalf("unexpected storage of account %x", account.Hash)
+		}
+	}
+}
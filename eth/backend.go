@@ -75,7 +75,8 @@ type Ethereum struct {
 	discmix *enode.FairMix
 
 	// DB interfaces
-	chainDb ethdb.Database // Block chain database
+	chainDb     ethdb.Database       // Block chain database
+	statePruner *pruner.OnlinePruner // Background pruner of the stale state
 
 	eventMux       *event.TypeMux
 	engine         consensus.Engine
@@ -195,6 +196,7 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 			SnapshotLimit:       config.SnapshotCache,
 			Preimages:           config.Preimages,
 			StateHistory:        config.StateHistory,
+			StateIndex:          config.StateIndex,
 			StateScheme:         scheme,
 		}
 	)
@@ -222,6 +224,7 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		return nil, err
 	}
 	eth.bloomIndexer.Start(eth.blockchain)
+	eth.statePruner = pruner.NewOnlinePruner(chainDb, eth.blockchain.TrieDB(), eth.blockchain.Snapshots())
 
 	if config.BlobPool.Datadir != "" {
 		config.BlobPool.Datadir = stack.ResolvePath(config.BlobPool.Datadir)
@@ -231,12 +234,20 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = stack.ResolvePath(config.TxPool.Journal)
 	}
+	if config.TxPool.Snapshot != "" {
+		config.TxPool.Snapshot = stack.ResolvePath(config.TxPool.Snapshot)
+	}
 	legacyPool := legacypool.New(config.TxPool, eth.blockchain)
 
 	eth.txPool, err = txpool.New(config.TxPool.PriceLimit, eth.blockchain, []txpool.SubPool{legacyPool, blobPool})
 	if err != nil {
 		return nil, err
 	}
+	// Private transactions must not be restored as public ones after a restart,
+	// neither from the local journal nor from the pool snapshot
+	legacyPool.SetPersistFilter(func(tx *types.Transaction) bool {
+		return !eth.txPool.IsPrivate(tx.Hash())
+	})
 	// Permit the downloader to use the trie cache allowance during fast sync
 	cacheLimit := cacheConfig.TrieCleanLimit + cacheConfig.TrieDirtyLimit + cacheConfig.SnapshotLimit
 	if eth.handler, err = newHandler(&handlerConfig{
@@ -410,6 +421,7 @@ func (s *Ethereum) Stop() error {
 	s.bloomIndexer.Close()
 	close(s.closeBloomHandler)
 	s.txPool.Close()
+	s.statePruner.Stop()
 	s.blockchain.Stop()
 	s.engine.Close()
 
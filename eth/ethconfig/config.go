@@ -66,6 +66,7 @@ var Defaults = Config{
 	RPCEVMTimeout:      5 * time.Second,
 	GPO:                FullNodeGPO,
 	RPCTxFeeCap:        1, // 1 ether
+	PrivateTxLifetime:  25,
 }
 
 //go:generate go run github.com/fjl/gencodec -type Config -formats toml -out gen_config.go
@@ -95,6 +96,7 @@ type Config struct {
 
 	TransactionHistory uint64 `toml:",omitempty"` // The maximum number of blocks from head whose tx indices are reserved.
 	StateHistory       uint64 `toml:",omitempty"` // The maximum number of blocks from head whose state histories are reserved.
+	StateIndex         bool   `toml:",omitempty"` // Whether to index the state histories for historical state access (path scheme only)
 
 	// State scheme represents the scheme used to store ethereum states and trie
 	// nodes on top. It can be 'hash', 'path', or none which means use the scheme
@@ -128,6 +130,10 @@ type Config struct {
 	TxPool   legacypool.Config
 	BlobPool blobpool.Config
 
+	// PrivateTxLifetime is the number of blocks a privately submitted transaction
+	// is kept in the pool for inclusion, before being dropped.
+	PrivateTxLifetime uint64
+
 	// Gas Price Oracle options
 	GPO gasprice.Config
 
@@ -28,6 +28,7 @@ import (
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -190,6 +191,60 @@ func (api *FilterAPI) NewPendingTransactions(ctx context.Context, fullTx *bool)
 	return rpcSub, nil
 }
 
+// TxPoolEvent is the JSON representation of a transaction lifecycle event in
+// the transaction pool.
+type TxPoolEvent struct {
+	Type       txpool.TxEventType `json:"type"`
+	Hash       common.Hash        `json:"hash"`
+	From       common.Address     `json:"from"`
+	Nonce      hexutil.Uint64     `json:"nonce"`
+	ReplacedBy *common.Hash       `json:"replacedBy,omitempty"`
+	Reason     txpool.DropReason  `json:"reason,omitempty"`
+}
+
+// TxpoolEvents creates a subscription that is triggered each time a transaction
+// enters the transaction pool, is promoted to or demoted from the executable
+// set, or is replaced or dropped.
+func (api *FilterAPI) TxpoolEvents(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		eventsCh := make(chan []txpool.TxEvent, 128)
+		eventsSub := api.sys.backend.SubscribeTxPoolEvents(eventsCh)
+		defer eventsSub.Unsubscribe()
+
+		for {
+			select {
+			case events := <-eventsCh:
+				for _, ev := range events {
+					res := &TxPoolEvent{
+						Type:   ev.Type,
+						Hash:   ev.Hash,
+						From:   ev.From,
+						Nonce:  hexutil.Uint64(ev.Nonce),
+						Reason: ev.Reason,
+					}
+					if ev.Type == txpool.TxReplaced {
+						res.ReplacedBy = &ev.ReplacedBy
+					}
+					notifier.Notify(rpcSub.ID, res)
+				}
+			case <-eventsSub.Err():
+				return
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
 // NewBlockFilter creates a filter that fetches blocks that are imported into the chain.
 // It is part of the filter package since polling goes with eth_getFilterChanges.
 func (api *FilterAPI) NewBlockFilter() rpc.ID {
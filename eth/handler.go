@@ -70,6 +70,10 @@ type txPool interface {
 	// Add should add the given transactions to the pool.
 	Add(txs []*types.Transaction, local bool, sync bool) []error
 
+	// IsPrivate returns whether the transaction with the given hash was submitted
+	// privately, and must not be propagated to the network.
+	IsPrivate(hash common.Hash) bool
+
 	// Pending should return pending transactions.
 	// The slice should be modifiable by the caller.
 	Pending(filter txpool.PendingFilter) map[common.Address][]*txpool.LazyTransaction
@@ -482,6 +486,10 @@ func (h *handler) BroadcastTransactions(txs types.Transactions) {
 		hash   = make([]byte, 32)
 	)
 	for _, tx := range txs {
+		// Never propagate the privately submitted transactions
+		if h.txpool.IsPrivate(tx.Hash()) {
+			continue
+		}
 		var maybeDirect bool
 		switch {
 		case tx.Type() == types.BlobTxType:
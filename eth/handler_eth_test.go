@@ -436,3 +436,99 @@ func testTransactionPropagation(t *testing.T, protocol uint) {
 		}
 	}
 }
+
+// Tests that privately submitted transactions are neither propagated to the
+// peers, nor served to them upon request.
+func TestPrivateTransactionPropagation68(t *testing.T) {
+	testPrivateTransactionPropagation(t, eth.ETH68)
+}
+
+func testPrivateTransactionPropagation(t *testing.T, protocol uint) {
+	t.Parallel()
+
+	source := newTestHandler()
+	source.handler.snapSync.Store(false) // Avoid requiring snap, otherwise some will be dropped below
+	defer source.close()
+
+	// Create a batch of private and public transactions, half of them are added
+	// before the peers connect to be synced, the rest afterwards to be broadcast.
+	var (
+		private = make([]*types.Transaction, 32)
+		public  = make([]*types.Transaction, 32)
+	)
+	for i := range private {
+		tx := types.NewTransaction(uint64(i), common.Address{}, big.NewInt(0), 100000, big.NewInt(0), nil)
+		private[i], _ = types.SignTx(tx, types.HomesteadSigner{}, testKey)
+
+		tx = types.NewTransaction(uint64(i), common.Address{0x01}, big.NewInt(0), 100000, big.NewInt(0), nil)
+		public[i], _ = types.SignTx(tx, types.HomesteadSigner{}, testKey)
+	}
+	source.txpool.AddPrivate(private[:16])
+	source.txpool.Add(public[:16], false, false)
+
+	for _, tx := range private {
+		if (*ethHandler)(source.handler).TxPool().Get(tx.Hash()) != nil {
+			t.Fatalf("private transaction %x served to peers", tx.Hash())
+		}
+	}
+	sinks := make([]*testHandler, 4)
+	for i := 0; i < len(sinks); i++ {
+		sinks[i] = newTestHandler()
+		defer sinks[i].close()
+
+		sinks[i].handler.synced.Store(true) // mark synced to accept transactions
+	}
+	txChs := make([]chan core.NewTxsEvent, len(sinks))
+	for i := 0; i < len(sinks); i++ {
+		txChs[i] = make(chan core.NewTxsEvent, 1024)
+
+		sub := sinks[i].txpool.SubscribeTransactions(txChs[i], false)
+		defer sub.Unsubscribe()
+	}
+	for i, sink := range sinks {
+		sourcePipe, sinkPipe := p2p.MsgPipe()
+		defer sourcePipe.Close()
+		defer sinkPipe.Close()
+
+		sourcePeer := eth.NewPeer(protocol, p2p.NewPeerPipe(enode.ID{byte(i + 1)}, "", nil, sourcePipe), sourcePipe, (*ethHandler)(source.handler).TxPool())
+		sinkPeer := eth.NewPeer(protocol, p2p.NewPeerPipe(enode.ID{0}, "", nil, sinkPipe), sinkPipe, sink.txpool)
+		defer sourcePeer.Close()
+		defer sinkPeer.Close()
+
+		go source.handler.runEthPeer(sourcePeer, func(peer *eth.Peer) error {
+			return eth.Handle((*ethHandler)(source.handler), peer)
+		})
+		go sink.handler.runEthPeer(sinkPeer, func(peer *eth.Peer) error {
+			return eth.Handle((*ethHandler)(sink.handler), peer)
+		})
+	}
+	go func() {
+		source.txpool.AddPrivate(private[16:])
+		source.txpool.Add(public[16:], false, false)
+	}()
+	// Wait for all the public transactions at the sinks, then give some time
+	// for any private transaction leaking.
+	for i := range sinks {
+		for arrived, timeout := 0, false; arrived < len(public) && !timeout; {
+			select {
+			case event := <-txChs[i]:
+				arrived += len(event.Txs)
+			case <-time.After(2 * time.Second):
+				t.Fatalf("sink %d: transaction propagation timed out: have %d, want %d", i, arrived, len(public))
+			}
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+	for i, sink := range sinks {
+		for _, tx := range private {
+			if sink.txpool.Has(tx.Hash()) {
+				t.Errorf("sink %d: private transaction %x propagated", i, tx.Hash())
+			}
+		}
+		for _, tx := range public {
+			if !sink.txpool.Has(tx.Hash()) {
+				t.Errorf("sink %d: public transaction %x missing", i, tx.Hash())
+			}
+		}
+	}
+}
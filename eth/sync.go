@@ -22,12 +22,15 @@ import (
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
 )
 
-// syncTransactions starts sending all currently pending transactions to the given peer.
+// syncTransactions starts sending all currently pending transactions to the given peer,
+// except the private ones.
 func (h *handler) syncTransactions(p *eth.Peer) {
 	var hashes []common.Hash
 	for _, batch := range h.txpool.Pending(txpool.PendingFilter{OnlyPlainTxs: true}) {
 		for _, tx := range batch {
-			hashes = append(hashes, tx.Hash)
+			if !h.txpool.IsPrivate(tx.Hash) {
+				hashes = append(hashes, tx.Hash)
+			}
 		}
 	}
 	if len(hashes) == 0 {
@@ -0,0 +1,609 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/internal/ethapi/override"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/holiman/uint256"
+)
+
+const (
+	// defaultDebugSessionTimeout is the amount of time a debug session is kept
+	// alive without any request from the client.
+	defaultDebugSessionTimeout = 5 * time.Minute
+
+	// maxDebugSessions is the maximum number of concurrent debug sessions. Each
+	// session holds on to a state, and to an execution goroutine.
+	maxDebugSessions = 16
+)
+
+var (
+	errDebugSessionNotFound = errors.New("debug session not found")
+	errDebugSessionFinished = errors.New("debug session finished executing")
+	errTooManyDebugSessions = errors.New("too many debug sessions")
+	errDebugSessionAborted  = errors.New("debug session aborted")
+)
+
+// DebugBreakpoint pauses the execution before an instruction matching all of
+// its set fields.
+type DebugBreakpoint struct {
+	PC      *uint64         `json:"pc"`      // Program counter
+	Op      string          `json:"op"`      // Opcode name, e.g. SSTORE
+	Address *common.Address `json:"address"` // Address of the executing contract
+	Depth   *int            `json:"depth"`   // Call depth, starting at 1
+	Slot    *common.Hash    `json:"slot"`    // Storage slot accessed by SLOAD or SSTORE
+}
+
+// validate checks that the breakpoint can be matched.
+func (b *DebugBreakpoint) validate() error {
+	if b.PC == nil && b.Op == "" && b.Address == nil && b.Depth == nil && b.Slot == nil {
+		return errors.New("empty breakpoint")
+	}
+	if b.Op != "" && vm.StringToOp(b.Op).String() != b.Op {
+		return fmt.Errorf("unknown opcode %q", b.Op)
+	}
+	return nil
+}
+
+// matches reports whether the breakpoint is hit by the instruction about to be
+// executed.
+func (b *DebugBreakpoint) matches(pc uint64, op vm.OpCode, addr common.Address, depth int, stack []uint256.Int) bool {
+	if b.PC != nil && *b.PC != pc {
+		return false
+	}
+	if b.Op != "" && b.Op != op.String() {
+		return false
+	}
+	if b.Address != nil && *b.Address != addr {
+		return false
+	}
+	if b.Depth != nil && *b.Depth != depth {
+		return false
+	}
+	if b.Slot != nil {
+		if (op != vm.SLOAD && op != vm.SSTORE) || len(stack) == 0 {
+			return false
+		}
+		if common.Hash(stack[len(stack)-1].Bytes32()) != *b.Slot {
+			return false
+		}
+	}
+	return true
+}
+
+// DebugConfig holds the parameters of a debug session.
+type DebugConfig struct {
+	Breakpoints []DebugBreakpoint
+	StopOnEntry bool    // Pause before the first instruction
+	Timeout     *string // Lifetime of the session without any request
+	Reexec      *uint64
+}
+
+// DebugCallConfig holds the parameters of a debug session executing a call. It
+// holds additional fields to override the state and the block.
+type DebugCallConfig struct {
+	DebugConfig
+	StateOverrides *override.StateOverride
+	BlockOverrides *override.BlockOverrides
+	TxIndex        *hexutil.Uint
+}
+
+// DebugState describes the instruction the execution is paused at, or the
+// result of the execution once it is finished.
+type DebugState struct {
+	PC         uint64         `json:"pc"`
+	Op         string         `json:"op"`
+	Depth      int            `json:"depth"`
+	Address    common.Address `json:"address"`
+	Gas        uint64         `json:"gas"`
+	GasCost    uint64         `json:"gasCost"`
+	Breakpoint *int           `json:"breakpoint,omitempty"` // Index of the breakpoint hit
+	Done       bool           `json:"done"`
+	Result     *DebugResult   `json:"result,omitempty"`
+}
+
+// DebugResult is the outcome of the execution of a debug session.
+type DebugResult struct {
+	Gas         uint64        `json:"gas"`
+	Failed      bool          `json:"failed"`
+	ReturnValue hexutil.Bytes `json:"returnValue"`
+	Error       string        `json:"error,omitempty"`
+}
+
+// DebugSession is returned when a debug session is started.
+type DebugSession struct {
+	ID    string      `json:"id"`
+	State *DebugState `json:"state"`
+}
+
+type debugCommandKind int
+
+const (
+	debugStep debugCommandKind = iota
+	debugContinue
+	debugInspect
+	debugAbort
+)
+
+// debugCommand is sent by the client to the paused execution.
+type debugCommand struct {
+	kind    debugCommandKind
+	inspect func(scope tracing.OpContext, rData []byte) // Run by the execution goroutine while paused
+	done    chan struct{}
+}
+
+// debugSession runs an execution in a background goroutine, which blocks in
+// the opcode hook while the execution is paused. All accesses to the EVM and
+// the state happen on this goroutine, the client interacts with it through
+// commands.
+type debugSession struct {
+	id      string
+	evm     *vm.EVM
+	timeout time.Duration
+	timer   *time.Timer
+
+	lock sync.Mutex  // Serializes the requests of the client
+	last *DebugState // Last state reported to the client
+
+	cmds   chan *debugCommand
+	events chan *DebugState
+	done   chan struct{}
+
+	// Fields only accessed by the execution goroutine
+	breakpoints []DebugBreakpoint
+	stepping    bool
+	aborted     bool
+}
+
+// onOpcode pauses the execution if stepping or if a breakpoint is hit, and
+// serves the commands of the client until the execution is resumed.
+func (s *debugSession) onOpcode(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	if s.aborted {
+		return
+	}
+	hit := -1
+	for i := range s.breakpoints {
+		if s.breakpoints[i].matches(pc, vm.OpCode(op), scope.Address(), depth, scope.StackData()) {
+			hit = i
+			break
+		}
+	}
+	if !s.stepping && hit < 0 {
+		return
+	}
+	state := &DebugState{
+		PC:      pc,
+		Op:      vm.OpCode(op).String(),
+		Depth:   depth,
+		Address: scope.Address(),
+		Gas:     gas,
+		GasCost: cost,
+	}
+	if hit >= 0 {
+		state.Breakpoint = &hit
+	}
+	s.events <- state
+
+	for cmd := range s.cmds {
+		switch cmd.kind {
+		case debugInspect:
+			cmd.inspect(scope, rData)
+			close(cmd.done)
+		case debugStep:
+			s.stepping = true
+			return
+		case debugContinue:
+			s.stepping = false
+			return
+		case debugAbort:
+			s.aborted = true
+			s.evm.Cancel()
+			return
+		}
+	}
+}
+
+// run executes the session in the background, reporting the result once done.
+func (s *debugSession) run(execute func() (*core.ExecutionResult, error), release StateReleaseFunc) {
+	defer close(s.done)
+	defer release()
+
+	state := &DebugState{Done: true, Result: &DebugResult{}}
+	res, err := execute()
+	switch {
+	case err != nil:
+		state.Result.Failed = true
+		state.Result.Error = err.Error()
+	case s.aborted:
+		state.Result.Failed = true
+		state.Result.Error = "execution aborted"
+	default:
+		state.Result.Gas = res.UsedGas
+		state.Result.Failed = res.Failed()
+		state.Result.ReturnValue = common.CopyBytes(res.ReturnData)
+		if res.Err != nil {
+			state.Result.Error = res.Err.Error()
+		}
+	}
+	s.events <- state
+}
+
+// wait waits for the execution to pause or finish.
+func (s *debugSession) wait(ctx context.Context) (*DebugState, error) {
+	select {
+	case state := <-s.events:
+		s.last = state
+		return state, nil
+	case <-s.done:
+		// The final state is delivered before termination, unless it was
+		// discarded by a concurrent stop.
+		select {
+		case state := <-s.events:
+			s.last = state
+			return state, nil
+		default:
+			return nil, errDebugSessionNotFound
+		}
+	case <-ctx.Done():
+		// The client is gone, and the session left in an unknown state.
+		s.stop()
+		return nil, fmt.Errorf("%w: %w", errDebugSessionAborted, ctx.Err())
+	}
+}
+
+// resume sends a step or continue command, and waits for the execution to
+// pause again.
+func (s *debugSession) resume(ctx context.Context, kind debugCommandKind) (*DebugState, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.last.Done {
+		return s.last, nil
+	}
+	select {
+	case s.cmds <- &debugCommand{kind: kind}:
+	case <-s.done:
+	}
+	return s.wait(ctx)
+}
+
+// inspect runs the given function on the paused execution.
+func (s *debugSession) inspect(fn func(scope tracing.OpContext, rData []byte)) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.last.Done {
+		return errDebugSessionFinished
+	}
+	cmd := &debugCommand{kind: debugInspect, inspect: fn, done: make(chan struct{})}
+	select {
+	case s.cmds <- cmd:
+	case <-s.done:
+		return errDebugSessionFinished
+	}
+	<-cmd.done
+	return nil
+}
+
+// stop aborts the execution if it's still running, and waits for it to
+// terminate.
+func (s *debugSession) stop() {
+	s.evm.Cancel()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.events:
+		case s.cmds <- &debugCommand{kind: debugAbort}:
+		}
+	}
+}
+
+// DebuggerAPI provides interactive debug sessions, executing transactions or
+// calls instruction by instruction.
+type DebuggerAPI struct {
+	api *API
+
+	lock     sync.Mutex
+	sessions map[string]*debugSession
+}
+
+// NewDebuggerAPI creates a new API definition for the debug sessions.
+func NewDebuggerAPI(backend Backend) *DebuggerAPI {
+	return &DebuggerAPI{
+		api:      NewAPI(backend),
+		sessions: make(map[string]*debugSession),
+	}
+}
+
+// StartTransactionSession starts a debug session replaying the given
+// transaction, and runs it until the first pause.
+func (d *DebuggerAPI) StartTransactionSession(ctx context.Context, hash common.Hash, config *DebugConfig) (*DebugSession, error) {
+	if config == nil {
+		config = &DebugConfig{}
+	}
+	found, _, blockHash, blockNumber, index, err := d.api.backend.GetTransaction(ctx, hash)
+	if err != nil {
+		return nil, ethapi.NewTxIndexingError()
+	}
+	// Only mined txes are supported
+	if !found {
+		return nil, errTxNotFound
+	}
+	if blockNumber == 0 {
+		return nil, errors.New("genesis is not traceable")
+	}
+	reexec := defaultTraceReexec
+	if config.Reexec != nil {
+		reexec = *config.Reexec
+	}
+	block, err := d.api.blockByNumberAndHash(ctx, rpc.BlockNumber(blockNumber), blockHash)
+	if err != nil {
+		return nil, err
+	}
+	tx, vmctx, statedb, release, err := d.api.backend.StateAtTransaction(ctx, block, int(index), reexec)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := core.TransactionToMessage(tx, types.MakeSigner(d.api.backend.ChainConfig(), block.Number(), block.Time()), block.BaseFee())
+	if err != nil {
+		release()
+		return nil, err
+	}
+	statedb.SetTxContext(hash, int(index))
+	return d.start(ctx, config, msg, vmctx, statedb, release)
+}
+
+// StartCallSession starts a debug session executing the given call on top of
+// the given block, and runs it until the first pause.
+func (d *DebuggerAPI) StartCallSession(ctx context.Context, args ethapi.TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, config *DebugCallConfig) (*DebugSession, error) {
+	if config == nil {
+		config = &DebugCallConfig{}
+	}
+	callConfig := &TraceCallConfig{
+		TraceConfig:    TraceConfig{Reexec: config.Reexec},
+		StateOverrides: config.StateOverrides,
+		BlockOverrides: config.BlockOverrides,
+		TxIndex:        config.TxIndex,
+	}
+	// The chain context is used during the whole session, not only during
+	// this request.
+	msg, _, vmctx, statedb, release, err := d.api.prepareCall(context.WithoutCancel(ctx), args, blockNrOrHash, callConfig)
+	if err != nil {
+		return nil, err
+	}
+	return d.start(ctx, &config.DebugConfig, msg, vmctx, statedb, release)
+}
+
+// start creates a session executing the message, and waits for it to pause.
+func (d *DebuggerAPI) start(ctx context.Context, config *DebugConfig, msg *core.Message, vmctx vm.BlockContext, statedb *state.StateDB, release StateReleaseFunc) (*DebugSession, error) {
+	for i := range config.Breakpoints {
+		if err := config.Breakpoints[i].validate(); err != nil {
+			release()
+			return nil, fmt.Errorf("breakpoint %d: %v", i, err)
+		}
+	}
+	timeout := defaultDebugSessionTimeout
+	if config.Timeout != nil {
+		var err error
+		if timeout, err = time.ParseDuration(*config.Timeout); err != nil {
+			release()
+			return nil, err
+		}
+	}
+	s := &debugSession{
+		id:          string(rpc.NewID()),
+		timeout:     timeout,
+		cmds:        make(chan *debugCommand),
+		events:      make(chan *DebugState, 1),
+		done:        make(chan struct{}),
+		breakpoints: config.Breakpoints,
+		stepping:    config.StopOnEntry,
+	}
+	s.evm = vm.NewEVM(vmctx, statedb, d.api.backend.ChainConfig(), vm.Config{
+		Tracer:    &tracing.Hooks{OnOpcode: s.onOpcode},
+		NoBaseFee: true,
+	})
+	d.lock.Lock()
+	if len(d.sessions) >= maxDebugSessions {
+		d.lock.Unlock()
+		release()
+		return nil, errTooManyDebugSessions
+	}
+	d.sessions[s.id] = s
+	s.timer = time.AfterFunc(timeout, func() {
+		log.Debug("Debug session timed out", "id", s.id)
+		d.StopSession(s.id)
+	})
+	d.lock.Unlock()
+
+	go s.run(func() (*core.ExecutionResult, error) {
+		return core.ApplyMessage(s.evm, msg, new(core.GasPool).AddGas(msg.GasLimit))
+	}, release)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	state, err := s.wait(ctx)
+	if err != nil {
+		d.remove(s.id)
+		return nil, err
+	}
+	return &DebugSession{ID: s.id, State: state}, nil
+}
+
+// resume sends a step or continue command to a session, dropping the session
+// if it was aborted meanwhile.
+func (d *DebuggerAPI) resume(ctx context.Context, id string, kind debugCommandKind) (*DebugState, error) {
+	s, err := d.session(id)
+	if err != nil {
+		return nil, err
+	}
+	state, err := s.resume(ctx, kind)
+	if errors.Is(err, errDebugSessionAborted) {
+		d.remove(id)
+	}
+	return state, err
+}
+
+// session retrieves a session, extending its lifetime.
+func (d *DebuggerAPI) session(id string) (*debugSession, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	s, ok := d.sessions[id]
+	if !ok {
+		return nil, errDebugSessionNotFound
+	}
+	s.timer.Reset(s.timeout)
+	return s, nil
+}
+
+// remove deletes a session without stopping it.
+func (d *DebuggerAPI) remove(id string) *debugSession {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	s := d.sessions[id]
+	if s != nil {
+		s.timer.Stop()
+		delete(d.sessions, id)
+	}
+	return s
+}
+
+// SessionStep executes the next instruction, and pauses before the following
+// one, which might be in another call frame.
+func (d *DebuggerAPI) SessionStep(ctx context.Context, id string) (*DebugState, error) {
+	return d.resume(ctx, id, debugStep)
+}
+
+// SessionContinue resumes the execution until the next breakpoint is hit, or
+// the execution finishes.
+func (d *DebuggerAPI) SessionContinue(ctx context.Context, id string) (*DebugState, error) {
+	return d.resume(ctx, id, debugContinue)
+}
+
+// SessionSetBreakpoints replaces the breakpoints of a paused session.
+func (d *DebuggerAPI) SessionSetBreakpoints(id string, breakpoints []DebugBreakpoint) error {
+	for i := range breakpoints {
+		if err := breakpoints[i].validate(); err != nil {
+			return fmt.Errorf("breakpoint %d: %v", i, err)
+		}
+	}
+	s, err := d.session(id)
+	if err != nil {
+		return err
+	}
+	return s.inspect(func(tracing.OpContext, []byte) {
+		s.breakpoints = breakpoints
+	})
+}
+
+// SessionStack returns the stack of a paused session, the top of the stack
+// being the last element.
+func (d *DebuggerAPI) SessionStack(id string) ([]hexutil.U256, error) {
+	s, err := d.session(id)
+	if err != nil {
+		return nil, err
+	}
+	var stack []hexutil.U256
+	err = s.inspect(func(scope tracing.OpContext, _ []byte) {
+		stack = make([]hexutil.U256, len(scope.StackData()))
+		for i, item := range scope.StackData() {
+			stack[i] = hexutil.U256(item)
+		}
+	})
+	return stack, err
+}
+
+// SessionMemory returns a region of the memory of a paused session. The region
+// is truncated to the current size of the memory.
+func (d *DebuggerAPI) SessionMemory(id string, offset hexutil.Uint64, length hexutil.Uint64) (hexutil.Bytes, error) {
+	s, err := d.session(id)
+	if err != nil {
+		return nil, err
+	}
+	var data hexutil.Bytes
+	err = s.inspect(func(scope tracing.OpContext, _ []byte) {
+		memory := scope.MemoryData()
+		start := min(uint64(offset), uint64(len(memory)))
+		end := min(start+uint64(length), uint64(len(memory)))
+		if end < start { // overflow
+			end = uint64(len(memory))
+		}
+		data = common.CopyBytes(memory[start:end])
+	})
+	return data, err
+}
+
+// SessionStorage returns the value of a storage slot of a paused session. If
+// no address is given, the storage of the executing contract is read.
+func (d *DebuggerAPI) SessionStorage(id string, slot common.Hash, address *common.Address) (common.Hash, error) {
+	s, err := d.session(id)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	var value common.Hash
+	err = s.inspect(func(scope tracing.OpContext, _ []byte) {
+		addr := scope.Address()
+		if address != nil {
+			addr = *address
+		}
+		value = s.evm.StateDB.GetState(addr, slot)
+	})
+	return value, err
+}
+
+// SessionReturnData returns the data returned by the last call of the paused
+// call frame.
+func (d *DebuggerAPI) SessionReturnData(id string) (hexutil.Bytes, error) {
+	s, err := d.session(id)
+	if err != nil {
+		return nil, err
+	}
+	var data hexutil.Bytes
+	err = s.inspect(func(_ tracing.OpContext, rData []byte) {
+		data = common.CopyBytes(rData)
+	})
+	return data, err
+}
+
+// StopSession aborts the execution of a session, and releases its resources.
+func (d *DebuggerAPI) StopSession(id string) error {
+	s := d.remove(id)
+	if s == nil {
+		return errDebugSessionNotFound
+	}
+	s.stop()
+	return nil
+}
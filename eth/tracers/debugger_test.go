@@ -0,0 +1,226 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/program"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/holiman/uint256"
+)
+
+// newDebuggerTestAPI creates a chain with a single transaction calling a
+// contract which stores 0x2a in slot 1, and returns it from memory.
+func newDebuggerTestAPI(t *testing.T) (*DebuggerAPI, common.Address, common.Hash) {
+	var (
+		accounts = newAccounts(1)
+		contract = common.HexToAddress("0xc0de")
+		code     = program.New().
+				Sstore(1, 0x2a).
+				Push(0x2a).Push(0).Op(vm.MSTORE).
+				Return(0, 32).Bytes()
+		genesis = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+				contract:         {Code: code},
+			},
+		}
+		txHash common.Hash
+	)
+	backend := newTestBackend(t, 1, genesis, func(i int, b *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTx(&types.LegacyTx{Nonce: 0, To: &contract, Gas: 100_000, GasPrice: b.BaseFee()}), types.HomesteadSigner{}, accounts[0].key)
+		b.AddTx(tx)
+		txHash = tx.Hash()
+	})
+	t.Cleanup(backend.teardown)
+	return NewDebuggerAPI(backend), contract, txHash
+}
+
+func TestDebugSessionStep(t *testing.T) {
+	t.Parallel()
+	api, contract, txHash := newDebuggerTestAPI(t)
+	ctx := context.Background()
+
+	session, err := api.StartTransactionSession(ctx, txHash, &DebugConfig{StopOnEntry: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := session.State; s.PC != 0 || s.Op != "PUSH1" || s.Depth != 1 || s.Address != contract || s.Done {
+		t.Fatalf("wrong entry state: %+v", s)
+	}
+	state, err := api.SessionStep(ctx, session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.PC != 2 || state.Op != "PUSH1" {
+		t.Fatalf("wrong state after step: %+v", state)
+	}
+	stack, err := api.SessionStack(session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stack) != 1 || (*uint256.Int)(&stack[0]).Uint64() != 0x2a {
+		t.Fatalf("wrong stack: %v", stack)
+	}
+
+	// Run until the storage slot is written.
+	slot := common.BigToHash(big.NewInt(1))
+	if err := api.SessionSetBreakpoints(session.ID, []DebugBreakpoint{{Slot: &slot}}); err != nil {
+		t.Fatal(err)
+	}
+	if state, err = api.SessionContinue(ctx, session.ID); err != nil {
+		t.Fatal(err)
+	}
+	if state.Op != "SSTORE" || state.Breakpoint == nil || *state.Breakpoint != 0 {
+		t.Fatalf("breakpoint not hit: %+v", state)
+	}
+	if value, err := api.SessionStorage(session.ID, slot, nil); err != nil || value != (common.Hash{}) {
+		t.Fatalf("wrong storage before store: %x, %v", value, err)
+	}
+	if _, err = api.SessionStep(ctx, session.ID); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := api.SessionStorage(session.ID, slot, &contract); err != nil || value != common.BigToHash(big.NewInt(0x2a)) {
+		t.Fatalf("wrong storage after store: %x, %v", value, err)
+	}
+
+	// Run until the value is returned from memory.
+	op := "RETURN"
+	if err := api.SessionSetBreakpoints(session.ID, []DebugBreakpoint{{Op: op}}); err != nil {
+		t.Fatal(err)
+	}
+	if state, err = api.SessionContinue(ctx, session.ID); err != nil || state.Op != op {
+		t.Fatalf("breakpoint not hit: %+v, %v", state, err)
+	}
+	memory, err := api.SessionMemory(session.ID, 0, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(memory) != 32 || memory[31] != 0x2a {
+		t.Fatalf("wrong memory: %x", memory)
+	}
+	if state, err = api.SessionContinue(ctx, session.ID); err != nil {
+		t.Fatal(err)
+	}
+	if !state.Done || state.Result.Failed || common.BytesToHash(state.Result.ReturnValue) != common.BigToHash(big.NewInt(0x2a)) {
+		t.Fatalf("wrong final state: %+v", state)
+	}
+	// Inspection is not possible anymore.
+	if _, err := api.SessionStack(session.ID); !errors.Is(err, errDebugSessionFinished) {
+		t.Fatalf("wrong error inspecting finished session: %v", err)
+	}
+	if err := api.StopSession(session.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.SessionStep(ctx, session.ID); !errors.Is(err, errDebugSessionNotFound) {
+		t.Fatalf("wrong error for stopped session: %v", err)
+	}
+}
+
+func TestDebugCallSession(t *testing.T) {
+	t.Parallel()
+	api, contract, _ := newDebuggerTestAPI(t)
+	ctx := context.Background()
+
+	// Without any breakpoint, the call runs to completion.
+	args := ethapi.TransactionArgs{To: &contract}
+	session, err := api.StartCallSession(ctx, args, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !session.State.Done || len(session.State.Result.ReturnValue) != 32 {
+		t.Fatalf("wrong final state: %+v", session.State)
+	}
+	api.StopSession(session.ID)
+
+	// Stopping a paused session aborts the execution.
+	pc := uint64(4)
+	config := &DebugCallConfig{DebugConfig: DebugConfig{Breakpoints: []DebugBreakpoint{{PC: &pc}}}}
+	session, err = api.StartCallSession(ctx, args, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.State.PC != pc || session.State.Op != "SSTORE" {
+		t.Fatalf("breakpoint not hit: %+v", session.State)
+	}
+	if err := api.StopSession(session.ID); err != nil {
+		t.Fatal(err)
+	}
+	if len(api.sessions) != 0 {
+		t.Fatalf("sessions not removed: %d", len(api.sessions))
+	}
+}
+
+func TestDebugSessionTimeout(t *testing.T) {
+	t.Parallel()
+	api, _, txHash := newDebuggerTestAPI(t)
+	ctx := context.Background()
+
+	timeout := "50ms"
+	session, err := api.StartTransactionSession(ctx, txHash, &DebugConfig{StopOnEntry: true, Timeout: &timeout})
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if _, err := api.SessionStep(ctx, session.ID); !errors.Is(err, errDebugSessionNotFound) {
+		t.Fatalf("session not collected: %v", err)
+	}
+	// Invalid breakpoints are rejected.
+	if _, err := api.StartTransactionSession(ctx, txHash, &DebugConfig{Breakpoints: []DebugBreakpoint{{}}}); err == nil {
+		t.Fatal("expected error for empty breakpoint")
+	}
+	if _, err := api.StartTransactionSession(ctx, txHash, &DebugConfig{Breakpoints: []DebugBreakpoint{{Op: "FOO"}}}); err == nil {
+		t.Fatal("expected error for unknown opcode")
+	}
+}
+
+func TestDebugSessionCancel(t *testing.T) {
+	t.Parallel()
+	api, _, _ := newDebuggerTestAPI(t)
+
+	// Start a paused session deploying a contract looping forever.
+	code, dest := program.New().Jumpdest()
+	loop := hexutil.Bytes(code.Jump(dest).Bytes())
+	args := ethapi.TransactionArgs{Input: &loop}
+	session, err := api.StartCallSession(context.Background(), args, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), &DebugCallConfig{DebugConfig: DebugConfig{StopOnEntry: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A client going away while the execution runs aborts the session, which
+	// must release its slot.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := api.SessionContinue(ctx, session.ID); !errors.Is(err, context.Canceled) {
+		t.Fatalf("wrong error for cancelled request: %v", err)
+	}
+	if len(api.sessions) != 0 {
+		t.Fatalf("aborted session not removed: %d", len(api.sessions))
+	}
+}
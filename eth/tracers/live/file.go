@@ -0,0 +1,471 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package live
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+func init() {
+	tracers.LiveDirectory.Register("file", newFileTracer)
+}
+
+// fileTraceName is the name of the file the block traces are written to,
+// rotated files are suffixed with the time of the rotation.
+const fileTraceName = "traces.jsonl"
+
+// fileResumeBlocks is the number of blocks at the end of the trace file which
+// are remembered on startup, to avoid tracing them again when re-imported.
+const fileResumeBlocks = 1024
+
+// fileBlockTraces is a line of the trace file, containing the results of the
+// wrapped tracer for all transactions in a block.
+type fileBlockTraces struct {
+	Number uint64         `json:"blockNumber"`
+	Hash   common.Hash    `json:"blockHash"`
+	Traces []*fileTxTrace `json:"traces"`
+}
+
+// fileTxTrace is the trace result of a single transaction.
+type fileTxTrace struct {
+	TxHash common.Hash     `json:"txHash"`           // transaction hash
+	Result json.RawMessage `json:"result,omitempty"` // Trace results produced by the tracer
+	Error  string          `json:"error,omitempty"`  // Trace failure produced by the tracer
+}
+
+type fileTracerConfig struct {
+	Path           string          `json:"path"`           // Path to the directory where the traces will be stored
+	Tracer         string          `json:"tracer"`         // Name of the native tracer to run on each transaction
+	TracerConfig   json.RawMessage `json:"tracerConfig"`   // Config passed to the native tracer
+	MaxSize        int             `json:"maxSize"`        // MaxSize is the maximum size in megabytes of the trace file before it gets rotated. It defaults to 100 megabytes.
+	MaxBackups     int             `json:"maxBackups"`     // MaxBackups is the maximum number of rotated files to retain, zero retains all of them.
+	RotateInterval string          `json:"rotateInterval"` // RotateInterval is the duration (e.g. "1h") after which the trace file is rotated regardless of its size.
+	Compress       bool            `json:"compress"`       // Compress determines whether rotated files are gzipped.
+}
+
+// fileTracer runs a native tracer on every transaction of the imported blocks,
+// and appends the results of each block as a single line to a rotating file.
+//
+// Since blocks are written atomically from the point of view of a reader, a
+// partially written line can only be left behind by a crash. It is removed on
+// startup, and the last blocks fully written are not traced again when they
+// are re-imported. Blocks are identified by hash, so the ones of a different
+// chain are traced regardless, while re-imported blocks older than the ones
+// remembered are traced again.
+type fileTracer struct {
+	name        string
+	config      json.RawMessage
+	chainConfig *params.ChainConfig
+	logger      *lumberjack.Logger
+
+	interval   time.Duration
+	lastRotate time.Time
+
+	resume  map[uint64]common.Hash // Last blocks written before a restart, nil once past them
+	last    uint64                 // Number of the last block written before a restart
+	block   *fileBlockTraces       // Traces of the block being imported, nil if not traced
+	tx      *tracers.Tracer        // Tracer of the transaction being executed
+	txHash  common.Hash
+	txIndex int
+}
+
+func newFileTracer(cfg json.RawMessage) (*tracing.Hooks, error) {
+	var config fileTracerConfig
+	if err := json.Unmarshal(cfg, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %v", err)
+	}
+	if config.Path == "" {
+		return nil, errors.New("file tracer output path is required")
+	}
+	if config.Tracer == "" {
+		return nil, errors.New("file tracer requires a tracer name")
+	}
+	if tracers.DefaultDirectory.IsJS(config.Tracer) {
+		return nil, fmt.Errorf("tracer %q is not a native tracer", config.Tracer)
+	}
+	// Ensure the tracer exists and accepts the config.
+	if _, err := tracers.DefaultDirectory.New(config.Tracer, &tracers.Context{}, config.TracerConfig, params.MainnetChainConfig); err != nil {
+		return nil, fmt.Errorf("failed to create tracer %q: %v", config.Tracer, err)
+	}
+	var interval time.Duration
+	if config.RotateInterval != "" {
+		d, err := time.ParseDuration(config.RotateInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rotation interval: %v", err)
+		}
+		interval = d
+	}
+	if err := os.MkdirAll(config.Path, 0755); err != nil {
+		return nil, err
+	}
+	filename := filepath.Join(config.Path, fileTraceName)
+	written, err := recoverTraceFile(filename, fileResumeBlocks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recover trace file: %v", err)
+	}
+	var (
+		resume map[uint64]common.Hash
+		last   uint64
+	)
+	if len(written) > 0 {
+		resume = make(map[uint64]common.Hash, len(written))
+		for _, block := range written {
+			resume[*block.Number] = block.Hash
+			last = max(last, *block.Number)
+		}
+		log.Info("Resuming block tracing", "tracer", config.Tracer, "number", last)
+	}
+
+	// Store traces in a rotating file
+	logger := &lumberjack.Logger{
+		Filename:   filename,
+		MaxBackups: config.MaxBackups,
+		Compress:   config.Compress,
+	}
+	if config.MaxSize > 0 {
+		logger.MaxSize = config.MaxSize
+	}
+	t := &fileTracer{
+		name:       config.Tracer,
+		config:     config.TracerConfig,
+		logger:     logger,
+		interval:   interval,
+		lastRotate: time.Now(),
+		resume:     resume,
+		last:       last,
+	}
+	return &tracing.Hooks{
+		OnBlockchainInit: t.onBlockchainInit,
+		OnBlockStart:     t.onBlockStart,
+		OnBlockEnd:       t.onBlockEnd,
+		OnTxStart:        t.onTxStart,
+		OnTxEnd:          t.onTxEnd,
+		OnEnter:          t.onEnter,
+		OnExit:           t.onExit,
+		OnOpcode:         t.onOpcode,
+		OnFault:          t.onFault,
+		OnGasChange:      t.onGasChange,
+		OnBalanceChange:  t.onBalanceChange,
+		OnNonceChange:    t.onNonceChange,
+		OnCodeChange:     t.onCodeChange,
+		OnStorageChange:  t.onStorageChange,
+		OnLog:            t.onLog,
+		OnClose:          t.onClose,
+	}, nil
+}
+
+func (t *fileTracer) onBlockchainInit(chainConfig *params.ChainConfig) {
+	t.chainConfig = chainConfig
+}
+
+func (t *fileTracer) onBlockStart(ev tracing.BlockEvent) {
+	number := ev.Block.NumberU64()
+	if t.resume != nil {
+		if number > t.last {
+			t.resume = nil
+		} else if hash, ok := t.resume[number]; ok && hash == ev.Block.Hash() {
+			// Block already written before the restart.
+			t.block = nil
+			return
+		}
+	}
+	t.block = &fileBlockTraces{
+		Number: number,
+		Hash:   ev.Block.Hash(),
+		Traces: make([]*fileTxTrace, 0, len(ev.Block.Transactions())),
+	}
+	t.txIndex = 0
+}
+
+func (t *fileTracer) onBlockEnd(err error) {
+	block := t.block
+	t.block, t.tx = nil, nil
+	if block == nil || err != nil {
+		return
+	}
+	out, err := json.Marshal(block)
+	if err != nil {
+		log.Warn("Failed to encode block traces", "number", block.Number, "error", err)
+		return
+	}
+	if _, err := t.logger.Write(append(out, '\n')); err != nil {
+		log.Warn("Failed to write block traces", "number", block.Number, "error", err)
+		return
+	}
+	// Rotate on block boundaries, so that a file never contains partial
+	// blocks.
+	if t.interval > 0 && time.Since(t.lastRotate) >= t.interval {
+		if err := t.logger.Rotate(); err != nil {
+			log.Warn("Failed to rotate trace file", "error", err)
+		}
+		t.lastRotate = time.Now()
+	}
+}
+
+func (t *fileTracer) onTxStart(env *tracing.VMContext, tx *types.Transaction, from common.Address) {
+	if t.block == nil {
+		return
+	}
+	ctx := &tracers.Context{
+		BlockHash:   t.block.Hash,
+		BlockNumber: env.BlockNumber,
+		TxIndex:     t.txIndex,
+		TxHash:      tx.Hash(),
+	}
+	t.txIndex++
+	t.txHash = tx.Hash()
+
+	tracer, err := tracers.DefaultDirectory.New(t.name, ctx, t.config, t.chainConfig)
+	if err != nil {
+		t.tx = nil
+		t.block.Traces = append(t.block.Traces, &fileTxTrace{TxHash: t.txHash, Error: err.Error()})
+		return
+	}
+	t.tx = tracer
+	if t.tx.OnTxStart != nil {
+		t.tx.OnTxStart(env, tx, from)
+	}
+}
+
+func (t *fileTracer) onTxEnd(receipt *types.Receipt, err error) {
+	if t.tx == nil {
+		return
+	}
+	tracer := t.tx
+	t.tx = nil
+	if tracer.OnTxEnd != nil {
+		tracer.OnTxEnd(receipt, err)
+	}
+	trace := &fileTxTrace{TxHash: t.txHash}
+	if res, err := tracer.GetResult(); err != nil {
+		trace.Error = err.Error()
+	} else {
+		trace.Result = res
+	}
+	t.block.Traces = append(t.block.Traces, trace)
+}
+
+func (t *fileTracer) onEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if t.tx != nil && t.tx.OnEnter != nil {
+		t.tx.OnEnter(depth, typ, from, to, input, gas, value)
+	}
+}
+
+func (t *fileTracer) onExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	if t.tx != nil && t.tx.OnExit != nil {
+		t.tx.OnExit(depth, output, gasUsed, err, reverted)
+	}
+}
+
+func (t *fileTracer) onOpcode(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	if t.tx != nil && t.tx.OnOpcode != nil {
+		t.tx.OnOpcode(pc, op, gas, cost, scope, rData, depth, err)
+	}
+}
+
+func (t *fileTracer) onFault(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, depth int, err error) {
+	if t.tx != nil && t.tx.OnFault != nil {
+		t.tx.OnFault(pc, op, gas, cost, scope, depth, err)
+	}
+}
+
+func (t *fileTracer) onGasChange(old, new uint64, reason tracing.GasChangeReason) {
+	if t.tx != nil && t.tx.OnGasChange != nil {
+		t.tx.OnGasChange(old, new, reason)
+	}
+}
+
+func (t *fileTracer) onBalanceChange(addr common.Address, prev, new *big.Int, reason tracing.BalanceChangeReason) {
+	if t.tx != nil && t.tx.OnBalanceChange != nil {
+		t.tx.OnBalanceChange(addr, prev, new, reason)
+	}
+}
+
+func (t *fileTracer) onNonceChange(addr common.Address, prev, new uint64) {
+	if t.tx != nil && t.tx.OnNonceChange != nil {
+		t.tx.OnNonceChange(addr, prev, new)
+	}
+}
+
+func (t *fileTracer) onCodeChange(addr common.Address, prevCodeHash common.Hash, prevCode []byte, codeHash common.Hash, code []byte) {
+	if t.tx != nil && t.tx.OnCodeChange != nil {
+		t.tx.OnCodeChange(addr, prevCodeHash, prevCode, codeHash, code)
+	}
+}
+
+func (t *fileTracer) onStorageChange(addr common.Address, slot common.Hash, prev, new common.Hash) {
+	if t.tx != nil && t.tx.OnStorageChange != nil {
+		t.tx.OnStorageChange(addr, slot, prev, new)
+	}
+}
+
+func (t *fileTracer) onLog(l *types.Log) {
+	if t.tx != nil && t.tx.OnLog != nil {
+		t.tx.OnLog(l)
+	}
+}
+
+func (t *fileTracer) onClose() {
+	if err := t.logger.Close(); err != nil {
+		log.Warn("failed to close file tracer log file", "error", err)
+	}
+}
+
+// writtenBlock identifies a block written to the trace file.
+type writtenBlock struct {
+	Number *uint64     `json:"blockNumber"`
+	Hash   common.Hash `json:"blockHash"`
+}
+
+// recoverTraceFile truncates a partially written line at the end of the trace
+// file, and returns the last blocks fully written, up to the given limit. If
+// the current file contains no blocks, the last rotated file is inspected
+// instead.
+func recoverTraceFile(filename string, limit int) ([]writtenBlock, error) {
+	f, err := os.OpenFile(filename, os.O_RDWR, 0)
+	if err == nil {
+		defer f.Close()
+		if err := truncatePartialLine(f); err != nil {
+			return nil, err
+		}
+		blocks, err := lastBlocks(f, limit)
+		if err != nil || len(blocks) > 0 {
+			return blocks, err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	// Rotated files are named after the rotation time, their lexical order
+	// is the chronological order.
+	ext := filepath.Ext(filename)
+	backups, err := filepath.Glob(filename[:len(filename)-len(ext)] + "-*" + ext)
+	if err != nil || len(backups) == 0 {
+		return nil, err
+	}
+	slices.Sort(backups)
+	backup, err := os.Open(backups[len(backups)-1])
+	if err != nil {
+		return nil, err
+	}
+	defer backup.Close()
+	return lastBlocks(backup, limit)
+}
+
+// truncatePartialLine removes any data after the last newline in the file.
+func truncatePartialLine(f *os.File) error {
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	end, err := lastNewline(f, stat.Size())
+	if err != nil {
+		return err
+	}
+	if end+1 < stat.Size() {
+		log.Warn("Truncating partially written trace", "file", f.Name(), "size", stat.Size(), "truncated", stat.Size()-end-1)
+		if err := f.Truncate(end + 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lastBlocks returns the blocks of the last complete lines of the file, up to
+// the given limit, newest first.
+func lastBlocks(f *os.File, limit int) ([]writtenBlock, error) {
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	end, err := lastNewline(f, stat.Size())
+	if err != nil {
+		return nil, err
+	}
+	var blocks []writtenBlock
+	for end >= 0 && len(blocks) < limit {
+		line, start, err := readLineEndingAt(f, end)
+		if err != nil {
+			return nil, err
+		}
+		block, err := parseWrittenBlock(line)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+		end = start
+	}
+	return blocks, nil
+}
+
+// readLineEndingAt returns the line terminated by the newline at offset end,
+// along with the offset of the newline preceding it (-1 if there is none).
+func readLineEndingAt(f *os.File, end int64) ([]byte, int64, error) {
+	start, err := lastNewline(f, end)
+	if err != nil {
+		return nil, 0, err
+	}
+	line := make([]byte, end-start-1)
+	if _, err := f.ReadAt(line, start+1); err != nil {
+		return nil, 0, err
+	}
+	return line, start, nil
+}
+
+// lastNewline returns the offset of the last newline located before the given
+// offset, or -1 if there is none.
+func lastNewline(f io.ReaderAt, before int64) (int64, error) {
+	buf := make([]byte, 64*1024)
+	for before > 0 {
+		n := min(int64(len(buf)), before)
+		if _, err := f.ReadAt(buf[:n], before-n); err != nil {
+			return 0, err
+		}
+		if i := bytes.LastIndexByte(buf[:n], '\n'); i >= 0 {
+			return before - n + int64(i), nil
+		}
+		before -= n
+	}
+	return -1, nil
+}
+
+func parseWrittenBlock(line []byte) (writtenBlock, error) {
+	var block writtenBlock
+	if err := json.Unmarshal(line, &block); err != nil {
+		return writtenBlock{}, err
+	}
+	if block.Number == nil {
+		return writtenBlock{}, errors.New("missing block number in trace file")
+	}
+	return block, nil
+}
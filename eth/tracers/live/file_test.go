@@ -0,0 +1,185 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package live
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	_ "github.com/ethereum/go-ethereum/eth/tracers/native"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestFileTracerResume(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, fileTraceName)
+
+	blocks := make([]*types.Block, 5)
+	for i := range blocks {
+		blocks[i] = types.NewBlockWithHeader(&types.Header{Number: big.NewInt(int64(i))})
+	}
+	line := func(block *types.Block) string {
+		return fmt.Sprintf(`{"blockNumber":%d,"blockHash":%q,"traces":[]}`, block.NumberU64(), block.Hash().Hex()) + "\n"
+	}
+	// Simulate a crash in the middle of writing block 4.
+	content := line(blocks[1]) + line(blocks[2]) + line(blocks[3]) + `{"blockNumber":4,"tra`
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := fmt.Sprintf(`{"path": %q, "tracer": "callTracer"}`, dir)
+	hooks, err := newFileTracer(json.RawMessage(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hooks.OnBlockchainInit(params.MergedTestChainConfig)
+
+	// Blocks up to 3 are already written, and must not be duplicated, unless
+	// they were reorged out meanwhile.
+	reorged := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(3), Extra: []byte("reorg")})
+	for _, block := range []*types.Block{blocks[1], blocks[2], reorged, blocks[4]} {
+		hooks.OnBlockStart(tracing.BlockEvent{Block: block})
+		hooks.OnBlockEnd(nil)
+	}
+	hooks.OnClose()
+
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var hashes []common.Hash
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var block fileBlockTraces
+		if err := json.Unmarshal(scanner.Bytes(), &block); err != nil {
+			t.Fatalf("invalid line %q: %v", scanner.Text(), err)
+		}
+		hashes = append(hashes, block.Hash)
+	}
+	want := []common.Hash{blocks[1].Hash(), blocks[2].Hash(), blocks[3].Hash(), reorged.Hash(), blocks[4].Hash()}
+	if !slices.Equal(hashes, want) {
+		t.Fatalf("wrong blocks in trace file: have %v, want %v", hashes, want)
+	}
+}
+
+func TestFileTracerRecoverBackup(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, fileTraceName)
+
+	// The current file is empty after a rotation, the last blocks must be
+	// read from the newest rotated file.
+	files := map[string]string{
+		"traces-2024-01-01T00-00-00.000.jsonl": `{"blockNumber":10}` + "\n",
+		"traces-2024-01-02T00-00-00.000.jsonl": `{"blockNumber":20}` + "\n" + `{"blockNumber":21,"blockHash":"0x0100000000000000000000000000000000000000000000000000000000000000"}` + "\n",
+		fileTraceName:                          "",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	blocks, err := recoverTraceFile(filename, fileResumeBlocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 2 || *blocks[0].Number != 21 || blocks[0].Hash != (common.Hash{0x01}) || *blocks[1].Number != 20 {
+		t.Fatalf("wrong resume blocks: %v", blocks)
+	}
+	// Only the requested number of blocks are recovered.
+	if blocks, err = recoverTraceFile(filename, 1); err != nil || len(blocks) != 1 {
+		t.Fatalf("wrong resume blocks: %v, %v", blocks, err)
+	}
+	// Without any trace file, tracing starts from scratch.
+	blocks, err = recoverTraceFile(filepath.Join(t.TempDir(), fileTraceName), fileResumeBlocks)
+	if err != nil || blocks != nil {
+		t.Fatalf("unexpected resume blocks: %v, %v", blocks, err)
+	}
+	// A long line spanning several read chunks is read entirely.
+	long := fmt.Sprintf(`{"blockNumber":7,"padding":%q}`, strings.Repeat("x", 200*1024))
+	if err := os.WriteFile(filename, []byte(long+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if blocks, err = recoverTraceFile(filename, fileResumeBlocks); err != nil || len(blocks) != 1 || *blocks[0].Number != 7 {
+		t.Fatalf("wrong resume blocks: %v, %v", blocks, err)
+	}
+}
+
+// Tests that the state diffs of all transactions can be recorded by running the
+// native stateDiffTracer through the file tracer.
+func TestFileTracerStateDiff(t *testing.T) {
+	dir := t.TempDir()
+	cfg := fmt.Sprintf(`{"path": %q, "tracer": "stateDiffTracer"}`, dir)
+	hooks, err := newFileTracer(json.RawMessage(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hooks.OnBlockchainInit(params.MergedTestChainConfig)
+
+	var (
+		from = common.Address{0x01}
+		to   = common.Address{0x02}
+	)
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	env := &tracing.VMContext{
+		BlockNumber: big.NewInt(1),
+		Time:        1,
+		Random:      &common.Hash{},
+		StateDB:     statedb,
+	}
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1)})
+	hooks.OnBlockStart(tracing.BlockEvent{Block: block})
+	hooks.OnTxStart(env, types.NewTx(&types.LegacyTx{To: &to}), from)
+	hooks.OnBalanceChange(from, big.NewInt(10), big.NewInt(5), tracing.BalanceChangeTransfer)
+	hooks.OnBalanceChange(to, big.NewInt(0), big.NewInt(5), tracing.BalanceChangeTransfer)
+	hooks.OnTxEnd(&types.Receipt{}, nil)
+	hooks.OnBlockEnd(nil)
+	hooks.OnClose()
+
+	content, err := os.ReadFile(filepath.Join(dir, fileTraceName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var traces fileBlockTraces
+	if err := json.Unmarshal(content, &traces); err != nil {
+		t.Fatalf("invalid trace file %q: %v", content, err)
+	}
+	if len(traces.Traces) != 1 {
+		t.Fatalf("wrong number of traces: %d", len(traces.Traces))
+	}
+	var diff map[common.Address]json.RawMessage
+	if err := json.Unmarshal(traces.Traces[0].Result, &diff); err != nil {
+		t.Fatalf("invalid state diff %s: %v", traces.Traces[0].Result, err)
+	}
+	if _, ok := diff[from]; !ok {
+		t.Errorf("sender missing from state diff: %s", traces.Traces[0].Result)
+	}
+	if _, ok := diff[to]; !ok {
+		t.Errorf("recipient missing from state diff: %s", traces.Traces[0].Result)
+	}
+}
@@ -0,0 +1,522 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("gasProfileTracer", newGasProfileTracer, false)
+}
+
+// gasProfile is the gas spent in the frames of a contract, or of a single
+// function of a contract.
+type gasProfile struct {
+	Address   common.Address `json:"address"`
+	Function  string         `json:"function,omitempty"`
+	Calls     uint64         `json:"calls"`
+	Inclusive uint64         `json:"inclusive"` // Gas spent by the frames, including subcalls
+	Exclusive uint64         `json:"exclusive"` // Gas spent by the frames themselves
+}
+
+// pcProfile is the gas spent by the instruction at a specific program counter.
+type pcProfile struct {
+	Address    common.Address `json:"address"`
+	PC         uint64         `json:"pc"`
+	Op         string         `json:"op"`
+	Count      uint64         `json:"count"`
+	Inclusive  uint64         `json:"inclusive"`            // Gas spent, including the subcalls made by the instruction
+	Exclusive  uint64         `json:"exclusive"`            // Gas spent by the instruction itself
+	Memory     uint64         `json:"memory,omitempty"`     // Memory expansion, included in exclusive
+	ColdAccess uint64         `json:"coldAccess,omitempty"` // Surcharge of cold account and storage accesses, included in exclusive
+}
+
+// gasProfileResult is the output of the gasProfileTracer in json format.
+type gasProfileResult struct {
+	GasUsed   uint64        `json:"gasUsed"`
+	Intrinsic uint64        `json:"intrinsic"`
+	Refund    uint64        `json:"refund"`
+	Contracts []*gasProfile `json:"contracts"`
+	Functions []*gasProfile `json:"functions"`
+	PCs       []*pcProfile  `json:"pcs"`
+}
+
+type gasProfileTracerConfig struct {
+	Format string `json:"format"` // Output format, either "json" (default) or "folded"
+}
+
+// profileOp is an instruction whose gas consumption is not known yet, as it
+// can only be determined once the next instruction of the frame executes, or
+// the frame exits.
+type profileOp struct {
+	pc     uint64
+	op     vm.OpCode
+	gas    uint64 // Gas available before the instruction
+	memory uint64 // Memory size before the instruction
+	memEnd uint64 // Memory size after a RETURN or REVERT
+	cold   uint64
+	failed bool
+}
+
+type profileFrame struct {
+	typ        vm.OpCode
+	address    common.Address // Address of the executing code
+	function   string
+	label      string // Stack of frames in folded format, including this one
+	gas        uint64 // Gas available on entry
+	children   uint64 // Gas used by subcalls of the pending instruction
+	deposit    uint64 // Gas charged for storing the created code
+	exclusive  uint64
+	pending    *profileOp
+	leaves     map[string]uint64 // Exclusive gas per instruction, for the folded output
+	precompile bool
+}
+
+// gasProfileTracer attributes the gas spent by a transaction to contracts,
+// functions (identified by their 4-byte selector) and instructions. Gas spent
+// in subcalls is accounted for both inclusively and exclusively. The result
+// can also be emitted in folded stack format, which is understood by
+// flamegraph tools:
+//
+//	> debug.traceTransaction(hash, {tracer: "gasProfileTracer", tracerConfig: {format: "folded"}})
+//	"[intrinsic] 21000\n0xAbC...:0xa9059cbb;SLOAD 2100\n..."
+type gasProfileTracer struct {
+	config      gasProfileTracerConfig
+	chainConfig *params.ChainConfig
+	rules       params.Rules
+	precompiles []common.Address
+
+	frames    []*profileFrame
+	cold      uint64 // Cold access charged before the next instruction
+	gasUsed   uint64
+	intrinsic uint64
+	refund    uint64
+
+	contracts map[common.Address]*gasProfile
+	functions map[string]*gasProfile
+	pcs       map[string]*pcProfile
+	folded    map[string]uint64
+
+	interrupt atomic.Bool // Atomic flag to signal execution interruption
+	reason    error       // Textual reason for the interruption
+}
+
+// newGasProfileTracer returns a native go tracer which profiles the gas usage
+// of a transaction.
+func newGasProfileTracer(ctx *tracers.Context, cfg json.RawMessage, chainConfig *params.ChainConfig) (*tracers.Tracer, error) {
+	var config gasProfileTracerConfig
+	if cfg != nil {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, err
+		}
+	}
+	switch config.Format {
+	case "":
+		config.Format = "json"
+	case "json", "folded":
+	default:
+		return nil, fmt.Errorf("unknown output format %q", config.Format)
+	}
+	t := &gasProfileTracer{
+		config:      config,
+		chainConfig: chainConfig,
+		contracts:   make(map[common.Address]*gasProfile),
+		functions:   make(map[string]*gasProfile),
+		pcs:         make(map[string]*pcProfile),
+		folded:      make(map[string]uint64),
+	}
+	return &tracers.Tracer{
+		Hooks: &tracing.Hooks{
+			OnTxStart:   t.OnTxStart,
+			OnTxEnd:     t.OnTxEnd,
+			OnEnter:     t.OnEnter,
+			OnExit:      t.OnExit,
+			OnOpcode:    t.OnOpcode,
+			OnGasChange: t.OnGasChange,
+		},
+		GetResult: t.GetResult,
+		Stop:      t.Stop,
+	}, nil
+}
+
+func (t *gasProfileTracer) OnTxStart(env *tracing.VMContext, tx *types.Transaction, from common.Address) {
+	t.rules = t.chainConfig.Rules(env.BlockNumber, env.Random != nil, env.Time)
+	t.precompiles = vm.ActivePrecompiles(t.rules)
+}
+
+func (t *gasProfileTracer) OnTxEnd(receipt *types.Receipt, err error) {
+	if err == nil && receipt != nil {
+		t.gasUsed = receipt.GasUsed
+	}
+}
+
+// OnEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *gasProfileTracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if t.interrupt.Load() {
+		return
+	}
+	frame := &profileFrame{
+		typ:        vm.OpCode(typ),
+		address:    to,
+		gas:        gas,
+		leaves:     make(map[string]uint64),
+		precompile: slices.Contains(t.precompiles, to),
+	}
+	switch {
+	case frame.typ == vm.CREATE || frame.typ == vm.CREATE2:
+		frame.function = "constructor"
+	case frame.precompile:
+	case len(input) >= 4:
+		frame.function = bytesToHex(input[:4])
+	default:
+		frame.function = "fallback"
+	}
+	frame.label = to.Hex()
+	if frame.function != "" {
+		frame.label += ":" + frame.function
+	}
+	if len(t.frames) > 0 {
+		frame.label = t.frames[len(t.frames)-1].label + ";" + frame.label
+	}
+	t.frames = append(t.frames, frame)
+}
+
+// OnExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *gasProfileTracer) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	if t.interrupt.Load() || len(t.frames) == 0 {
+		return
+	}
+	frame := t.frames[len(t.frames)-1]
+	t.frames = t.frames[:len(t.frames)-1]
+
+	if frame.typ == vm.SELFDESTRUCT {
+		return
+	}
+	// Everything left after the code deposit was spent by the last instruction.
+	left := frame.gas - min(gasUsed, frame.gas)
+	if op := frame.pending; op != nil {
+		memory := op.memory
+		if !op.failed && (op.op == vm.RETURN || op.op == vm.REVERT) {
+			memory = op.memEnd
+		}
+		t.settle(frame, left+frame.deposit, memory)
+	} else {
+		// No code was executed, the gas went to a precompile or the
+		// overhead of entering the frame.
+		spent := safeSub(gasUsed, frame.children)
+		if spent > 0 {
+			leaf := "[overhead]"
+			if frame.precompile {
+				leaf = "[precompile]"
+			}
+			frame.exclusive += spent
+			frame.leaves[leaf] += spent
+		}
+	}
+	if frame.deposit > 0 {
+		frame.exclusive += frame.deposit
+		frame.leaves["[codedeposit]"] += frame.deposit
+	}
+	// Recursive frames are only accounted for inclusively in the outermost
+	// frame of the same contract or function.
+	var outerContract, outerFunction bool
+	for _, parent := range t.frames {
+		if parent.address == frame.address {
+			outerContract = true
+			if parent.function == frame.function {
+				outerFunction = true
+			}
+		}
+	}
+	contract := t.contracts[frame.address]
+	if contract == nil {
+		contract = &gasProfile{Address: frame.address}
+		t.contracts[frame.address] = contract
+	}
+	contract.Calls++
+	contract.Exclusive += frame.exclusive
+	if !outerContract {
+		contract.Inclusive += gasUsed
+	}
+	key := frame.address.Hex() + ":" + frame.function
+	function := t.functions[key]
+	if function == nil {
+		function = &gasProfile{Address: frame.address, Function: frame.function}
+		t.functions[key] = function
+	}
+	function.Calls++
+	function.Exclusive += frame.exclusive
+	if !outerFunction {
+		function.Inclusive += gasUsed
+	}
+	for leaf, gas := range frame.leaves {
+		t.folded[frame.label+";"+leaf] += gas
+	}
+	if len(t.frames) > 0 {
+		t.frames[len(t.frames)-1].children += gasUsed
+	} else if t.gasUsed == 0 {
+		// Fallback for executions which don't end with a receipt.
+		t.gasUsed = t.intrinsic + gasUsed - t.refund
+	}
+}
+
+// OnOpcode settles the gas spent by the previous instruction of the frame and
+// records the current one.
+func (t *gasProfileTracer) OnOpcode(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	if t.interrupt.Load() || len(t.frames) == 0 {
+		return
+	}
+	var (
+		frame  = t.frames[len(t.frames)-1]
+		memory = uint64(len(scope.MemoryData()))
+	)
+	if frame.pending != nil {
+		t.settle(frame, gas, memory)
+	}
+	pending := &profileOp{
+		pc:     pc,
+		op:     vm.OpCode(op),
+		gas:    gas,
+		memory: memory,
+		memEnd: memory,
+		cold:   t.cold,
+		failed: err != nil,
+	}
+	t.cold = 0
+
+	if err == nil {
+		stack := scope.StackData()
+		switch pending.op {
+		case vm.RETURN, vm.REVERT:
+			pending.memEnd = memoryEnd(memory, stack, 1, 2)
+		}
+		if t.rules.IsEIP2929 && !t.rules.IsEIP4762 {
+			pending.cold += coldAccessCost(pending.op, cost, memory, stack)
+		}
+	}
+	frame.pending = pending
+}
+
+func (t *gasProfileTracer) OnGasChange(old, new uint64, reason tracing.GasChangeReason) {
+	if t.interrupt.Load() {
+		return
+	}
+	switch reason {
+	case tracing.GasChangeTxIntrinsicGas:
+		t.intrinsic += old - new
+		t.folded["[intrinsic]"] += old - new
+	case tracing.GasChangeTxRefunds:
+		t.refund += new - old
+	case tracing.GasChangeCallStorageColdAccess:
+		// Charged by call variants before their OnOpcode.
+		t.cold += old - new
+	case tracing.GasChangeCallCodeStorage:
+		if len(t.frames) > 0 {
+			t.frames[len(t.frames)-1].deposit += old - new
+		}
+	}
+}
+
+// settle attributes the gas spent by the pending instruction of the frame,
+// given the gas and memory size after it executed.
+func (t *gasProfileTracer) settle(frame *profileFrame, gas uint64, memory uint64) {
+	op := frame.pending
+	frame.pending = nil
+
+	var (
+		inclusive = safeSub(op.gas, gas)
+		exclusive = safeSub(inclusive, frame.children)
+		name      = op.op.String()
+	)
+	frame.children = 0
+	frame.exclusive += exclusive
+	frame.leaves[name] += exclusive
+
+	key := fmt.Sprintf("%v:%d", frame.address, op.pc)
+	profile := t.pcs[key]
+	if profile == nil {
+		profile = &pcProfile{Address: frame.address, PC: op.pc, Op: name}
+		t.pcs[key] = profile
+	}
+	profile.Count++
+	profile.Inclusive += inclusive
+	profile.Exclusive += exclusive
+	profile.ColdAccess += op.cold
+	if memory > op.memory {
+		profile.Memory += memoryGasCost(memory) - memoryGasCost(op.memory)
+	}
+}
+
+// GetResult returns the json-encoded gas profile, and any error arising from
+// the encoding or forceful termination (via `Stop`).
+func (t *gasProfileTracer) GetResult() (json.RawMessage, error) {
+	if t.config.Format == "folded" {
+		lines := make([]string, 0, len(t.folded))
+		for stack, gas := range t.folded {
+			if gas > 0 {
+				lines = append(lines, fmt.Sprintf("%s %d", stack, gas))
+			}
+		}
+		slices.Sort(lines)
+		res, err := json.Marshal(strings.Join(lines, "\n"))
+		if err != nil {
+			return nil, err
+		}
+		return res, t.reason
+	}
+	result := &gasProfileResult{
+		GasUsed:   t.gasUsed,
+		Intrinsic: t.intrinsic,
+		Refund:    t.refund,
+		Contracts: sortProfiles(t.contracts),
+		Functions: sortProfiles(t.functions),
+		PCs:       make([]*pcProfile, 0, len(t.pcs)),
+	}
+	for _, p := range t.pcs {
+		result.PCs = append(result.PCs, p)
+	}
+	slices.SortFunc(result.PCs, func(a, b *pcProfile) int {
+		if c := cmp.Compare(b.Exclusive, a.Exclusive); c != 0 {
+			return c
+		}
+		if c := a.Address.Cmp(b.Address); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.PC, b.PC)
+	})
+	res, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *gasProfileTracer) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
+
+// sortProfiles returns the profiles ordered by descending inclusive gas.
+func sortProfiles[K comparable](profiles map[K]*gasProfile) []*gasProfile {
+	sorted := make([]*gasProfile, 0, len(profiles))
+	for _, p := range profiles {
+		sorted = append(sorted, p)
+	}
+	slices.SortFunc(sorted, func(a, b *gasProfile) int {
+		if c := cmp.Compare(b.Inclusive, a.Inclusive); c != 0 {
+			return c
+		}
+		if c := a.Address.Cmp(b.Address); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Function, b.Function)
+	})
+	return sorted
+}
+
+// memoryGasCost returns the total gas charged for memory of the given size.
+func memoryGasCost(size uint64) uint64 {
+	words := (size + 31) / 32
+	return words*params.MemoryGas + words*words/params.QuadCoeffDiv
+}
+
+// memoryEnd returns the memory size after an instruction accessing the memory
+// area given by the offset and size at the given positions from the top of
+// the stack.
+func memoryEnd(memory uint64, stack []uint256.Int, offsetPos, sizePos int) uint64 {
+	if len(stack) < max(offsetPos, sizePos) {
+		return memory
+	}
+	offset, size := stack[len(stack)-offsetPos], stack[len(stack)-sizePos]
+	if size.IsZero() {
+		return memory
+	}
+	end, overflow := new(uint256.Int).AddOverflow(&offset, &size)
+	if overflow || !end.IsUint64() || end.Uint64() > 0x1FFFFFFFE0 {
+		return memory
+	}
+	return max(memory, (end.Uint64()+31)/32*32)
+}
+
+// coldAccessCost returns the surcharge for accessing a cold account or storage
+// slot included in the cost of the instruction, as defined by EIP-2929. Call
+// variants report the surcharge through a gas change event instead.
+func coldAccessCost(op vm.OpCode, cost, memory uint64, stack []uint256.Int) uint64 {
+	switch op {
+	case vm.SLOAD:
+		if cost == params.ColdSloadCostEIP2929 {
+			return params.ColdSloadCostEIP2929 - params.WarmStorageReadCostEIP2929
+		}
+	case vm.SSTORE:
+		// A cold slot adds the full cold load cost to the write, which makes
+		// the possible costs of cold and warm writes disjoint.
+		switch cost {
+		case params.WarmStorageReadCostEIP2929 + params.ColdSloadCostEIP2929,
+			params.SstoreResetGasEIP2200,
+			params.SstoreSetGasEIP2200 + params.ColdSloadCostEIP2929:
+			return params.ColdSloadCostEIP2929
+		}
+	case vm.BALANCE, vm.EXTCODESIZE, vm.EXTCODEHASH:
+		if cost == params.ColdAccountAccessCostEIP2929 {
+			return params.ColdAccountAccessCostEIP2929 - params.WarmStorageReadCostEIP2929
+		}
+	case vm.EXTCODECOPY:
+		if len(stack) < 4 {
+			return 0
+		}
+		var (
+			length = stack[len(stack)-4]
+			end    = memoryEnd(memory, stack, 2, 4)
+			copy   = (length.Uint64() + 31) / 32 * params.CopyGas
+			access = safeSub(cost, copy+memoryGasCost(end)-memoryGasCost(memory))
+		)
+		if length.IsUint64() && access == params.ColdAccountAccessCostEIP2929 {
+			return params.ColdAccountAccessCostEIP2929 - params.WarmStorageReadCostEIP2929
+		}
+	case vm.SELFDESTRUCT:
+		switch cost - params.SelfdestructGasEIP150 {
+		case params.ColdAccountAccessCostEIP2929, params.ColdAccountAccessCostEIP2929 + params.CreateBySelfdestructGas:
+			return params.ColdAccountAccessCostEIP2929
+		}
+	}
+	return 0
+}
+
+// safeSub returns a-b, or zero if b exceeds a.
+func safeSub(a, b uint64) uint64 {
+	if b > a {
+		return 0
+	}
+	return a - b
+}
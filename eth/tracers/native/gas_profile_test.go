@@ -0,0 +1,170 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/program"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/require"
+)
+
+type gasProfile struct {
+	Address   common.Address `json:"address"`
+	Function  string         `json:"function"`
+	Calls     uint64         `json:"calls"`
+	Inclusive uint64         `json:"inclusive"`
+	Exclusive uint64         `json:"exclusive"`
+}
+
+type pcProfile struct {
+	Address    common.Address `json:"address"`
+	PC         uint64         `json:"pc"`
+	Op         string         `json:"op"`
+	Count      uint64         `json:"count"`
+	Inclusive  uint64         `json:"inclusive"`
+	Exclusive  uint64         `json:"exclusive"`
+	Memory     uint64         `json:"memory"`
+	ColdAccess uint64         `json:"coldAccess"`
+}
+
+type gasProfileResult struct {
+	GasUsed   uint64        `json:"gasUsed"`
+	Contracts []*gasProfile `json:"contracts"`
+	Functions []*gasProfile `json:"functions"`
+	PCs       []*pcProfile  `json:"pcs"`
+}
+
+// runGasProfile executes a contract calling into a callee with the selector
+// 0x12345678, and returns the output of the gasProfileTracer.
+func runGasProfile(t *testing.T, config string) (json.RawMessage, common.Address, common.Address) {
+	var (
+		caller = common.BytesToAddress([]byte("contract"))
+		callee = common.HexToAddress("0xc0ffee")
+	)
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	statedb.CreateAccount(callee)
+	statedb.SetCode(callee, program.New().
+		Push(1).Op(vm.SLOAD).Op(vm.POP).   // cold slot
+		Push(1).Push(0x100).Op(vm.MSTORE). // memory expansion
+		Op(vm.STOP).Bytes())
+
+	code := program.New().
+		Push(0x12345678).Push(224).Op(vm.SHL).Push(0).Op(vm.MSTORE).
+		Call(nil, callee, 0, 0, 4, 0, 0).Op(vm.POP).
+		Op(vm.STOP).Bytes()
+
+	tracer, err := tracers.DefaultDirectory.New("gasProfileTracer", &tracers.Context{}, json.RawMessage(config), params.MergedTestChainConfig)
+	require.NoError(t, err)
+	_, _, err = runtime.Execute(code, nil, &runtime.Config{
+		ChainConfig: params.MergedTestChainConfig,
+		State:       statedb,
+		GasLimit:    1_000_000,
+		EVMConfig:   vm.Config{Tracer: tracer.Hooks},
+	})
+	require.NoError(t, err)
+	res, err := tracer.GetResult()
+	require.NoError(t, err)
+	return res, caller, callee
+}
+
+func TestGasProfileTracer(t *testing.T) {
+	res, caller, callee := runGasProfile(t, "{}")
+
+	var profile gasProfileResult
+	require.NoError(t, json.Unmarshal(res, &profile))
+
+	// The outermost frame includes all gas spent.
+	require.Len(t, profile.Contracts, 2)
+	require.Equal(t, caller, profile.Contracts[0].Address)
+	require.Equal(t, profile.GasUsed, profile.Contracts[0].Inclusive)
+	require.Equal(t, profile.GasUsed, profile.Contracts[0].Exclusive+profile.Contracts[1].Inclusive)
+	require.Equal(t, profile.Contracts[1].Inclusive, profile.Contracts[1].Exclusive)
+
+	// The callee is profiled by selector.
+	var function *gasProfile
+	for _, f := range profile.Functions {
+		if f.Address == callee {
+			function = f
+		}
+	}
+	require.NotNil(t, function)
+	require.Equal(t, "0x12345678", function.Function)
+	require.Equal(t, uint64(1), function.Calls)
+
+	// The exclusive gas of all instructions adds up to the total.
+	var (
+		total    uint64
+		opsByPCs = make(map[string]*pcProfile)
+	)
+	for _, pc := range profile.PCs {
+		total += pc.Exclusive
+		if pc.Address == callee {
+			opsByPCs[pc.Op] = pc
+		}
+	}
+	require.Equal(t, profile.GasUsed, total)
+
+	sload := opsByPCs["SLOAD"]
+	require.Equal(t, params.ColdSloadCostEIP2929, sload.Exclusive)
+	require.Equal(t, params.ColdSloadCostEIP2929-params.WarmStorageReadCostEIP2929, sload.ColdAccess)
+
+	// Storing at 0x100 expands the memory to 0x120 bytes, i.e. 9 words.
+	mstore := opsByPCs["MSTORE"]
+	require.Equal(t, uint64(9*3+81/512), mstore.Memory)
+	require.Equal(t, vm.GasFastestStep+mstore.Memory, mstore.Exclusive)
+
+	// The call to the cold callee includes the gas spent in the subcall.
+	var call *pcProfile
+	for _, pc := range profile.PCs {
+		if pc.Op == "CALL" {
+			call = pc
+		}
+	}
+	require.NotNil(t, call)
+	require.Equal(t, params.ColdAccountAccessCostEIP2929-params.WarmStorageReadCostEIP2929, call.ColdAccess)
+	require.Equal(t, call.Exclusive+profile.Contracts[1].Inclusive, call.Inclusive)
+}
+
+func TestGasProfileTracerFolded(t *testing.T) {
+	res, caller, callee := runGasProfile(t, `{"format": "folded"}`)
+
+	var folded string
+	require.NoError(t, json.Unmarshal(res, &folded))
+
+	var found bool
+	for _, line := range strings.Split(folded, "\n") {
+		if strings.HasPrefix(line, caller.Hex()+":fallback;"+callee.Hex()+":0x12345678;SLOAD ") {
+			found = true
+		}
+	}
+	require.True(t, found, "missing SLOAD stack in folded output:\n%s", folded)
+}
+
+func TestGasProfileTracerConfig(t *testing.T) {
+	_, err := tracers.DefaultDirectory.New("gasProfileTracer", &tracers.Context{}, json.RawMessage(`{"format": "pprof"}`), params.MainnetChainConfig)
+	require.Error(t, err)
+}
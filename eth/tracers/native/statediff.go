@@ -0,0 +1,444 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"slices"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("stateDiffTracer", newStateDiffTracer, false)
+}
+
+// stateDiff is the state modified by a transaction, in the format of the
+// stateDiff of Parity's trace_replayTransaction.
+type stateDiff map[common.Address]*accountDiff
+
+// accountDiff contains the changes to a single account. Besides the fields of
+// the Parity format, it lists the reasons of all balance changes.
+type accountDiff struct {
+	Balance diffValue                 `json:"balance"`
+	Code    diffValue                 `json:"code"`
+	Nonce   diffValue                 `json:"nonce"`
+	Storage map[common.Hash]diffValue `json:"storage"`
+	Reasons []string                  `json:"balanceChangeReasons,omitempty"`
+}
+
+// diffValue is the change of a single value: "=" if it is unchanged, {"+": to}
+// if it was created, {"-": from} if it was deleted and {"*": {"from": from,
+// "to": to}} if it was modified.
+type diffValue struct {
+	Kind string // One of "=", "+", "-" or "*"
+	From any
+	To   any
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d diffValue) MarshalJSON() ([]byte, error) {
+	switch d.Kind {
+	case "+":
+		return json.Marshal(map[string]any{"+": d.To})
+	case "-":
+		return json.Marshal(map[string]any{"-": d.From})
+	case "*":
+		return json.Marshal(map[string]any{"*": map[string]any{"from": d.From, "to": d.To}})
+	default:
+		return json.Marshal("=")
+	}
+}
+
+// accountState is the state of an account, excluding storage.
+type accountState struct {
+	balance *big.Int
+	nonce   uint64
+	code    []byte
+}
+
+func (s *accountState) empty() bool {
+	return s.balance.Sign() == 0 && s.nonce == 0 && len(s.code) == 0
+}
+
+// diffAccount tracks the state of an account touched by the transaction.
+type diffAccount struct {
+	existed bool // Whether the account existed before the transaction
+	created bool // Whether the account was created by the transaction
+	killed  bool // Whether the account was destructed by the transaction
+
+	pre, post   accountState
+	preStorage  map[common.Hash]common.Hash
+	postStorage map[common.Hash]common.Hash
+	reasons     []tracing.BalanceChangeReason
+}
+
+// diffFrame is a call frame, along with the position in the journal its
+// changes start from.
+type diffFrame struct {
+	typ    vm.OpCode
+	from   common.Address
+	mark   int
+	bumped bool // Whether the nonce of the creator was incremented
+}
+
+// stateDiffTracer collects the state changes of a transaction through the
+// state hooks. As the hooks are not invoked when changes are rolled back, the
+// tracer keeps a journal of the changes in each call frame, which is unwound
+// if the frame reverts.
+//
+// The tracer can be reused for consecutive transactions, state changes
+// outside of a transaction are ignored.
+//
+// To record the state diffs of all imported blocks, run it through the live
+// file tracer:
+//
+//	--vmtrace file --vmtrace.jsonconfig '{"path": "<dir>", "tracer": "stateDiffTracer"}'
+type stateDiffTracer struct {
+	env         *tracing.VMContext
+	chainConfig *params.ChainConfig
+	rules       params.Rules
+	active      bool
+
+	accounts map[common.Address]*diffAccount
+	frames   []diffFrame
+	journal  []func()
+	result   stateDiff
+
+	interrupt atomic.Bool // Atomic flag to signal execution interruption
+	reason    error       // Textual reason for the interruption
+}
+
+// newStateDiffTracer returns a native go tracer which reports the state
+// changes of a transaction in Parity's stateDiff format.
+func newStateDiffTracer(ctx *tracers.Context, cfg json.RawMessage, chainConfig *params.ChainConfig) (*tracers.Tracer, error) {
+	t := &stateDiffTracer{chainConfig: chainConfig}
+	return &tracers.Tracer{
+		Hooks: &tracing.Hooks{
+			OnTxStart:       t.OnTxStart,
+			OnTxEnd:         t.OnTxEnd,
+			OnEnter:         t.OnEnter,
+			OnExit:          t.OnExit,
+			OnBalanceChange: t.OnBalanceChange,
+			OnNonceChange:   t.OnNonceChange,
+			OnCodeChange:    t.OnCodeChange,
+			OnStorageChange: t.OnStorageChange,
+		},
+		GetResult: t.GetResult,
+		Stop:      t.Stop,
+	}, nil
+}
+
+func (t *stateDiffTracer) OnTxStart(env *tracing.VMContext, tx *types.Transaction, from common.Address) {
+	t.env = env
+	t.rules = t.chainConfig.Rules(env.BlockNumber, env.Random != nil, env.Time)
+	t.active = true
+	t.accounts = make(map[common.Address]*diffAccount)
+	t.frames = t.frames[:0]
+	t.journal = t.journal[:0]
+	t.result = nil
+
+	// Record the accounts before they are modified, to know whether they
+	// existed.
+	t.lookup(from)
+	if tx.To() != nil {
+		t.lookup(*tx.To())
+	}
+}
+
+func (t *stateDiffTracer) OnTxEnd(receipt *types.Receipt, err error) {
+	if !t.active {
+		return
+	}
+	t.active = false
+	if err != nil {
+		return
+	}
+	t.result = t.diff()
+}
+
+// OnEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *stateDiffTracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if !t.active || t.interrupt.Load() {
+		return
+	}
+	op := vm.OpCode(typ)
+	if op == vm.SELFDESTRUCT {
+		// Since EIP-6780, only contracts created in the same transaction
+		// are destructed.
+		acc := t.lookup(from)
+		if !t.rules.IsCancun || acc.created {
+			t.setKilled(acc)
+		}
+	} else {
+		t.lookup(to)
+	}
+	t.frames = append(t.frames, diffFrame{typ: op, from: from, mark: len(t.journal)})
+	if op == vm.CREATE || op == vm.CREATE2 {
+		t.setCreated(t.lookup(to))
+	}
+}
+
+// OnExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *stateDiffTracer) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	if !t.active || t.interrupt.Load() || len(t.frames) == 0 {
+		return
+	}
+	frame := t.frames[len(t.frames)-1]
+	t.frames = t.frames[:len(t.frames)-1]
+	if !reverted {
+		return
+	}
+	for i := len(t.journal) - 1; i >= frame.mark; i-- {
+		t.journal[i]()
+	}
+	t.journal = t.journal[:frame.mark]
+}
+
+func (t *stateDiffTracer) OnBalanceChange(addr common.Address, prev, new *big.Int, reason tracing.BalanceChangeReason) {
+	if !t.active || t.interrupt.Load() {
+		return
+	}
+	acc, fresh := t.touch(addr)
+	if fresh {
+		acc.pre.balance = new0(prev)
+		acc.existed = !acc.pre.empty()
+	}
+	old := acc.post.balance
+	acc.post.balance = new0(new)
+	acc.reasons = append(acc.reasons, reason)
+	t.journal = append(t.journal, func() {
+		acc.post.balance = old
+		acc.reasons = acc.reasons[:len(acc.reasons)-1]
+	})
+}
+
+func (t *stateDiffTracer) OnNonceChange(addr common.Address, prev, new uint64) {
+	if !t.active || t.interrupt.Load() {
+		return
+	}
+	acc, fresh := t.touch(addr)
+	if fresh {
+		acc.pre.nonce = prev
+		acc.existed = !acc.pre.empty()
+	}
+	old := acc.post.nonce
+	acc.post.nonce = new
+	undo := func() { acc.post.nonce = old }
+
+	// The nonce of the creator is incremented before the snapshot of the
+	// creation is taken, so it is not rolled back if the creation fails.
+	if n := len(t.frames); n > 0 {
+		frame := &t.frames[n-1]
+		if (frame.typ == vm.CREATE || frame.typ == vm.CREATE2) && frame.from == addr && !frame.bumped {
+			frame.bumped = true
+			t.journal = slices.Insert(t.journal, frame.mark, undo)
+			frame.mark++
+			return
+		}
+	}
+	t.journal = append(t.journal, undo)
+}
+
+func (t *stateDiffTracer) OnCodeChange(addr common.Address, prevCodeHash common.Hash, prevCode []byte, codeHash common.Hash, code []byte) {
+	if !t.active || t.interrupt.Load() {
+		return
+	}
+	acc, fresh := t.touch(addr)
+	if fresh {
+		acc.pre.code = common.CopyBytes(prevCode)
+		acc.existed = !acc.pre.empty()
+	}
+	old := acc.post.code
+	acc.post.code = common.CopyBytes(code)
+	t.journal = append(t.journal, func() { acc.post.code = old })
+}
+
+func (t *stateDiffTracer) OnStorageChange(addr common.Address, slot common.Hash, prev, new common.Hash) {
+	if !t.active || t.interrupt.Load() {
+		return
+	}
+	acc, _ := t.touch(addr)
+	if _, ok := acc.preStorage[slot]; !ok {
+		acc.preStorage[slot] = prev
+		acc.postStorage[slot] = prev
+	}
+	old := acc.postStorage[slot]
+	acc.postStorage[slot] = new
+	t.journal = append(t.journal, func() { acc.postStorage[slot] = old })
+}
+
+// GetResult returns the json-encoded state diff of the last transaction, and
+// any error arising from the encoding or forceful termination (via `Stop`).
+func (t *stateDiffTracer) GetResult() (json.RawMessage, error) {
+	result := t.result
+	if result == nil {
+		result = make(stateDiff)
+	}
+	res, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *stateDiffTracer) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
+
+// lookup returns the tracked state of an account, reading it from the state
+// if the account was not touched yet. It must only be invoked before any
+// changes to the account, as the state is expected to be unmodified.
+func (t *stateDiffTracer) lookup(addr common.Address) *diffAccount {
+	acc, fresh := t.touch(addr)
+	if fresh && t.env != nil && t.env.StateDB != nil {
+		acc.existed = t.env.StateDB.Exist(addr)
+	}
+	return acc
+}
+
+// touch returns the tracked state of an account, and whether it was not
+// tracked yet. The state of new accounts is read from the state, callers
+// are responsible for correcting the fields which were modified already.
+func (t *stateDiffTracer) touch(addr common.Address) (*diffAccount, bool) {
+	if acc, ok := t.accounts[addr]; ok {
+		return acc, false
+	}
+	acc := &diffAccount{
+		pre:         accountState{balance: new(big.Int)},
+		preStorage:  make(map[common.Hash]common.Hash),
+		postStorage: make(map[common.Hash]common.Hash),
+	}
+	if t.env != nil && t.env.StateDB != nil {
+		acc.pre = accountState{
+			balance: t.env.StateDB.GetBalance(addr).ToBig(),
+			nonce:   t.env.StateDB.GetNonce(addr),
+			code:    common.CopyBytes(t.env.StateDB.GetCode(addr)),
+		}
+		acc.existed = !acc.pre.empty()
+	}
+	acc.post = acc.pre
+	t.accounts[addr] = acc
+	return acc, true
+}
+
+func (t *stateDiffTracer) setCreated(acc *diffAccount) {
+	old := acc.created
+	acc.created = true
+	t.journal = append(t.journal, func() { acc.created = old })
+}
+
+func (t *stateDiffTracer) setKilled(acc *diffAccount) {
+	old := acc.killed
+	acc.killed = true
+	t.journal = append(t.journal, func() { acc.killed = old })
+}
+
+// diff assembles the state diff of the transaction from the tracked accounts.
+func (t *stateDiffTracer) diff() stateDiff {
+	diff := make(stateDiff)
+	for addr, acc := range t.accounts {
+		var exists bool
+		switch {
+		case acc.killed:
+			exists = false
+		case t.rules.IsEIP158:
+			// Touched empty accounts are removed at the end of the transaction.
+			exists = !acc.post.empty()
+		default:
+			exists = acc.existed || acc.created || !acc.post.empty()
+		}
+		var d *accountDiff
+		switch {
+		case !acc.existed && !exists:
+			continue
+		case !acc.existed:
+			d = &accountDiff{
+				Balance: diffValue{Kind: "+", To: (*hexutil.Big)(acc.post.balance)},
+				Code:    diffValue{Kind: "+", To: hexutil.Bytes(acc.post.code)},
+				Nonce:   diffValue{Kind: "+", To: hexutil.Uint64(acc.post.nonce)},
+				Storage: make(map[common.Hash]diffValue),
+			}
+			for slot, value := range acc.postStorage {
+				if value != (common.Hash{}) {
+					d.Storage[slot] = diffValue{Kind: "+", To: value}
+				}
+			}
+		case !exists:
+			d = &accountDiff{
+				Balance: diffValue{Kind: "-", From: (*hexutil.Big)(acc.pre.balance)},
+				Code:    diffValue{Kind: "-", From: hexutil.Bytes(acc.pre.code)},
+				Nonce:   diffValue{Kind: "-", From: hexutil.Uint64(acc.pre.nonce)},
+				Storage: make(map[common.Hash]diffValue),
+			}
+			for slot, value := range acc.preStorage {
+				if value != (common.Hash{}) {
+					d.Storage[slot] = diffValue{Kind: "-", From: value}
+				}
+			}
+		default:
+			d = &accountDiff{Storage: make(map[common.Hash]diffValue)}
+			changed := false
+			if acc.pre.balance.Cmp(acc.post.balance) != 0 {
+				d.Balance = diffValue{Kind: "*", From: (*hexutil.Big)(acc.pre.balance), To: (*hexutil.Big)(acc.post.balance)}
+				changed = true
+			}
+			if !bytes.Equal(acc.pre.code, acc.post.code) {
+				d.Code = diffValue{Kind: "*", From: hexutil.Bytes(acc.pre.code), To: hexutil.Bytes(acc.post.code)}
+				changed = true
+			}
+			if acc.pre.nonce != acc.post.nonce {
+				d.Nonce = diffValue{Kind: "*", From: hexutil.Uint64(acc.pre.nonce), To: hexutil.Uint64(acc.post.nonce)}
+				changed = true
+			}
+			for slot, from := range acc.preStorage {
+				if to := acc.postStorage[slot]; from != to {
+					d.Storage[slot] = diffValue{Kind: "*", From: from, To: to}
+					changed = true
+				}
+			}
+			if !changed {
+				continue
+			}
+		}
+		for _, reason := range acc.reasons {
+			d.Reasons = append(d.Reasons, reason.String())
+		}
+		diff[addr] = d
+	}
+	return diff
+}
+
+// new0 returns a copy of the given number, or zero if it is nil.
+func new0(x *big.Int) *big.Int {
+	if x == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(x)
+}
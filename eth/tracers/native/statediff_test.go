@@ -0,0 +1,146 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/program"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+)
+
+// applyTraced executes the transaction created by mktx on top of the given
+// state, and returns the result of the tracer.
+func applyTraced(t *testing.T, tracer string, statedb *state.StateDB, coinbase common.Address, mktx func(nonce uint64) types.TxData) json.RawMessage {
+	key, _ := crypto.GenerateKey()
+	var (
+		config = params.MergedTestChainConfig
+		signer = types.LatestSigner(config)
+		sender = crypto.PubkeyToAddress(key.PublicKey)
+		header = &types.Header{
+			Number:     big.NewInt(1),
+			Time:       1,
+			GasLimit:   30_000_000,
+			BaseFee:    big.NewInt(1),
+			Difficulty: new(big.Int),
+		}
+	)
+	statedb.SetBalance(sender, uint256.NewInt(params.Ether), tracing.BalanceChangeUnspecified)
+	statedb.Finalise(true)
+
+	tx := types.MustSignNewTx(key, signer, mktx(0))
+	msg, err := core.TransactionToMessage(tx, signer, header.BaseFee)
+	require.NoError(t, err)
+
+	tr, err := tracers.DefaultDirectory.New(tracer, &tracers.Context{}, nil, config)
+	require.NoError(t, err)
+	var (
+		vmctx   = core.NewEVMBlockContext(header, nil, &coinbase)
+		evm     = vm.NewEVM(vmctx, state.NewHookedState(statedb, tr.Hooks), config, vm.Config{Tracer: tr.Hooks})
+		usedGas uint64
+	)
+	_, err = core.ApplyTransactionWithEVM(msg, new(core.GasPool).AddGas(header.GasLimit), statedb, header.Number, common.Hash{}, tx, &usedGas, evm)
+	require.NoError(t, err)
+	res, err := tr.GetResult()
+	require.NoError(t, err)
+	return res
+}
+
+func TestStateDiffTracer(t *testing.T) {
+	var (
+		coinbase = common.HexToAddress("0xc0ba5e")
+		contract = common.HexToAddress("0xc0de")
+		reverter = common.HexToAddress("0xdead")
+	)
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	statedb.SetCode(contract, program.New().
+		Sstore(1, 0x2a).
+		Sstore(2, 0).
+		Call(nil, reverter, 0, 0, 0, 0, 0).Op(vm.POP).
+		Op(vm.STOP).Bytes())
+	statedb.SetState(contract, common.BigToHash(big.NewInt(2)), common.BigToHash(big.NewInt(5)))
+	statedb.SetCode(reverter, program.New().Sstore(1, 1).Op(vm.PUSH0, vm.PUSH0, vm.REVERT).Bytes())
+
+	res := applyTraced(t, "stateDiffTracer", statedb, coinbase, func(nonce uint64) types.TxData {
+		return &types.LegacyTx{Nonce: nonce, To: &contract, Gas: 200_000, GasPrice: big.NewInt(2)}
+	})
+	var diff map[common.Address]map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(res, &diff))
+
+	// The reverted call leaves no trace.
+	require.NotContains(t, diff, reverter)
+
+	// The storage of the contract is modified, everything else is unchanged.
+	require.Contains(t, diff, contract)
+	require.JSONEq(t, `"="`, string(diff[contract]["balance"]))
+	require.JSONEq(t, `"="`, string(diff[contract]["nonce"]))
+	require.JSONEq(t, `{
+		"0x0000000000000000000000000000000000000000000000000000000000000001": {"*": {
+			"from": "0x0000000000000000000000000000000000000000000000000000000000000000",
+			"to": "0x000000000000000000000000000000000000000000000000000000000000002a"
+		}},
+		"0x0000000000000000000000000000000000000000000000000000000000000002": {"*": {
+			"from": "0x0000000000000000000000000000000000000000000000000000000000000005",
+			"to": "0x0000000000000000000000000000000000000000000000000000000000000000"
+		}}
+	}`, string(diff[contract]["storage"]))
+
+	// The coinbase receives the tip, creating the account.
+	require.Contains(t, diff, coinbase)
+	require.Contains(t, string(diff[coinbase]["balance"]), `"+"`)
+	require.JSONEq(t, `{"+": "0x"}`, string(diff[coinbase]["code"]))
+	require.JSONEq(t, `["BalanceIncreaseRewardTransactionFee"]`, string(diff[coinbase]["balanceChangeReasons"]))
+
+	// The sender pays for gas and increments the nonce.
+	for addr, account := range diff {
+		if addr == contract || addr == coinbase {
+			continue
+		}
+		require.JSONEq(t, `{"*": {"from": "0x0", "to": "0x1"}}`, string(account["nonce"]))
+		require.JSONEq(t, `["BalanceDecreaseGasBuy", "BalanceIncreaseGasReturn"]`, string(account["balanceChangeReasons"]))
+	}
+	require.Len(t, diff, 3)
+}
+
+func TestStateDiffTracerFailedCreate(t *testing.T) {
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	initcode := program.New().Sstore(1, 1).Op(vm.PUSH0, vm.PUSH0, vm.REVERT).Bytes()
+
+	res := applyTraced(t, "stateDiffTracer", statedb, common.Address{}, func(nonce uint64) types.TxData {
+		return &types.LegacyTx{Nonce: nonce, Gas: 200_000, GasPrice: big.NewInt(1), Data: initcode}
+	})
+	var diff map[common.Address]map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(res, &diff))
+
+	// Only the sender is modified, the nonce increment is not rolled back.
+	require.Len(t, diff, 1)
+	for _, account := range diff {
+		require.JSONEq(t, `{"*": {"from": "0x0", "to": "0x1"}}`, string(account["nonce"]))
+	}
+}
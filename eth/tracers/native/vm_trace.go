@@ -0,0 +1,296 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("vmTraceTracer", newVMTraceTracer, false)
+}
+
+// vmTrace is the execution of the code of a call frame, in Parity's vmTrace
+// format.
+type vmTrace struct {
+	Code hexutil.Bytes  `json:"code"`
+	Ops  []*vmOperation `json:"ops"`
+}
+
+// vmOperation is an executed instruction. The sub trace is set if the
+// instruction entered a new call frame.
+type vmOperation struct {
+	Cost uint64      `json:"cost"`
+	Ex   *vmExecuted `json:"ex"`
+	PC   uint64      `json:"pc"`
+	Sub  *vmTrace    `json:"sub"`
+}
+
+// vmExecuted contains the effects of an instruction, it is nil if the
+// instruction failed.
+type vmExecuted struct {
+	Mem   *vmMemoryDiff  `json:"mem"`
+	Push  []hexutil.U256 `json:"push"`
+	Store *vmStoreDiff   `json:"store"`
+	Used  uint64         `json:"used"`
+}
+
+type vmMemoryDiff struct {
+	Off  uint64        `json:"off"`
+	Data hexutil.Bytes `json:"data"`
+}
+
+type vmStoreDiff struct {
+	Key hexutil.U256 `json:"key"`
+	Val hexutil.U256 `json:"val"`
+}
+
+// vmTraceFrame tracks the last instruction of a call frame, whose effects are
+// only known once the next instruction is executed.
+type vmTraceFrame struct {
+	trace *vmTrace
+	gas   uint64
+
+	pending *vmOperation
+	op      vm.OpCode
+	memOff  uint64
+	memSize uint64
+	store   *vmStoreDiff
+}
+
+// vmTraceTracer reports the executed instructions of a transaction in the
+// vmTrace format of Parity's trace_replayTransaction.
+type vmTraceTracer struct {
+	env    *tracing.VMContext
+	root   *vmTrace
+	frames []*vmTraceFrame
+
+	interrupt atomic.Bool // Atomic flag to signal execution interruption
+	reason    error       // Textual reason for the interruption
+}
+
+// newVMTraceTracer returns a native go tracer which reports the executed
+// instructions of a transaction in Parity's vmTrace format.
+func newVMTraceTracer(ctx *tracers.Context, cfg json.RawMessage, chainConfig *params.ChainConfig) (*tracers.Tracer, error) {
+	t := &vmTraceTracer{}
+	return &tracers.Tracer{
+		Hooks: &tracing.Hooks{
+			OnTxStart: t.OnTxStart,
+			OnEnter:   t.OnEnter,
+			OnExit:    t.OnExit,
+			OnOpcode:  t.OnOpcode,
+			OnFault:   t.OnFault,
+		},
+		GetResult: t.GetResult,
+		Stop:      t.Stop,
+	}, nil
+}
+
+func (t *vmTraceTracer) OnTxStart(env *tracing.VMContext, tx *types.Transaction, from common.Address) {
+	t.env = env
+	t.root = nil
+	t.frames = t.frames[:0]
+}
+
+// OnEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *vmTraceTracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if t.interrupt.Load() {
+		return
+	}
+	// Self-destructs don't execute code, but are reported as scopes.
+	if vm.OpCode(typ) == vm.SELFDESTRUCT {
+		t.frames = append(t.frames, &vmTraceFrame{})
+		return
+	}
+	trace := &vmTrace{Ops: []*vmOperation{}}
+	if op := vm.OpCode(typ); op == vm.CREATE || op == vm.CREATE2 {
+		trace.Code = common.CopyBytes(input)
+	} else if t.env != nil {
+		trace.Code = t.env.StateDB.GetCode(to)
+	}
+	if depth == 0 {
+		t.root = trace
+	} else if len(t.frames) > 0 {
+		if parent := t.frames[len(t.frames)-1]; parent.pending != nil {
+			parent.pending.Sub = trace
+		}
+	}
+	t.frames = append(t.frames, &vmTraceFrame{trace: trace, gas: gas})
+}
+
+// OnExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *vmTraceTracer) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	if t.interrupt.Load() || len(t.frames) == 0 {
+		return
+	}
+	frame := t.frames[len(t.frames)-1]
+	t.frames = t.frames[:len(t.frames)-1]
+
+	if frame.pending != nil {
+		frame.pending.Ex = &vmExecuted{Push: []hexutil.U256{}, Store: frame.store}
+		if gasUsed < frame.gas {
+			frame.pending.Ex.Used = frame.gas - gasUsed
+		}
+	}
+}
+
+// OnOpcode completes the previous instruction of the frame, and records the
+// current one.
+func (t *vmTraceTracer) OnOpcode(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	if t.interrupt.Load() || len(t.frames) == 0 {
+		return
+	}
+	frame := t.frames[len(t.frames)-1]
+	if frame.trace == nil {
+		return
+	}
+	if frame.pending != nil {
+		frame.complete(gas, scope)
+	}
+	operation := &vmOperation{PC: pc, Cost: cost}
+	frame.trace.Ops = append(frame.trace.Ops, operation)
+	if err != nil {
+		// The instruction failed before being executed.
+		return
+	}
+	frame.pending, frame.op = operation, vm.OpCode(op)
+	frame.memOff, frame.memSize, frame.store = 0, 0, nil
+
+	var (
+		stack = scope.StackData()
+		peek  = func(n int) *uint256.Int { return &stack[len(stack)-1-n] }
+	)
+	switch vm.OpCode(op) {
+	case vm.SSTORE:
+		if len(stack) >= 2 {
+			frame.store = &vmStoreDiff{Key: hexutil.U256(*peek(0)), Val: hexutil.U256(*peek(1))}
+		}
+	case vm.MSTORE:
+		frame.setMemory(stack, 0, -1, 32)
+	case vm.MSTORE8:
+		frame.setMemory(stack, 0, -1, 1)
+	case vm.CALLDATACOPY, vm.CODECOPY, vm.RETURNDATACOPY, vm.MCOPY:
+		frame.setMemory(stack, 0, 2, 0)
+	case vm.EXTCODECOPY:
+		frame.setMemory(stack, 1, 3, 0)
+	case vm.CALL, vm.CALLCODE:
+		frame.setMemory(stack, 5, 6, 0)
+	case vm.DELEGATECALL, vm.STATICCALL:
+		frame.setMemory(stack, 4, 5, 0)
+	}
+}
+
+// OnFault marks the instruction of the frame which failed during execution.
+func (t *vmTraceTracer) OnFault(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, depth int, err error) {
+	if t.interrupt.Load() || len(t.frames) == 0 || errors.Is(err, vm.ErrExecutionReverted) {
+		return
+	}
+	frame := t.frames[len(t.frames)-1]
+	frame.pending = nil
+}
+
+// GetResult returns the json-encoded vmTrace of the transaction.
+func (t *vmTraceTracer) GetResult() (json.RawMessage, error) {
+	res, err := json.Marshal(t.root)
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *vmTraceTracer) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
+
+// setMemory records the memory region written by the pending instruction. The
+// size is either read from the stack, or fixed if sizeIdx is negative.
+func (f *vmTraceFrame) setMemory(stack []uint256.Int, offIdx, sizeIdx int, size uint64) {
+	if len(stack) <= max(offIdx, sizeIdx) {
+		return
+	}
+	off := &stack[len(stack)-1-offIdx]
+	if sizeIdx >= 0 {
+		s := &stack[len(stack)-1-sizeIdx]
+		if !s.IsUint64() {
+			return
+		}
+		size = s.Uint64()
+	}
+	if size == 0 || !off.IsUint64() {
+		return
+	}
+	f.memOff, f.memSize = off.Uint64(), size
+}
+
+// complete fills in the effects of the pending instruction, given the state
+// of the frame after its execution.
+func (f *vmTraceFrame) complete(gas uint64, scope tracing.OpContext) {
+	ex := &vmExecuted{Used: gas, Store: f.store, Push: []hexutil.U256{}}
+
+	stack := scope.StackData()
+	n := min(stackPushes(f.op), len(stack))
+	for _, item := range stack[len(stack)-n:] {
+		ex.Push = append(ex.Push, hexutil.U256(item))
+	}
+	if f.memSize > 0 {
+		memory := scope.MemoryData()
+		if end := f.memOff + f.memSize; end >= f.memOff && end <= uint64(len(memory)) {
+			ex.Mem = &vmMemoryDiff{Off: f.memOff, Data: common.CopyBytes(memory[f.memOff:end])}
+		}
+	}
+	f.pending.Ex = ex
+	f.pending = nil
+}
+
+// stackPushes returns the number of stack items reported as pushed by an
+// instruction. Following Parity, DUPs and SWAPs report all the items they
+// touched.
+func stackPushes(op vm.OpCode) int {
+	switch {
+	case op >= vm.DUP1 && op <= vm.DUP16:
+		return int(op-vm.DUP1) + 2
+	case op >= vm.SWAP1 && op <= vm.SWAP16:
+		return int(op-vm.SWAP1) + 2
+	case op.IsPush():
+		return 1
+	case op >= vm.LOG0 && op <= vm.LOG4:
+		return 0
+	}
+	switch op {
+	case vm.STOP, vm.POP, vm.MSTORE, vm.MSTORE8, vm.SSTORE, vm.TSTORE, vm.JUMP, vm.JUMPI, vm.JUMPDEST,
+		vm.CALLDATACOPY, vm.CODECOPY, vm.EXTCODECOPY, vm.RETURNDATACOPY, vm.MCOPY,
+		vm.RETURN, vm.REVERT, vm.SELFDESTRUCT, vm.INVALID,
+		vm.RJUMP, vm.RJUMPI, vm.RJUMPV, vm.CALLF, vm.RETF, vm.JUMPF, vm.RETURNCONTRACT:
+		return 0
+	}
+	return 1
+}
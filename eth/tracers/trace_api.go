@@ -0,0 +1,310 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// maxTraceFilterBlocks is the maximum number of blocks trace_filter is willing
+// to re-execute in a single request.
+const maxTraceFilterBlocks = 1000
+
+// The trace types which can be requested from trace_replayBlockTransactions.
+const (
+	traceTypeTrace     = "trace"
+	traceTypeStateDiff = "stateDiff"
+	traceTypeVMTrace   = "vmTrace"
+)
+
+// flatCallConfig is the configuration of the flatCallTracer used by the trace
+// namespace, reporting errors the same way as Parity.
+var flatCallConfig = json.RawMessage(`{"convertParityErrors": true}`)
+
+// TraceAPI is the collection of Parity-compatible tracing APIs exposed over the
+// trace namespace. The traces are produced by the flatCallTracer, stateDiffTracer
+// and vmTraceTracer native tracers, which must be registered.
+//
+// Block rewards are not reported, as they are not executed by the EVM.
+type TraceAPI struct {
+	api *API
+}
+
+// NewTraceAPI creates a new API definition for the trace namespace.
+func NewTraceAPI(backend Backend) *TraceAPI {
+	return &TraceAPI{api: NewAPI(backend)}
+}
+
+// TraceResults is the result of replaying a transaction with
+// trace_replayBlockTransactions. Trace types which weren't requested are nil.
+type TraceResults struct {
+	Output          hexutil.Bytes   `json:"output"`
+	StateDiff       json.RawMessage `json:"stateDiff"`
+	Trace           json.RawMessage `json:"trace"`
+	VMTrace         json.RawMessage `json:"vmTrace"`
+	TransactionHash common.Hash     `json:"transactionHash"`
+}
+
+// TraceFilterArgs are the arguments of trace_filter.
+type TraceFilterArgs struct {
+	FromBlock   *rpc.BlockNumber `json:"fromBlock"`
+	ToBlock     *rpc.BlockNumber `json:"toBlock"`
+	FromAddress []common.Address `json:"fromAddress"`
+	ToAddress   []common.Address `json:"toAddress"`
+	After       *uint64          `json:"after"`
+	Count       *uint64          `json:"count"`
+}
+
+// Block returns the flattened call traces of all transactions in a block.
+func (api *TraceAPI) Block(ctx context.Context, number rpc.BlockNumber) ([]json.RawMessage, error) {
+	block, err := api.api.blockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	return api.blockTraces(ctx, block)
+}
+
+// Transaction returns the flattened call traces of a transaction.
+func (api *TraceAPI) Transaction(ctx context.Context, hash common.Hash) ([]json.RawMessage, error) {
+	tracer := "flatCallTracer"
+	res, err := api.api.TraceTransaction(ctx, hash, &TraceConfig{Tracer: &tracer, TracerConfig: flatCallConfig})
+	if err != nil {
+		return nil, err
+	}
+	return decodeFlatTraces(res)
+}
+
+// ReplayBlockTransactions replays all transactions in a block, and returns the
+// requested trace types (trace, stateDiff and vmTrace) for each of them.
+func (api *TraceAPI) ReplayBlockTransactions(ctx context.Context, number rpc.BlockNumber, traceTypes []string) ([]*TraceResults, error) {
+	// The top call is always traced, to retrieve the transaction output.
+	tracers := map[string]json.RawMessage{
+		"callTracer": json.RawMessage(`{"onlyTopCall": true}`),
+	}
+	for _, typ := range traceTypes {
+		switch typ {
+		case traceTypeTrace:
+			tracers["flatCallTracer"] = flatCallConfig
+		case traceTypeStateDiff:
+			tracers["stateDiffTracer"] = nil
+		case traceTypeVMTrace:
+			tracers["vmTraceTracer"] = nil
+		default:
+			return nil, fmt.Errorf("unsupported trace type %q", typ)
+		}
+	}
+	config, err := json.Marshal(tracers)
+	if err != nil {
+		return nil, err
+	}
+	block, err := api.api.blockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	tracer := "muxTracer"
+	traces, err := api.api.traceBlock(ctx, block, &TraceConfig{Tracer: &tracer, TracerConfig: config})
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*TraceResults, len(traces))
+	for i, trace := range traces {
+		var res struct {
+			Call struct {
+				Output hexutil.Bytes `json:"output"`
+			} `json:"callTracer"`
+			Trace     json.RawMessage `json:"flatCallTracer"`
+			StateDiff json.RawMessage `json:"stateDiffTracer"`
+			VMTrace   json.RawMessage `json:"vmTraceTracer"`
+		}
+		if err := decodeTraceResult(trace.Result, &res); err != nil {
+			return nil, err
+		}
+		results[i] = &TraceResults{
+			Output:          res.Call.Output,
+			StateDiff:       res.StateDiff,
+			Trace:           res.Trace,
+			VMTrace:         res.VMTrace,
+			TransactionHash: trace.TxHash,
+		}
+		if results[i].Output == nil {
+			results[i].Output = hexutil.Bytes{}
+		}
+	}
+	return results, nil
+}
+
+// Filter returns the flattened call traces in a range of blocks which were sent
+// from one of the given fromAddress, and to one of the given toAddress. An
+// empty address list matches any address.
+//
+// The range ends at the latest block if toBlock is omitted, and consists of the
+// last block only if fromBlock is omitted, as an open range from the genesis
+// would exceed the maximum range on any live chain.
+func (api *TraceAPI) Filter(ctx context.Context, args TraceFilterArgs) ([]json.RawMessage, error) {
+	var from, to *types.Block
+	var err error
+	if args.ToBlock == nil {
+		to, err = api.api.blockByNumber(ctx, rpc.LatestBlockNumber)
+	} else {
+		to, err = api.api.blockByNumber(ctx, *args.ToBlock)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if args.FromBlock == nil {
+		from = to
+	} else {
+		from, err = api.api.blockByNumber(ctx, *args.FromBlock)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if from.NumberU64() > to.NumberU64() {
+		return nil, errors.New("invalid block range")
+	}
+	if n := to.NumberU64() - from.NumberU64() + 1; n > maxTraceFilterBlocks {
+		return nil, fmt.Errorf("block range too large: %d > %d", n, maxTraceFilterBlocks)
+	}
+	var (
+		after   uint64
+		matches []json.RawMessage
+	)
+	if args.After != nil {
+		after = *args.After
+	}
+	for number := from.NumberU64(); number <= to.NumberU64(); number++ {
+		if number == 0 {
+			continue // genesis is not traceable
+		}
+		if args.Count != nil && uint64(len(matches)) >= *args.Count {
+			break
+		}
+		block, err := api.api.blockByNumber(ctx, rpc.BlockNumber(number))
+		if err != nil {
+			return nil, err
+		}
+		traces, err := api.blockTraces(ctx, block)
+		if err != nil {
+			return nil, err
+		}
+		for _, trace := range traces {
+			ok, err := matchFlatTrace(trace, args.FromAddress, args.ToAddress)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			if after > 0 {
+				after--
+				continue
+			}
+			if args.Count != nil && uint64(len(matches)) >= *args.Count {
+				break
+			}
+			matches = append(matches, trace)
+		}
+	}
+	if matches == nil {
+		matches = []json.RawMessage{}
+	}
+	return matches, nil
+}
+
+// blockTraces returns the flattened call traces of all transactions in a block.
+func (api *TraceAPI) blockTraces(ctx context.Context, block *types.Block) ([]json.RawMessage, error) {
+	tracer := "flatCallTracer"
+	traces, err := api.api.traceBlock(ctx, block, &TraceConfig{Tracer: &tracer, TracerConfig: flatCallConfig})
+	if err != nil {
+		return nil, err
+	}
+	flat := []json.RawMessage{}
+	for _, trace := range traces {
+		frames, err := decodeFlatTraces(trace.Result)
+		if err != nil {
+			return nil, err
+		}
+		flat = append(flat, frames...)
+	}
+	return flat, nil
+}
+
+// decodeTraceResult decodes the result of a native tracer.
+func decodeTraceResult(res interface{}, v interface{}) error {
+	raw, ok := res.(json.RawMessage)
+	if !ok {
+		return fmt.Errorf("unexpected trace result type %T", res)
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// decodeFlatTraces splits the result of the flatCallTracer into its frames.
+func decodeFlatTraces(res interface{}) ([]json.RawMessage, error) {
+	var frames []json.RawMessage
+	if err := decodeTraceResult(res, &frames); err != nil {
+		return nil, err
+	}
+	return frames, nil
+}
+
+// matchFlatTrace reports whether the sender and recipient of a flattened call
+// frame are contained in the given address lists.
+func matchFlatTrace(trace json.RawMessage, fromAddrs, toAddrs []common.Address) (bool, error) {
+	if len(fromAddrs) == 0 && len(toAddrs) == 0 {
+		return true, nil
+	}
+	var frame struct {
+		Action struct {
+			From           *common.Address `json:"from"`
+			To             *common.Address `json:"to"`
+			SelfDestructed *common.Address `json:"address"`
+			RefundAddress  *common.Address `json:"refundAddress"`
+		} `json:"action"`
+		Result *struct {
+			Address *common.Address `json:"address"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(trace, &frame); err != nil {
+		return false, err
+	}
+	// Self-destructs are sent from the destructed contract to the beneficiary,
+	// creations are sent to the created contract.
+	from, to := frame.Action.From, frame.Action.To
+	if frame.Action.SelfDestructed != nil {
+		from, to = frame.Action.SelfDestructed, frame.Action.RefundAddress
+	}
+	if to == nil && frame.Result != nil {
+		to = frame.Result.Address
+	}
+	return matchAddress(from, fromAddrs) && matchAddress(to, toAddrs), nil
+}
+
+func matchAddress(addr *common.Address, addrs []common.Address) bool {
+	if len(addrs) == 0 {
+		return true
+	}
+	return addr != nil && slices.Contains(addrs, *addr)
+}
@@ -0,0 +1,258 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/program"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	_ "github.com/ethereum/go-ethereum/eth/tracers/native"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type flatTrace struct {
+	Action struct {
+		From *common.Address `json:"from"`
+		To   *common.Address `json:"to"`
+	} `json:"action"`
+	TraceAddress    []int       `json:"traceAddress"`
+	TransactionHash common.Hash `json:"transactionHash"`
+	Type            string      `json:"type"`
+}
+
+// newTraceTestAPI creates a chain of two blocks. The first one contains a call
+// to a contract calling into another, and a transfer. The second one contains
+// a single transfer.
+func newTraceTestAPI(t *testing.T) (*tracers.TraceAPI, []common.Address, []*types.Transaction) {
+	var (
+		keys     = make([]*ecdsa.PrivateKey, 3)
+		addrs    = make([]common.Address, 3)
+		contract = common.HexToAddress("0xc0de")
+		callee   = common.HexToAddress("0xca11ee")
+		signer   = types.HomesteadSigner{}
+		txs      []*types.Transaction
+	)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		addrs[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+	}
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: types.GenesisAlloc{
+			addrs[0]: {Balance: big.NewInt(params.Ether)},
+			addrs[1]: {Balance: big.NewInt(params.Ether)},
+			contract: {Code: program.New().
+				Call(nil, callee, 0, 0, 0, 0, 0).Op(vm.POP).
+				Push(42).Push(0).Op(vm.MSTORE).
+				Return(0, 32).Bytes()},
+			callee: {Code: program.New().Sstore(1, 1).Bytes()},
+		},
+	}
+	backend := tracers.NewTestBackend(t, 2, genesis, func(i int, b *core.BlockGen) {
+		switch i {
+		case 0:
+			tx1, _ := types.SignTx(types.NewTx(&types.LegacyTx{Nonce: 0, To: &contract, Gas: 100_000, GasPrice: b.BaseFee()}), signer, keys[0])
+			tx2, _ := types.SignTx(types.NewTx(&types.LegacyTx{Nonce: 1, To: &addrs[2], Value: big.NewInt(1000), Gas: params.TxGas, GasPrice: b.BaseFee()}), signer, keys[0])
+			b.AddTx(tx1)
+			b.AddTx(tx2)
+			txs = append(txs, tx1, tx2)
+		case 1:
+			tx, _ := types.SignTx(types.NewTx(&types.LegacyTx{Nonce: 0, To: &addrs[2], Value: big.NewInt(1000), Gas: params.TxGas, GasPrice: b.BaseFee()}), signer, keys[1])
+			b.AddTx(tx)
+			txs = append(txs, tx)
+		}
+	})
+	return tracers.NewTraceAPI(backend), append(addrs, contract, callee), txs
+}
+
+func decodeFlatTraces(t *testing.T, raw []json.RawMessage) []*flatTrace {
+	t.Helper()
+	traces := make([]*flatTrace, len(raw))
+	for i, r := range raw {
+		if err := json.Unmarshal(r, &traces[i]); err != nil {
+			t.Fatalf("invalid trace %s: %v", r, err)
+		}
+	}
+	return traces
+}
+
+func TestTraceBlockAndTransaction(t *testing.T) {
+	t.Parallel()
+	api, addrs, txs := newTraceTestAPI(t)
+
+	raw, err := api.Block(context.Background(), rpc.BlockNumber(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	traces := decodeFlatTraces(t, raw)
+	if len(traces) != 3 {
+		t.Fatalf("wrong number of traces: have %d, want 3", len(traces))
+	}
+	if traces[1].TransactionHash != txs[0].Hash() || len(traces[1].TraceAddress) != 1 || *traces[1].Action.To != addrs[4] {
+		t.Errorf("wrong subcall trace: %s", raw[1])
+	}
+	if traces[2].TransactionHash != txs[1].Hash() || *traces[2].Action.To != addrs[2] {
+		t.Errorf("wrong transfer trace: %s", raw[2])
+	}
+	raw, err = api.Transaction(context.Background(), txs[0].Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(raw) != 2 {
+		t.Fatalf("wrong number of transaction traces: have %d, want 2", len(raw))
+	}
+	if _, err := api.Block(context.Background(), rpc.BlockNumber(0)); err == nil {
+		t.Error("expected error tracing genesis")
+	}
+}
+
+func TestTraceReplayBlockTransactions(t *testing.T) {
+	t.Parallel()
+	api, addrs, txs := newTraceTestAPI(t)
+
+	results, err := api.ReplayBlockTransactions(context.Background(), rpc.BlockNumber(1), []string{"trace", "stateDiff", "vmTrace"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("wrong number of results: have %d, want 2", len(results))
+	}
+	res := results[0]
+	if res.TransactionHash != txs[0].Hash() {
+		t.Errorf("wrong transaction hash: have %x, want %x", res.TransactionHash, txs[0].Hash())
+	}
+	if want := common.BigToHash(big.NewInt(42)); common.BytesToHash(res.Output) != want || len(res.Output) != 32 {
+		t.Errorf("wrong output: %x", res.Output)
+	}
+	var trace []json.RawMessage
+	if err := json.Unmarshal(res.Trace, &trace); err != nil || len(trace) != 2 {
+		t.Errorf("wrong trace: %s", res.Trace)
+	}
+	var diff map[common.Address]json.RawMessage
+	if err := json.Unmarshal(res.StateDiff, &diff); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := diff[addrs[4]]; !ok {
+		t.Errorf("missing storage change of callee in state diff: %s", res.StateDiff)
+	}
+	var vmTrace struct {
+		Ops []struct {
+			Sub *struct {
+				Ops []json.RawMessage `json:"ops"`
+			} `json:"sub"`
+		} `json:"ops"`
+	}
+	if err := json.Unmarshal(res.VMTrace, &vmTrace); err != nil {
+		t.Fatal(err)
+	}
+	var subs int
+	for _, op := range vmTrace.Ops {
+		if op.Sub != nil {
+			subs++
+			if len(op.Sub.Ops) == 0 {
+				t.Error("empty sub trace")
+			}
+		}
+	}
+	if subs != 1 {
+		t.Errorf("wrong number of sub traces: have %d, want 1", subs)
+	}
+
+	// Trace types which are not requested are omitted.
+	results, err = api.ReplayBlockTransactions(context.Background(), rpc.BlockNumber(1), []string{"trace"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[1].StateDiff != nil || results[1].VMTrace != nil || results[1].Trace == nil {
+		t.Errorf("wrong trace types in result: %+v", results[1])
+	}
+	if _, err := api.ReplayBlockTransactions(context.Background(), rpc.BlockNumber(1), []string{"unknown"}); err == nil {
+		t.Error("expected error for unknown trace type")
+	}
+}
+
+func TestTraceFilter(t *testing.T) {
+	t.Parallel()
+	api, addrs, txs := newTraceTestAPI(t)
+
+	var (
+		from  = rpc.BlockNumber(1)
+		to    = rpc.BlockNumber(2)
+		one   = uint64(1)
+		tests = []struct {
+			args tracers.TraceFilterArgs
+			want []common.Hash
+		}{
+			{
+				args: tracers.TraceFilterArgs{FromBlock: &from, ToBlock: &to},
+				want: []common.Hash{txs[0].Hash(), txs[0].Hash(), txs[1].Hash(), txs[2].Hash()},
+			},
+			{
+				args: tracers.TraceFilterArgs{FromBlock: &from, ToBlock: &to, FromAddress: []common.Address{addrs[1]}},
+				want: []common.Hash{txs[2].Hash()},
+			},
+			{
+				args: tracers.TraceFilterArgs{FromBlock: &from, ToBlock: &to, ToAddress: []common.Address{addrs[4]}},
+				want: []common.Hash{txs[0].Hash()},
+			},
+			{
+				args: tracers.TraceFilterArgs{FromBlock: &from, ToBlock: &to, FromAddress: []common.Address{addrs[0]}, ToAddress: []common.Address{addrs[2]}},
+				want: []common.Hash{txs[1].Hash()},
+			},
+			{
+				args: tracers.TraceFilterArgs{FromBlock: &from, ToBlock: &to, ToAddress: []common.Address{addrs[2]}, After: &one, Count: &one},
+				want: []common.Hash{txs[2].Hash()},
+			},
+			{
+				// Missing fromBlock defaults to toBlock
+				args: tracers.TraceFilterArgs{ToBlock: &from},
+				want: []common.Hash{txs[0].Hash(), txs[0].Hash(), txs[1].Hash()},
+			},
+			{
+				// Missing range defaults to the latest block
+				args: tracers.TraceFilterArgs{},
+				want: []common.Hash{txs[2].Hash()},
+			},
+		}
+	)
+	for i, test := range tests {
+		raw, err := api.Filter(context.Background(), test.args)
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		traces := decodeFlatTraces(t, raw)
+		if len(traces) != len(test.want) {
+			t.Fatalf("test %d: wrong number of traces: have %d, want %d", i, len(traces), len(test.want))
+		}
+		for j, trace := range traces {
+			if trace.TransactionHash != test.want[j] {
+				t.Errorf("test %d, trace %d: wrong transaction: have %x, want %x", i, j, trace.TransactionHash, test.want[j])
+			}
+		}
+	}
+}
@@ -277,6 +277,48 @@ func (api *TxPoolAPI) Inspect() map[string]map[string]map[string]string {
 	return content
 }
 
+// RPCTxExplanation represents the standing of a pooled transaction, explaining
+// why it may not be executable or may get evicted.
+type RPCTxExplanation struct {
+	Hash                common.Hash    `json:"hash"`
+	Nonce               hexutil.Uint64 `json:"nonce"`
+	Status              string         `json:"status"`
+	NonceGap            hexutil.Uint64 `json:"nonceGap"`
+	CumulativeCost      *hexutil.Big   `json:"cumulativeCost"`
+	InsufficientBalance bool           `json:"insufficientBalance"`
+	FeeCapTooLow        bool           `json:"feeCapBelowBaseFee"`
+	BlobFeeCapTooLow    bool           `json:"blobFeeCapBelowBlobFee"`
+	Evictable           bool           `json:"evictable"`
+	EvictionRank        hexutil.Uint64 `json:"evictionRank"`
+}
+
+// Explain returns the standing of the transactions of an address in the pool,
+// sorted by nonce, detailing why they may be stuck or at risk of eviction.
+func (api *TxPoolAPI) Explain(addr common.Address) []*RPCTxExplanation {
+	explanations := api.b.TxPoolExplain(addr)
+
+	result := make([]*RPCTxExplanation, 0, len(explanations))
+	for _, explanation := range explanations {
+		status := "queued"
+		if explanation.Pending {
+			status = "pending"
+		}
+		result = append(result, &RPCTxExplanation{
+			Hash:                explanation.Hash,
+			Nonce:               hexutil.Uint64(explanation.Nonce),
+			Status:              status,
+			NonceGap:            hexutil.Uint64(explanation.NonceGap),
+			CumulativeCost:      (*hexutil.Big)(explanation.Cost.ToBig()),
+			InsufficientBalance: explanation.InsufficientBalance,
+			FeeCapTooLow:        explanation.FeeCapTooLow,
+			BlobFeeCapTooLow:    explanation.BlobFeeCapTooLow,
+			Evictable:           explanation.Evictable,
+			EvictionRank:        hexutil.Uint64(explanation.EvictionRank),
+		})
+	}
+	return result
+}
+
 // EthereumAccountAPI provides an API to access accounts managed by this node.
 // It offers only methods that can retrieve accounts.
 type EthereumAccountAPI struct {
@@ -438,6 +480,134 @@ func (api *BlockChainAPI) GetProof(ctx context.Context, address common.Address,
 	}, statedb.Error()
 }
 
+// MultiProofQuery is an account along with its storage keys to be proven by
+// GetMultiProof.
+type MultiProofQuery struct {
+	Address     common.Address `json:"address"`
+	StorageKeys []string       `json:"storageKeys"`
+}
+
+// MultiProofResult is the result of GetMultiProof. The proof contains the nodes
+// of the account trie and all involved storage tries, each of them only once.
+type MultiProofResult struct {
+	Proof    []string            `json:"proof"`
+	Accounts []MultiProofAccount `json:"accounts"`
+}
+
+// MultiProofAccount structs for GetMultiProof
+type MultiProofAccount struct {
+	Address     common.Address      `json:"address"`
+	Balance     *hexutil.Big        `json:"balance"`
+	CodeHash    common.Hash         `json:"codeHash"`
+	Nonce       hexutil.Uint64      `json:"nonce"`
+	StorageHash common.Hash         `json:"storageHash"`
+	Storage     []MultiProofStorage `json:"storage"`
+}
+
+type MultiProofStorage struct {
+	Key   string       `json:"key"`
+	Value *hexutil.Big `json:"value"`
+}
+
+// proofSet implements ethdb.KeyValueWriter and collects the proofs as
+// hex-strings, dropping the nodes already collected.
+type proofSet struct {
+	seen  map[string]struct{}
+	nodes []string
+}
+
+func (n *proofSet) Put(key []byte, value []byte) error {
+	if _, ok := n.seen[string(key)]; ok {
+		return nil
+	}
+	n.seen[string(key)] = struct{}{}
+	n.nodes = append(n.nodes, hexutil.Encode(value))
+	return nil
+}
+
+func (n *proofSet) Delete(key []byte) error {
+	panic("not supported")
+}
+
+// GetMultiProof returns a single Merkle-multiproof for a batch of accounts and
+// their storage keys. Unlike GetProof, the nodes shared by the paths of multiple
+// keys are only delivered once. The proof can be checked by trie.VerifyMultiProof
+// against the state root and the storage roots of the accounts.
+func (api *BlockChainAPI) GetMultiProof(ctx context.Context, queries []MultiProofQuery, blockNrOrHash rpc.BlockNumberOrHash) (*MultiProofResult, error) {
+	var (
+		keys       = make([][]common.Hash, len(queries))
+		keyLengths = make([][]int, len(queries))
+	)
+	// Deserialize all keys. This prevents state access on invalid input.
+	for i, query := range queries {
+		keys[i] = make([]common.Hash, len(query.StorageKeys))
+		keyLengths[i] = make([]int, len(query.StorageKeys))
+		for j, hexKey := range query.StorageKeys {
+			var err error
+			keys[i][j], keyLengths[i][j], err = decodeHash(hexKey)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	statedb, header, err := api.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	var (
+		proof       = &proofSet{seen: make(map[string]struct{})}
+		accounts    = make([]MultiProofAccount, len(queries))
+		accountKeys = make([][]byte, len(queries))
+	)
+	for i, query := range queries {
+		address := query.Address
+		storageRoot := statedb.GetStorageRoot(address)
+		storage := make([]MultiProofStorage, len(keys[i]))
+
+		// Create the multiproof for the storage keys of the account. The output
+		// key encoding follows GetProof.
+		hashedKeys := make([][]byte, len(keys[i]))
+		for j, key := range keys[i] {
+			var outputKey string
+			if keyLengths[i][j] != 32 {
+				outputKey = hexutil.EncodeBig(key.Big())
+			} else {
+				outputKey = hexutil.Encode(key[:])
+			}
+			storage[j] = MultiProofStorage{outputKey, (*hexutil.Big)(statedb.GetState(address, key).Big())}
+			hashedKeys[j] = crypto.Keccak256(key.Bytes())
+		}
+		if len(hashedKeys) > 0 && storageRoot != types.EmptyRootHash && storageRoot != (common.Hash{}) {
+			id := trie.StorageTrieID(header.Root, crypto.Keccak256Hash(address.Bytes()), storageRoot)
+			st, err := trie.NewStateTrie(id, statedb.Database().TrieDB())
+			if err != nil {
+				return nil, err
+			}
+			if err := st.ProveMulti(hashedKeys, proof); err != nil {
+				return nil, err
+			}
+		}
+		accounts[i] = MultiProofAccount{
+			Address:     address,
+			Balance:     (*hexutil.Big)(statedb.GetBalance(address).ToBig()),
+			CodeHash:    statedb.GetCodeHash(address),
+			Nonce:       hexutil.Uint64(statedb.GetNonce(address)),
+			StorageHash: storageRoot,
+			Storage:     storage,
+		}
+		accountKeys[i] = crypto.Keccak256(address.Bytes())
+	}
+	// Create the multiproof for the accounts.
+	tr, err := trie.NewStateTrie(trie.StateTrieID(header.Root), statedb.Database().TrieDB())
+	if err != nil {
+		return nil, err
+	}
+	if err := tr.ProveMulti(accountKeys, proof); err != nil {
+		return nil, err
+	}
+	return &MultiProofResult{Proof: proof.nodes, Accounts: accounts}, statedb.Error()
+}
+
 // decodeHash parses a hex-encoded 32-byte hash. The input may optionally
 // be prefixed by 0x and can have a byte length up to 32.
 func decodeHash(s string) (h common.Hash, inputLength int, err error) {
@@ -1408,6 +1578,12 @@ func (api *TransactionAPI) sign(addr common.Address, tx *types.Transaction) (*ty
 
 // SubmitTransaction is a helper function that submits tx to txPool and logs a message.
 func SubmitTransaction(ctx context.Context, b Backend, tx *types.Transaction) (common.Hash, error) {
+	return submitTransaction(ctx, b, tx, false)
+}
+
+// submitTransaction is a helper function that submits tx to txPool, either for
+// public propagation or privately for the local block production.
+func submitTransaction(ctx context.Context, b Backend, tx *types.Transaction, private bool) (common.Hash, error) {
 	// If the transaction fee cap is already specified, ensure the
 	// fee of the given transaction is _reasonable_.
 	if err := checkTxFee(tx.GasPrice(), tx.Gas(), b.RPCTxFeeCap()); err != nil {
@@ -1417,7 +1593,11 @@ func SubmitTransaction(ctx context.Context, b Backend, tx *types.Transaction) (c
 		// Ensure only eip155 signed transactions are submitted if EIP155Required is set.
 		return common.Hash{}, errors.New("only replay-protected (EIP-155) transactions allowed over RPC")
 	}
-	if err := b.SendTx(ctx, tx); err != nil {
+	send := b.SendTx
+	if private {
+		send = b.SendPrivateTx
+	}
+	if err := send(ctx, tx); err != nil {
 		return common.Hash{}, err
 	}
 	// Print a log with full tx details for manual investigations and interventions
@@ -1430,9 +1610,9 @@ func SubmitTransaction(ctx context.Context, b Backend, tx *types.Transaction) (c
 
 	if tx.To() == nil {
 		addr := crypto.CreateAddress(from, tx.Nonce())
-		log.Info("Submitted contract creation", "hash", tx.Hash().Hex(), "from", from, "nonce", tx.Nonce(), "contract", addr.Hex(), "value", tx.Value())
+		log.Info("Submitted contract creation", "hash", tx.Hash().Hex(), "from", from, "nonce", tx.Nonce(), "contract", addr.Hex(), "value", tx.Value(), "private", private)
 	} else {
-		log.Info("Submitted transaction", "hash", tx.Hash().Hex(), "from", from, "nonce", tx.Nonce(), "recipient", tx.To(), "value", tx.Value())
+		log.Info("Submitted transaction", "hash", tx.Hash().Hex(), "from", from, "nonce", tx.Nonce(), "recipient", tx.To(), "value", tx.Value(), "private", private)
 	}
 	return tx.Hash(), nil
 }
@@ -1501,6 +1681,18 @@ func (api *TransactionAPI) SendRawTransaction(ctx context.Context, input hexutil
 	return SubmitTransaction(ctx, api.b, tx)
 }
 
+// SendPrivateTransaction will add the signed transaction to the transaction pool
+// for inclusion by the local block production only. The transaction is never
+// announced or served to the network, and it's dropped from the pool if it's not
+// included within the configured number of blocks.
+func (api *TransactionAPI) SendPrivateTransaction(ctx context.Context, input hexutil.Bytes) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(input); err != nil {
+		return common.Hash{}, err
+	}
+	return submitTransaction(ctx, api.b, tx, true)
+}
+
 // Sign calculates an ECDSA signature for:
 // keccak256("\x19Ethereum Signed Message:\n" + len(message) + message).
 //
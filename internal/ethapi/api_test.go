@@ -48,15 +48,19 @@ import (
 	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/crypto/kzg4844"
 	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/internal/blocktest"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
 	"github.com/holiman/uint256"
 	"github.com/stretchr/testify/require"
 )
@@ -590,6 +594,9 @@ func (b testBackend) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) even
 func (b testBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
 	panic("implement me")
 }
+func (b testBackend) SendPrivateTx(ctx context.Context, signedTx *types.Transaction) error {
+	panic("implement me")
+}
 func (b testBackend) GetTransaction(ctx context.Context, txHash common.Hash) (bool, *types.Transaction, common.Hash, uint64, uint64, error) {
 	tx, blockHash, blockNumber, index := rawdb.ReadTransaction(b.db, txHash)
 	return true, tx, blockHash, blockNumber, index, nil
@@ -606,9 +613,15 @@ func (b testBackend) TxPoolContent() (map[common.Address][]*types.Transaction, m
 func (b testBackend) TxPoolContentFrom(addr common.Address) ([]*types.Transaction, []*types.Transaction) {
 	panic("implement me")
 }
+func (b testBackend) TxPoolExplain(addr common.Address) []*txpool.TxExplanation {
+	panic("implement me")
+}
 func (b testBackend) SubscribeNewTxsEvent(events chan<- core.NewTxsEvent) event.Subscription {
 	panic("implement me")
 }
+func (b testBackend) SubscribeTxPoolEvents(ch chan<- []txpool.TxEvent) event.Subscription {
+	panic("implement me")
+}
 func (b testBackend) ChainConfig() *params.ChainConfig { return b.chain.Config() }
 func (b testBackend) Engine() consensus.Engine         { return b.chain.Engine() }
 func (b testBackend) GetLogs(ctx context.Context, blockHash common.Hash, number uint64) ([][]*types.Log, error) {
@@ -3423,3 +3436,151 @@ func testRPCResponseWithFile(t *testing.T, testid int, result interface{}, rpc s
 func addressToHash(a common.Address) common.Hash {
 	return common.BytesToHash(a.Bytes())
 }
+
+func TestGetMultiProof(t *testing.T) {
+	t.Parallel()
+
+	var (
+		accounts = []common.Address{common.HexToAddress("0xc0de"), common.HexToAddress("0xc0df"), common.HexToAddress("0xdead")}
+		genesis  = &core.Genesis{
+			Config: params.MergedTestChainConfig,
+			Alloc: types.GenesisAlloc{
+				accounts[0]: {Balance: big.NewInt(1), Storage: map[common.Hash]common.Hash{{0x1}: {0x1}, {0x2}: {0x2}}},
+				accounts[1]: {Nonce: 1, Storage: map[common.Hash]common.Hash{{0x1}: {0x3}}},
+			},
+		}
+	)
+	b := newTestBackend(t, 1, genesis, beacon.New(ethash.NewFaker()), func(i int, b *core.BlockGen) {
+		b.SetPoS()
+	})
+	api := NewBlockChainAPI(b)
+	res, err := api.GetMultiProof(context.Background(), []MultiProofQuery{
+		{Address: accounts[0], StorageKeys: []string{common.Hash{0x1}.Hex(), common.Hash{0x2}.Hex(), "0x3"}},
+		{Address: accounts[1], StorageKeys: []string{common.Hash{0x1}.Hex()}},
+		{Address: accounts[2], StorageKeys: []string{"0x1"}},
+	}, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber))
+	if err != nil {
+		t.Fatalf("failed to get multiproof: %v", err)
+	}
+	// Verify the returned accounts and slots against the proof.
+	proof := memorydb.New()
+	for _, node := range res.Proof {
+		blob := hexutil.MustDecode(node)
+		proof.Put(crypto.Keccak256(blob), blob)
+	}
+	if proof.Len() != len(res.Proof) {
+		t.Fatalf("duplicated proof nodes, have %d, want %d", len(res.Proof), proof.Len())
+	}
+	var keys [][]byte
+	for _, account := range accounts {
+		keys = append(keys, crypto.Keccak256(account.Bytes()))
+	}
+	values, err := trie.VerifyMultiProof(b.chain.CurrentBlock().Root, keys, proof)
+	if err != nil {
+		t.Fatalf("failed to verify account multiproof: %v", err)
+	}
+	for i, account := range res.Accounts {
+		if account.Address != accounts[i] {
+			t.Fatalf("account %d: address mismatch, have %x, want %x", i, account.Address, accounts[i])
+		}
+		if values[i] == nil {
+			if account.StorageHash != (common.Hash{}) || account.Balance.ToInt().Sign() != 0 {
+				t.Fatalf("account %d: unexpected content of absent account: %+v", i, account)
+			}
+			continue
+		}
+		acc, err := types.FullAccount(values[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if acc.Root != account.StorageHash || acc.Nonce != uint64(account.Nonce) || acc.Balance.ToBig().Cmp(account.Balance.ToInt()) != 0 {
+			t.Fatalf("account %d: content mismatch, have %+v, want %+v", i, account, acc)
+		}
+		var slots [][]byte
+		for _, slot := range account.Storage {
+			key, _, _ := decodeHash(slot.Key)
+			slots = append(slots, crypto.Keccak256(key.Bytes()))
+		}
+		slotValues, err := trie.VerifyMultiProof(acc.Root, slots, proof)
+		if err != nil {
+			t.Fatalf("account %d: failed to verify storage multiproof: %v", i, err)
+		}
+		for j, slot := range account.Storage {
+			var want *big.Int
+			if len(slotValues[j]) == 0 {
+				want = new(big.Int)
+			} else {
+				_, content, _, _ := rlp.Split(slotValues[j])
+				want = new(big.Int).SetBytes(content)
+			}
+			if slot.Value.ToInt().Cmp(want) != 0 {
+				t.Fatalf("account %d slot %s: value mismatch, have %v, want %v", i, slot.Key, slot.Value, want)
+			}
+		}
+	}
+}
+
+// explainBackend is a mock backend serving canned transaction pool explanations.
+type explainBackend struct {
+	Backend
+	explanations map[common.Address][]*txpool.TxExplanation
+}
+
+func (b *explainBackend) TxPoolExplain(addr common.Address) []*txpool.TxExplanation {
+	return b.explanations[addr]
+}
+
+func TestTxPoolExplain(t *testing.T) {
+	t.Parallel()
+
+	var (
+		addr    = common.HexToAddress("0xc0de")
+		backend = &explainBackend{
+			explanations: map[common.Address][]*txpool.TxExplanation{
+				addr: {
+					{Hash: common.HexToHash("0x01"), Nonce: 0, Pending: true, Cost: uint256.NewInt(1000), Evictable: true, EvictionRank: 3},
+					{Hash: common.HexToHash("0x02"), Nonce: 2, NonceGap: 1, Cost: uint256.NewInt(3000), InsufficientBalance: true, FeeCapTooLow: true, BlobFeeCapTooLow: true},
+				},
+			},
+		}
+		api = NewTxPoolAPI(backend)
+	)
+	out, err := json.Marshal(api.Explain(addr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[
+		{
+			"hash": "0x0000000000000000000000000000000000000000000000000000000000000001",
+			"nonce": "0x0",
+			"status": "pending",
+			"nonceGap": "0x0",
+			"cumulativeCost": "0x3e8",
+			"insufficientBalance": false,
+			"feeCapBelowBaseFee": false,
+			"blobFeeCapBelowBlobFee": false,
+			"evictable": true,
+			"evictionRank": "0x3"
+		},
+		{
+			"hash": "0x0000000000000000000000000000000000000000000000000000000000000002",
+			"nonce": "0x2",
+			"status": "queued",
+			"nonceGap": "0x1",
+			"cumulativeCost": "0xbb8",
+			"insufficientBalance": true,
+			"feeCapBelowBaseFee": true,
+			"blobFeeCapBelowBlobFee": true,
+			"evictable": false,
+			"evictionRank": "0x0"
+		}
+	]`
+	require.JSONEq(t, want, string(out))
+
+	// Unknown accounts are explained with an empty list instead of null
+	out, err = json.Marshal(api.Explain(common.HexToAddress("0xdead")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	require.JSONEq(t, `[]`, string(out))
+}
@@ -121,6 +121,16 @@ web3._extend({
 			call: 'admin_importChain',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'pruneState',
+			call: 'admin_pruneState',
+			params: 1,
+			inputFormatter: [null]
+		}),
+		new web3._extend.Method({
+			name: 'stopPruneState',
+			call: 'admin_stopPruneState',
+		}),
 		new web3._extend.Method({
 			name: 'sleepBlocks',
 			call: 'admin_sleepBlocks',
@@ -172,6 +182,10 @@ web3._extend({
 			name: 'datadir',
 			getter: 'admin_datadir'
 		}),
+		new web3._extend.Property({
+			name: 'pruneStateStatus',
+			getter: 'admin_pruneStateStatus'
+		}),
 	]
 });
 `
@@ -404,6 +418,59 @@ web3._extend({
 			params: 3,
 			inputFormatter: [null, null, null]
 		}),
+		new web3._extend.Method({
+			name: 'startTransactionSession',
+			call: 'debug_startTransactionSession',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'startCallSession',
+			call: 'debug_startCallSession',
+			params: 3,
+			inputFormatter: [null, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'sessionStep',
+			call: 'debug_sessionStep',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'sessionContinue',
+			call: 'debug_sessionContinue',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'sessionSetBreakpoints',
+			call: 'debug_sessionSetBreakpoints',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'sessionStack',
+			call: 'debug_sessionStack',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'sessionMemory',
+			call: 'debug_sessionMemory',
+			params: 3
+		}),
+		new web3._extend.Method({
+			name: 'sessionStorage',
+			call: 'debug_sessionStorage',
+			params: 3,
+			inputFormatter: [null, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'sessionReturnData',
+			call: 'debug_sessionReturnData',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'stopSession',
+			call: 'debug_stopSession',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'preimage',
 			call: 'debug_preimage',
@@ -420,6 +487,12 @@ web3._extend({
 			call: 'debug_storageRangeAt',
 			params: 5,
 		}),
+		new web3._extend.Method({
+			name: 'executionWitness',
+			call: 'debug_executionWitness',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
 		new web3._extend.Method({
 			name: 'getModifiedAccountsByNumber',
 			call: 'debug_getModifiedAccountsByNumber',
@@ -468,6 +541,22 @@ web3._extend({
 			call: 'debug_getTrieFlushInterval',
 			params: 0
 		}),
+		new web3._extend.Method({
+			name: 'startTrieHeatmap',
+			call: 'debug_startTrieHeatmap',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'stopTrieHeatmap',
+			call: 'debug_stopTrieHeatmap',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'trieHeatmap',
+			call: 'debug_trieHeatmap',
+			params: 1,
+			inputFormatter: [null]
+		}),
 	],
 	properties: []
 });
@@ -561,6 +650,18 @@ web3._extend({
 			params: 3,
 			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, web3._extend.formatters.inputBlockNumberFormatter]
 		}),
+		new web3._extend.Method({
+			name: 'sendPrivateTransaction',
+			call: 'eth_sendPrivateTransaction',
+			params: 1,
+			inputFormatter: [null]
+		}),
+		new web3._extend.Method({
+			name: 'getMultiProof',
+			call: 'eth_getMultiProof',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
 		new web3._extend.Method({
 			name: 'createAccessList',
 			call: 'eth_createAccessList',
@@ -698,6 +799,11 @@ web3._extend({
 			call: 'txpool_contentFrom',
 			params: 1,
 		}),
+		new web3._extend.Method({
+			name: 'explain',
+			call: 'txpool_explain',
+			params: 1,
+		}),
 	]
 });
 `
@@ -320,6 +320,10 @@ type ChainConfig struct {
 	PragueTime   *uint64 `json:"pragueTime,omitempty"`   // Prague switch time (nil = no fork, 0 = already on prague)
 	VerkleTime   *uint64 `json:"verkleTime,omitempty"`   // Verkle switch time (nil = no fork, 0 = already on verkle)
 
+	// Precompiles schedules the activation of custom precompiled contracts. The
+	// implementations are registered with the EVM and referenced by name.
+	Precompiles []*PrecompileConfig `json:"precompiles,omitempty"`
+
 	// TerminalTotalDifficulty is the amount of total difficulty reached by
 	// the network that triggers the consensus upgrade.
 	TerminalTotalDifficulty *big.Int `json:"terminalTotalDifficulty,omitempty"`
@@ -350,6 +354,31 @@ func (c CliqueConfig) String() string {
 	return fmt.Sprintf("clique(period: %d, epoch: %d)", c.Period, c.Epoch)
 }
 
+// PrecompileConfig schedules the activation of a custom precompiled contract,
+// which is deployed at the given address once the activation time is reached.
+type PrecompileConfig struct {
+	Name    string         `json:"name"`           // Name the implementation is registered under
+	Address common.Address `json:"address"`        // Address to deploy the precompile at
+	Time    *uint64        `json:"time,omitempty"` // Activation time (nil = never, 0 = from genesis)
+}
+
+// IsActive returns whether the precompile is active at the given time.
+func (p *PrecompileConfig) IsActive(time uint64) bool {
+	return isTimestampForked(p.Time, time)
+}
+
+// precompileRegistered reports whether an implementation of a custom precompile
+// is registered under the given name. The implementations live in core/vm, which
+// installs the lookup, as this package can't depend on it.
+var precompileRegistered func(name string) bool
+
+// SetPrecompileRegistry installs the lookup used to check that the custom
+// precompiles scheduled by chain configurations have an implementation. It is
+// meant to be called by core/vm only.
+func SetPrecompileRegistry(registered func(name string) bool) {
+	precompileRegistered = registered
+}
+
 // Description returns a human-readable description of ChainConfig.
 func (c *ChainConfig) Description() string {
 	var banner string
@@ -422,6 +451,17 @@ func (c *ChainConfig) Description() string {
 	if c.VerkleTime != nil {
 		banner += fmt.Sprintf(" - Verkle:                      @%-10v\n", *c.VerkleTime)
 	}
+	if len(c.Precompiles) > 0 {
+		banner += "\n"
+		banner += "Custom precompiles (timestamp based):\n"
+		for _, p := range c.Precompiles {
+			if p.Time != nil {
+				banner += fmt.Sprintf(" - %-28s @%-10v (%v)\n", p.Name+":", *p.Time, p.Address)
+			} else {
+				banner += fmt.Sprintf(" - %-28s %-11s (%v)\n", p.Name+":", "disabled", p.Address)
+			}
+		}
+	}
 	return banner
 }
 
@@ -620,6 +660,21 @@ func (c *ChainConfig) CheckConfigForkOrder() error {
 			lastFork = cur
 		}
 	}
+	// Custom precompiles are not ordered, but must be uniquely identified.
+	seen := make(map[common.Address]bool)
+	for _, p := range c.Precompiles {
+		if p.Name == "" {
+			return fmt.Errorf("invalid precompile config: missing name for precompile at %v", p.Address)
+		}
+		if seen[p.Address] {
+			return fmt.Errorf("invalid precompile config: multiple precompiles at %v", p.Address)
+		}
+		seen[p.Address] = true
+
+		if precompileRegistered != nil && !precompileRegistered(p.Name) {
+			return fmt.Errorf("invalid precompile config: precompile %q at %v is not registered", p.Name, p.Address)
+		}
+	}
 	return nil
 }
 
@@ -691,6 +746,45 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, headNumber *big.Int,
 	if isForkTimestampIncompatible(c.VerkleTime, newcfg.VerkleTime, headTimestamp) {
 		return newTimestampCompatError("Verkle fork timestamp", c.VerkleTime, newcfg.VerkleTime)
 	}
+	if err := checkPrecompilesCompatible(c.Precompiles, newcfg.Precompiles, headTimestamp); err != nil {
+		return err
+	}
+	return nil
+}
+
+// checkPrecompilesCompatible checks whether the activation of any custom precompile
+// which is already active (in either configuration) has been rescheduled, or
+// whether an active precompile has been replaced by a different implementation.
+func checkPrecompilesCompatible(stored, updated []*PrecompileConfig, headTimestamp uint64) *ConfigCompatError {
+	find := func(list []*PrecompileConfig, addr common.Address) *PrecompileConfig {
+		for _, p := range list {
+			if p.Address == addr {
+				return p
+			}
+		}
+		return &PrecompileConfig{Address: addr}
+	}
+	check := func(s, n *PrecompileConfig) *ConfigCompatError {
+		if isForkTimestampIncompatible(s.Time, n.Time, headTimestamp) {
+			return newTimestampCompatError(fmt.Sprintf("precompile %v activation timestamp", s.Address), s.Time, n.Time)
+		}
+		// The activation times match, so rewind to the activation if the
+		// implementation changed.
+		if s.IsActive(headTimestamp) && s.Name != n.Name {
+			return newTimestampCompatError(fmt.Sprintf("precompile %v implementation", s.Address), s.Time, n.Time)
+		}
+		return nil
+	}
+	for _, s := range stored {
+		if err := check(s, find(updated, s.Address)); err != nil {
+			return err
+		}
+	}
+	for _, n := range updated {
+		if err := check(find(stored, n.Address), n); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -863,6 +957,8 @@ type Rules struct {
 	IsBerlin, IsLondon                                      bool
 	IsMerge, IsShanghai, IsCancun, IsPrague                 bool
 	IsVerkle                                                bool
+
+	Precompiles []*PrecompileConfig // Custom precompiles active at this point
 }
 
 // Rules ensures c's ChainID is not nil.
@@ -893,5 +989,17 @@ func (c *ChainConfig) Rules(num *big.Int, isMerge bool, timestamp uint64) Rules
 		IsPrague:         isMerge && c.IsPrague(num, timestamp),
 		IsVerkle:         isVerkle,
 		IsEIP4762:        isVerkle,
+		Precompiles:      c.activePrecompiles(timestamp),
+	}
+}
+
+// activePrecompiles returns the custom precompiles active at the given time.
+func (c *ChainConfig) activePrecompiles(time uint64) []*PrecompileConfig {
+	var active []*PrecompileConfig
+	for _, p := range c.Precompiles {
+		if p.IsActive(time) {
+			active = append(active, p)
+		}
 	}
+	return active
 }
@@ -23,6 +23,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/require"
 )
 
@@ -110,6 +111,34 @@ func TestCheckCompatible(t *testing.T) {
 				RewindToTime: 9,
 			},
 		},
+		{
+			stored:        &ChainConfig{Precompiles: []*PrecompileConfig{{Name: "p", Address: common.Address{0xaa}, Time: newUint64(10)}}},
+			new:           &ChainConfig{Precompiles: []*PrecompileConfig{{Name: "p", Address: common.Address{0xaa}, Time: newUint64(20)}}},
+			headTimestamp: 9,
+			wantErr:       nil,
+		},
+		{
+			stored:        &ChainConfig{},
+			new:           &ChainConfig{Precompiles: []*PrecompileConfig{{Name: "p", Address: common.Address{0xaa}, Time: newUint64(10)}}},
+			headTimestamp: 25,
+			wantErr: &ConfigCompatError{
+				What:         "precompile 0xaa00000000000000000000000000000000000000 activation timestamp",
+				StoredTime:   nil,
+				NewTime:      newUint64(10),
+				RewindToTime: 9,
+			},
+		},
+		{
+			stored:        &ChainConfig{Precompiles: []*PrecompileConfig{{Name: "p", Address: common.Address{0xaa}, Time: newUint64(10)}}},
+			new:           &ChainConfig{Precompiles: []*PrecompileConfig{{Name: "q", Address: common.Address{0xaa}, Time: newUint64(10)}}},
+			headTimestamp: 25,
+			wantErr: &ConfigCompatError{
+				What:         "precompile 0xaa00000000000000000000000000000000000000 implementation",
+				StoredTime:   newUint64(10),
+				NewTime:      newUint64(10),
+				RewindToTime: 9,
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -139,6 +168,28 @@ func TestConfigRules(t *testing.T) {
 	}
 }
 
+func TestCheckPrecompileOrder(t *testing.T) {
+	config := *MergedTestChainConfig
+	config.Precompiles = []*PrecompileConfig{
+		{Name: "a", Address: common.Address{0xaa}, Time: newUint64(10)},
+		{Name: "b", Address: common.Address{0xaa}},
+	}
+	if err := config.CheckConfigForkOrder(); err == nil {
+		t.Fatal("expected error for duplicate precompile address")
+	}
+	config.Precompiles[1].Address = common.Address{0xbb}
+	if err := config.CheckConfigForkOrder(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rules := config.Rules(common.Big0, true, 10)
+	if len(rules.Precompiles) != 1 || rules.Precompiles[0].Name != "a" {
+		t.Fatalf("wrong active precompiles: %v", rules.Precompiles)
+	}
+	if rules := config.Rules(common.Big0, true, 9); len(rules.Precompiles) != 0 {
+		t.Fatalf("wrong active precompiles: %v", rules.Precompiles)
+	}
+}
+
 func TestTimestampCompatError(t *testing.T) {
 	require.Equal(t, new(ConfigCompatError).Error(), "")
 
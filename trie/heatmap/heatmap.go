@@ -0,0 +1,250 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package heatmap implements the optional sampling of trie node accesses, for
+// analyzing which parts of the state are hot and how efficient the caches are.
+//
+// The sampling is process wide and disabled by default, in which case the
+// recording functions are no-ops costing a single atomic load.
+package heatmap
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// maxContracts is the maximum number of storage tries tracked. Once exceeded,
+// the half of the storage tries with the least accesses is dropped.
+const maxContracts = 65536
+
+// Source is the location a trie node is served from by the trie database.
+type Source int
+
+const (
+	SourceDiff  Source = iota // In-memory diff layers
+	SourceDirty               // Dirty node buffer not yet flushed
+	SourceClean               // Clean node cache
+	SourceDisk                // Persistent database
+	sourceCount
+)
+
+var (
+	rate          atomic.Uint64 // One in rate accesses is sampled, zero if disabled
+	accessCounter atomic.Uint64 // Counter of the trie node accesses for sampling
+	sourceCounter atomic.Uint64 // Counter of the database node lookups for sampling
+
+	lock    sync.Mutex
+	current *heatmap
+)
+
+// heatmap is the aggregation of the sampled accesses.
+type heatmap struct {
+	start     time.Time
+	accesses  uint64
+	account   []uint64               // Account trie accesses by depth
+	storage   []uint64               // Storage trie accesses by depth
+	sources   [][sourceCount]uint64  // Database lookups by depth and source
+	contracts map[common.Hash]uint64 // Storage trie accesses by owner
+}
+
+// Enabled returns whether the trie node accesses are being sampled.
+func Enabled() bool {
+	return rate.Load() != 0
+}
+
+// Start enables the sampling of one in every rate trie node accesses, which
+// discards all the previously collected samples.
+func Start(sampleRate uint64) {
+	if sampleRate == 0 {
+		sampleRate = 1
+	}
+	lock.Lock()
+	defer lock.Unlock()
+
+	current = &heatmap{
+		start:     time.Now(),
+		contracts: make(map[common.Hash]uint64),
+	}
+	rate.Store(sampleRate)
+}
+
+// Stop disables the sampling. The collected samples are retained until the
+// next start.
+func Stop() {
+	rate.Store(0)
+}
+
+// sample reports whether the event counted by the given counter is sampled.
+func sample(counter *atomic.Uint64) bool {
+	r := rate.Load()
+	if r == 0 {
+		return false
+	}
+	return counter.Add(1)%r == 0
+}
+
+// grow extends the slice to contain the given index.
+func grow[T any](s []T, index int) []T {
+	for len(s) <= index {
+		var zero T
+		s = append(s, zero)
+	}
+	return s
+}
+
+// RecordAccess records the resolution of a trie node at the given path depth
+// in the trie owned by the given account, zero for the account trie.
+func RecordAccess(owner common.Hash, depth int) {
+	if !sample(&accessCounter) {
+		return
+	}
+	lock.Lock()
+	defer lock.Unlock()
+
+	if current == nil {
+		return
+	}
+	current.accesses++
+	if owner == (common.Hash{}) {
+		current.account = grow(current.account, depth)
+		current.account[depth]++
+		return
+	}
+	current.storage = grow(current.storage, depth)
+	current.storage[depth]++
+
+	current.contracts[owner]++
+	if len(current.contracts) > maxContracts {
+		current.prune()
+	}
+}
+
+// RecordSource records the source a trie node at the given path depth is
+// served from by the trie database.
+func RecordSource(depth int, source Source) {
+	if !sample(&sourceCounter) {
+		return
+	}
+	lock.Lock()
+	defer lock.Unlock()
+
+	if current == nil {
+		return
+	}
+	current.sources = grow(current.sources, depth)
+	current.sources[depth][source]++
+}
+
+// prune drops the half of the tracked storage tries with the least accesses.
+// The ties are broken by the owner hash, so that exactly half of the tries are
+// dropped even if most of them have the same number of accesses.
+func (h *heatmap) prune() {
+	stats := make([]ContractStats, 0, len(h.contracts))
+	for owner, count := range h.contracts {
+		stats = append(stats, ContractStats{Owner: owner, Accesses: count})
+	}
+	slices.SortFunc(stats, func(a, b ContractStats) int {
+		if c := cmp.Compare(a.Accesses, b.Accesses); c != 0 {
+			return c
+		}
+		return a.Owner.Cmp(b.Owner)
+	})
+	for _, stat := range stats[:len(stats)/2] {
+		delete(h.contracts, stat.Owner)
+	}
+}
+
+// SourceStats is the number of sampled trie node lookups at a certain depth,
+// grouped by the source the nodes are served from.
+type SourceStats struct {
+	Depth    int     `json:"depth"`
+	Diff     uint64  `json:"diff"`
+	Dirty    uint64  `json:"dirty"`
+	Clean    uint64  `json:"clean"`
+	Disk     uint64  `json:"disk"`
+	HitRatio float64 `json:"hitRatio"` // Ratio of the lookups served from memory
+}
+
+// ContractStats is the number of sampled accesses to the storage trie owned by
+// the account with the given hash.
+type ContractStats struct {
+	Owner    common.Hash `json:"owner"`
+	Accesses uint64      `json:"accesses"`
+}
+
+// Report is the summary of the sampled trie node accesses. All the numbers are
+// sampled counts, to be multiplied by the rate for estimating the totals.
+type Report struct {
+	Enabled       bool            `json:"enabled"`
+	Rate          uint64          `json:"rate"`
+	Duration      string          `json:"duration"`      // Time elapsed since the sampling started
+	Accesses      uint64          `json:"accesses"`      // Trie node accesses in total
+	AccountDepths []uint64        `json:"accountDepths"` // Account trie node accesses by depth
+	StorageDepths []uint64        `json:"storageDepths"` // Storage trie node accesses by depth
+	Sources       []SourceStats   `json:"sources"`       // Database lookups by depth
+	Contracts     []ContractStats `json:"contracts"`     // Most accessed storage tries
+}
+
+// Collect returns the summary of the samples collected since the last start,
+// including the top n most accessed storage tries. Nil is returned if the
+// sampling was never started.
+func Collect(n int) *Report {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if current == nil {
+		return nil
+	}
+	report := &Report{
+		Enabled:       Enabled(),
+		Rate:          rate.Load(),
+		Duration:      common.PrettyDuration(time.Since(current.start)).String(),
+		Accesses:      current.accesses,
+		AccountDepths: slices.Clone(current.account),
+		StorageDepths: slices.Clone(current.storage),
+	}
+	for depth, counts := range current.sources {
+		stats := SourceStats{
+			Depth: depth,
+			Diff:  counts[SourceDiff],
+			Dirty: counts[SourceDirty],
+			Clean: counts[SourceClean],
+			Disk:  counts[SourceDisk],
+		}
+		if total := stats.Diff + stats.Dirty + stats.Clean + stats.Disk; total > 0 {
+			stats.HitRatio = float64(total-stats.Disk) / float64(total)
+		}
+		report.Sources = append(report.Sources, stats)
+	}
+	for owner, count := range current.contracts {
+		report.Contracts = append(report.Contracts, ContractStats{Owner: owner, Accesses: count})
+	}
+	slices.SortFunc(report.Contracts, func(a, b ContractStats) int {
+		if c := cmp.Compare(b.Accesses, a.Accesses); c != 0 {
+			return c
+		}
+		return a.Owner.Cmp(b.Owner)
+	})
+	if n >= 0 && len(report.Contracts) > n {
+		report.Contracts = report.Contracts[:n]
+	}
+	return report
+}
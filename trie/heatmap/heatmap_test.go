@@ -0,0 +1,136 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package heatmap
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestHeatmap(t *testing.T) {
+	defer Stop()
+
+	// Nothing is recorded before the sampling is started
+	RecordAccess(common.Hash{}, 0)
+	if Enabled() || Collect(10) != nil {
+		t.Fatal("unexpected heatmap before start")
+	}
+	Start(1)
+
+	var (
+		hot  = common.HexToHash("0x01")
+		warm = common.HexToHash("0x02")
+		cold = common.HexToHash("0x03")
+	)
+	RecordAccess(common.Hash{}, 0)
+	RecordAccess(common.Hash{}, 2)
+	for i := 0; i < 3; i++ {
+		RecordAccess(hot, 1)
+	}
+	RecordAccess(warm, 0)
+	RecordAccess(warm, 1)
+	RecordAccess(cold, 0)
+
+	RecordSource(0, SourceDirty)
+	RecordSource(1, SourceClean)
+	RecordSource(1, SourceClean)
+	RecordSource(1, SourceClean)
+	RecordSource(1, SourceDisk)
+
+	report := Collect(2)
+	if !report.Enabled || report.Rate != 1 || report.Accesses != 8 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if want := []uint64{1, 0, 1}; !reflect.DeepEqual(report.AccountDepths, want) {
+		t.Fatalf("unexpected account depths, want %v, got %v", want, report.AccountDepths)
+	}
+	if want := []uint64{2, 4}; !reflect.DeepEqual(report.StorageDepths, want) {
+		t.Fatalf("unexpected storage depths, want %v, got %v", want, report.StorageDepths)
+	}
+	wantSources := []SourceStats{
+		{Depth: 0, Dirty: 1, HitRatio: 1},
+		{Depth: 1, Clean: 3, Disk: 1, HitRatio: 0.75},
+	}
+	if !reflect.DeepEqual(report.Sources, wantSources) {
+		t.Fatalf("unexpected sources, want %+v, got %+v", wantSources, report.Sources)
+	}
+	wantContracts := []ContractStats{{Owner: hot, Accesses: 3}, {Owner: warm, Accesses: 2}}
+	if !reflect.DeepEqual(report.Contracts, wantContracts) {
+		t.Fatalf("unexpected contracts, want %+v, got %+v", wantContracts, report.Contracts)
+	}
+	// The samples are retained after stopping, but nothing more is recorded
+	Stop()
+	RecordAccess(hot, 0)
+	if report := Collect(0); report.Enabled || report.Accesses != 8 || len(report.Contracts) != 0 {
+		t.Fatalf("unexpected report after stop: %+v", report)
+	}
+	// Restarting discards the previous samples
+	Start(4)
+	for i := 0; i < 8; i++ {
+		RecordAccess(hot, 0)
+	}
+	if report := Collect(10); report.Accesses != 2 || len(report.Contracts) != 1 || report.Contracts[0].Accesses != 2 {
+		t.Fatalf("unexpected sampled report: %+v", report)
+	}
+}
+
+func TestHeatmapPrune(t *testing.T) {
+	defer Stop()
+	Start(1)
+
+	hot := common.HexToHash("0xff")
+	for i := 0; i < 10; i++ {
+		RecordAccess(hot, 0)
+	}
+	for i := 0; i < maxContracts; i++ {
+		RecordAccess(common.BigToHash(big.NewInt(int64(i)+0x1000)), 0)
+	}
+	report := Collect(1)
+	if len(report.Contracts) != 1 || report.Contracts[0].Owner != hot || report.Contracts[0].Accesses != 10 {
+		t.Fatalf("hot contract was pruned: %+v", report.Contracts)
+	}
+	lock.Lock()
+	tracked := len(current.contracts)
+	lock.Unlock()
+	if tracked > maxContracts {
+		t.Fatalf("too many contracts tracked: %d", tracked)
+	}
+}
+
+// Tests that pruning drops exactly half of the contracts, even if most of them
+// have the same number of accesses.
+func TestHeatmapPruneTied(t *testing.T) {
+	var (
+		hot  = common.HexToHash("0xff")
+		warm = common.HexToHash("0xfe")
+		h    = &heatmap{contracts: map[common.Hash]uint64{hot: 10, warm: 2}}
+	)
+	for i := 0; i < 10; i++ {
+		h.contracts[common.BigToHash(big.NewInt(int64(i)))] = 1
+	}
+	h.prune()
+
+	if len(h.contracts) != 6 {
+		t.Fatalf("wrong number of contracts retained: have %d, want %d", len(h.contracts), 6)
+	}
+	if h.contracts[hot] != 10 || h.contracts[warm] != 2 {
+		t.Fatalf("most accessed contracts were pruned: %v", h.contracts)
+	}
+}
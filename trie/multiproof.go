@@ -0,0 +1,194 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ProveMulti constructs a merkle multiproof for the given set of keys. The result
+// contains all encoded nodes on the paths to the values at the keys, each of them
+// only once, even if it's shared by the paths of multiple keys. The values are
+// included in the nodes and can be retrieved by verifying the proof.
+//
+// Similar to Prove, the proof of a key absent in the trie contains the nodes of
+// the longest existing prefix of the key, ending with the node that proves the
+// absence.
+func (t *Trie) ProveMulti(keys [][]byte, proofDb ethdb.KeyValueWriter) error {
+	// Short circuit if the trie is already committed and not usable.
+	if t.committed {
+		return ErrCommitted
+	}
+	// Convert the keys into the sorted hex format without duplicates, so that
+	// the keys sharing the same path can be handled together.
+	hexKeys := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		hexKeys = append(hexKeys, keybytesToHex(key))
+	}
+	slices.SortFunc(hexKeys, bytes.Compare)
+	hexKeys = slices.CompactFunc(hexKeys, bytes.Equal)
+
+	hasher := newHasher(false)
+	defer returnHasherToPool(hasher)
+
+	return t.proveMulti(t.root, nil, hexKeys, true, hasher, proofDb)
+}
+
+// proveMulti collects the proof elements of the given node and its descendants
+// on the paths of the given keys, which are relative to the node.
+func (t *Trie) proveMulti(tn node, prefix []byte, keys [][]byte, root bool, hasher *hasher, proofDb ethdb.KeyValueWriter) error {
+	// Resolve the node from the underlying node reader if it's not loaded.
+	// Same as Prove, the loaded nodes are not linked to the trie.
+	if hash, ok := tn.(hashNode); ok {
+		blob, err := t.reader.node(prefix, common.BytesToHash(hash))
+		if err != nil {
+			log.Error("Unhandled trie error in Trie.ProveMulti", "err", err)
+			return err
+		}
+		tn = mustDecodeNodeUnsafe(hash, blob)
+	}
+	switch n := tn.(type) {
+	case nil, valueNode:
+		return nil
+	case *shortNode, *fullNode:
+		// If the node's database encoding is a hash (or is the root node),
+		// it becomes a proof element.
+		collapsed, hn := hasher.proofHash(n)
+		if hash, ok := hn.(hashNode); ok || root {
+			enc := nodeToBytes(collapsed)
+			if !ok {
+				hash = hasher.hashData(enc)
+			}
+			proofDb.Put(hash, enc)
+		}
+	default:
+		panic(fmt.Sprintf("%T: invalid node: %v", tn, tn))
+	}
+	switch n := tn.(type) {
+	case *shortNode:
+		var matched [][]byte
+		for _, key := range keys {
+			if bytes.HasPrefix(key, n.Key) {
+				matched = append(matched, key[len(n.Key):])
+			}
+		}
+		if len(matched) == 0 {
+			return nil // The trie doesn't contain any of the keys
+		}
+		return t.proveMulti(n.Val, append(prefix, n.Key...), matched, false, hasher, proofDb)
+
+	case *fullNode:
+		// The keys are sorted, split them into the groups by the first nibble.
+		for start := 0; start < len(keys); {
+			end, nibble := start+1, keys[start][0]
+			for end < len(keys) && keys[end][0] == nibble {
+				end++
+			}
+			group := make([][]byte, 0, end-start)
+			for _, key := range keys[start:end] {
+				group = append(group, key[1:])
+			}
+			if err := t.proveMulti(n.Children[nibble], append(slices.Clone(prefix), nibble), group, false, hasher, proofDb); err != nil {
+				return err
+			}
+			start = end
+		}
+	}
+	return nil
+}
+
+// ProveMulti constructs a merkle multiproof for the given set of keys. The result
+// contains all encoded nodes on the paths to the values at the keys, each of them
+// only once.
+func (t *StateTrie) ProveMulti(keys [][]byte, proofDb ethdb.KeyValueWriter) error {
+	return t.trie.ProveMulti(keys, proofDb)
+}
+
+// VerifyMultiProof checks a merkle multiproof. The partial trie is reconstructed
+// from the nodes in the given proof, then the values of the keys are retrieved
+// from it. Nil is returned as the value of a key absent in the trie. An error is
+// returned if the proof contains invalid trie nodes, or any node on the path of
+// a key is missing.
+func VerifyMultiProof(rootHash common.Hash, keys [][]byte, proofDb ethdb.KeyValueReader) ([][]byte, error) {
+	// An empty trie has no nodes and contains none of the keys.
+	if rootHash == types.EmptyRootHash {
+		return make([][]byte, len(keys)), nil
+	}
+	root, err := resolveProofNode(hashNode(rootHash.Bytes()), proofDb)
+	if err != nil {
+		return nil, err
+	}
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		keyrest, cld := get(root, keybytesToHex(key), true)
+		switch cld := cld.(type) {
+		case nil:
+			// The trie doesn't contain the key.
+		case hashNode:
+			return nil, fmt.Errorf("proof node (hash %x) on path of key %x (remaining %x) missing", []byte(cld), key, keyrest)
+		case valueNode:
+			values[i] = cld
+		}
+	}
+	return values, nil
+}
+
+// resolveProofNode decodes the given node along with all its descendants found
+// in the proof. The descendants absent in the proof are left as hash nodes.
+func resolveProofNode(n node, proofDb ethdb.KeyValueReader) (node, error) {
+	switch n := n.(type) {
+	case hashNode:
+		blob, _ := proofDb.Get(n)
+		if len(blob) == 0 {
+			return n, nil
+		}
+		if !bytes.Equal(crypto.Keccak256(blob), n) {
+			return nil, fmt.Errorf("proof node (hash %x) mismatched with content", []byte(n))
+		}
+		dec, err := decodeNode(n, blob)
+		if err != nil {
+			return nil, fmt.Errorf("bad proof node (hash %x): %v", []byte(n), err)
+		}
+		return resolveProofNode(dec, proofDb)
+	case *shortNode:
+		val, err := resolveProofNode(n.Val, proofDb)
+		if err != nil {
+			return nil, err
+		}
+		n.Val = val
+	case *fullNode:
+		for i := 0; i < 16; i++ {
+			if n.Children[i] == nil {
+				continue
+			}
+			child, err := resolveProofNode(n.Children[i], proofDb)
+			if err != nil {
+				return nil, err
+			}
+			n.Children[i] = child
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,191 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/trie/trienode"
+)
+
+// sampleKeys picks the first n keys from the given set, along with a few keys
+// absent in the trie.
+func sampleKeys(vals map[string]*kv, n int) [][]byte {
+	var keys [][]byte
+	for _, kv := range vals {
+		if len(keys) == n {
+			break
+		}
+		keys = append(keys, kv.k)
+	}
+	for i := 0; i < 5; i++ {
+		keys = append(keys, randBytes(32))
+	}
+	return keys
+}
+
+func TestMultiProof(t *testing.T) {
+	tr, vals := randomTrie(500)
+	root := tr.Hash()
+
+	for _, n := range []int{1, 2, 10, 100, len(vals)} {
+		keys := sampleKeys(vals, n)
+		proof := memorydb.New()
+		if err := tr.ProveMulti(keys, proof); err != nil {
+			t.Fatalf("Failed to construct multiproof: %v", err)
+		}
+		values, err := VerifyMultiProof(root, keys, proof)
+		if err != nil {
+			t.Fatalf("Failed to verify multiproof: %v", err)
+		}
+		// The multiproof must be the union of the single proofs.
+		union := memorydb.New()
+		for i, key := range keys {
+			var want []byte
+			if kv, ok := vals[string(key)]; ok {
+				want = kv.v
+			}
+			if !bytes.Equal(values[i], want) {
+				t.Fatalf("Value mismatch for key %x, want %x, got %x", key, want, values[i])
+			}
+			if err := tr.Prove(key, union); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if proof.Len() != union.Len() {
+			t.Fatalf("Proof size mismatch, want %d, got %d", union.Len(), proof.Len())
+		}
+	}
+}
+
+func TestMultiProofDuplicatedKeys(t *testing.T) {
+	tr, vals := randomTrie(100)
+	keys := sampleKeys(vals, 10)
+
+	proof := memorydb.New()
+	if err := tr.ProveMulti(keys, proof); err != nil {
+		t.Fatal(err)
+	}
+	duplicated := memorydb.New()
+	if err := tr.ProveMulti(append(keys, keys...), duplicated); err != nil {
+		t.Fatal(err)
+	}
+	if proof.Len() != duplicated.Len() {
+		t.Fatalf("Proof size mismatch, want %d, got %d", proof.Len(), duplicated.Len())
+	}
+	values, err := VerifyMultiProof(tr.Hash(), append(keys, keys...), duplicated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range keys {
+		if !bytes.Equal(values[i], values[i+len(keys)]) {
+			t.Fatalf("Value mismatch for duplicated key %x", keys[i])
+		}
+	}
+}
+
+// TestMultiProofCommittedTrie tests the multiproof of a trie loaded from the
+// database, whose nodes are resolved during proving.
+func TestMultiProofCommittedTrie(t *testing.T) {
+	db := newTestDatabase(rawdb.NewMemoryDatabase(), rawdb.HashScheme)
+	tr := NewEmpty(db)
+	vals := make(map[string]*kv)
+	for i := 0; i < 300; i++ {
+		kv := &kv{randBytes(32), randBytes(20), false}
+		tr.MustUpdate(kv.k, kv.v)
+		vals[string(kv.k)] = kv
+	}
+	root, nodes := tr.Commit(false)
+	db.Update(root, types.EmptyRootHash, trienode.NewWithNodeSet(nodes))
+
+	tr, _ = New(TrieID(root), db)
+	keys := sampleKeys(vals, 50)
+	proof := memorydb.New()
+	if err := tr.ProveMulti(keys, proof); err != nil {
+		t.Fatal(err)
+	}
+	values, err := VerifyMultiProof(root, keys, proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, key := range keys {
+		var want []byte
+		if kv, ok := vals[string(key)]; ok {
+			want = kv.v
+		}
+		if !bytes.Equal(values[i], want) {
+			t.Fatalf("Value mismatch for key %x, want %x, got %x", key, want, values[i])
+		}
+	}
+}
+
+func TestEmptyMultiProof(t *testing.T) {
+	tr := NewEmpty(newTestDatabase(rawdb.NewMemoryDatabase(), rawdb.HashScheme))
+	keys := [][]byte{randBytes(32), randBytes(32)}
+
+	proof := memorydb.New()
+	if err := tr.ProveMulti(keys, proof); err != nil {
+		t.Fatal(err)
+	}
+	if proof.Len() != 0 {
+		t.Fatalf("Unexpected proof of empty trie")
+	}
+	values, err := VerifyMultiProof(types.EmptyRootHash, keys, proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, value := range values {
+		if value != nil {
+			t.Fatalf("Unexpected value %x", value)
+		}
+	}
+}
+
+func TestBadMultiProof(t *testing.T) {
+	tr, vals := randomTrie(800)
+	root := tr.Hash()
+	keys := sampleKeys(vals, 20)
+
+	proof := memorydb.New()
+	if err := tr.ProveMulti(keys, proof); err != nil {
+		t.Fatal(err)
+	}
+	it := proof.NewIterator(nil, nil)
+	for it.Next() {
+		key, val := common.CopyBytes(it.Key()), common.CopyBytes(it.Value())
+
+		// Missing proof node
+		proof.Delete(key)
+		if _, err := VerifyMultiProof(root, keys, proof); err == nil {
+			t.Fatalf("Expected failure for missing node %x", key)
+		}
+		// Tampered proof node
+		mutated := common.CopyBytes(val)
+		mutated[len(mutated)-1] ^= 0xff
+		proof.Put(key, mutated)
+		if _, err := VerifyMultiProof(root, keys, proof); err == nil {
+			t.Fatalf("Expected failure for tampered node %x", key)
+		}
+		proof.Put(key, val)
+	}
+	it.Release()
+}
@@ -264,6 +264,31 @@ func (db *Database) Dereference(root common.Hash) error {
 	return nil
 }
 
+// ObserveWrites registers a callback which is invoked with the hash of every
+// trie node flushed into the persistent store, or removes it if nil is given.
+//
+// It's only supported by hash-based database and will return an error for others.
+func (db *Database) ObserveWrites(fn func(hash common.Hash)) error {
+	hdb, ok := db.backend.(*hashdb.Database)
+	if !ok {
+		return errors.New("not supported")
+	}
+	hdb.ObserveWrites(fn)
+	return nil
+}
+
+// DeleteNodes removes the specified trie nodes from the persistent store, skipping
+// the ones still cached in memory or rejected by the keep function.
+//
+// It's only supported by hash-based database and will return an error for others.
+func (db *Database) DeleteNodes(hashes []common.Hash, keep func(hash common.Hash) bool) (int, error) {
+	hdb, ok := db.backend.(*hashdb.Database)
+	if !ok {
+		return 0, errors.New("not supported")
+	}
+	return hdb.DeleteNodes(hashes, keep)
+}
+
 // Recover rollbacks the database to a specified historical point. The state is
 // supported as the rollback destination only if it's canonical state and the
 // corresponding trie histories are existent. It's only supported by path-based
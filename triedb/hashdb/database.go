@@ -95,6 +95,8 @@ type Database struct {
 	dirtiesSize  common.StorageSize // Storage size of the dirty node cache (exc. metadata)
 	childrenSize common.StorageSize // Storage size of the external children tracking
 
+	observer func(hash common.Hash) // Callback invoked with every node flushed to disk
+
 	lock sync.RWMutex
 }
 
@@ -343,6 +345,9 @@ func (db *Database) Cap(limit common.StorageSize) error {
 		// Fetch the oldest referenced node and push into the batch
 		node := db.dirties[oldest]
 		rawdb.WriteLegacyTrieNode(batch, oldest, node.node)
+		if db.observer != nil {
+			db.observer(oldest)
+		}
 
 		// If we exceeded the ideal batch size, commit and reset
 		if batch.ValueSize() >= ethdb.IdealBatchSize {
@@ -466,6 +471,9 @@ func (db *Database) commit(hash common.Hash, batch ethdb.Batch, uncacher *cleane
 	}
 	// If we've reached an optimal batch size, commit and start over
 	rawdb.WriteLegacyTrieNode(batch, hash, node.node)
+	if db.observer != nil {
+		db.observer(hash)
+	}
 	if batch.ValueSize() >= ethdb.IdealBatchSize {
 		if err := batch.Write(); err != nil {
 			return err
@@ -479,6 +487,50 @@ func (db *Database) commit(hash common.Hash, batch ethdb.Batch, uncacher *cleane
 	return nil
 }
 
+// ObserveWrites registers a callback which is invoked with the hash of every
+// trie node flushed from the dirty cache into the persistent store, or removes
+// the registered one if nil is given. The callback is invoked with the database
+// lock held, before the node is visible on disk.
+func (db *Database) ObserveWrites(fn func(hash common.Hash)) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	db.observer = fn
+}
+
+// DeleteNodes removes the specified trie nodes from the persistent store, which
+// is meant to be used for pruning the unreachable state while the database is
+// live. Nodes still tracked in the dirty cache are skipped, as well as the ones
+// rejected by the keep function. The checks are performed with the database
+// lock held, preventing any concurrent flush from interleaving. The number of
+// deleted nodes is returned.
+func (db *Database) DeleteNodes(hashes []common.Hash, keep func(hash common.Hash) bool) (int, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	var (
+		deleted int
+		batch   = db.diskdb.NewBatch()
+	)
+	for _, hash := range hashes {
+		if _, ok := db.dirties[hash]; ok {
+			continue
+		}
+		if keep != nil && keep(hash) {
+			continue
+		}
+		rawdb.DeleteLegacyTrieNode(batch, hash)
+		if db.cleans != nil {
+			db.cleans.Del(hash[:])
+		}
+		deleted++
+	}
+	if err := batch.Write(); err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
 // cleaner is a database batch replayer that takes a batch of write operations
 // and cleans up the trie database from anything written to disk.
 type cleaner struct {
@@ -110,10 +110,11 @@ type layer interface {
 
 // Config contains the settings for database.
 type Config struct {
-	StateHistory    uint64 // Number of recent blocks to maintain state history for
-	CleanCacheSize  int    // Maximum memory allowance (in bytes) for caching clean nodes
-	WriteBufferSize int    // Maximum memory allowance (in bytes) for write buffer
-	ReadOnly        bool   // Flag whether the database is opened in read only mode.
+	StateHistory        uint64 // Number of recent blocks to maintain state history for
+	EnableStateIndexing bool   // Whether to index the state history for historical state access
+	CleanCacheSize      int    // Maximum memory allowance (in bytes) for caching clean nodes
+	WriteBufferSize     int    // Maximum memory allowance (in bytes) for write buffer
+	ReadOnly            bool   // Flag whether the database is opened in read only mode.
 }
 
 // sanitize checks the provided user configurations and changes anything that's
@@ -136,6 +137,9 @@ func (c *Config) fields() []interface{} {
 	list = append(list, "cache", common.StorageSize(c.CleanCacheSize))
 	list = append(list, "buffer", common.StorageSize(c.WriteBufferSize))
 	list = append(list, "history", c.StateHistory)
+	if c.EnableStateIndexing {
+		list = append(list, "index", true)
+	}
 	return list
 }
 
@@ -198,6 +202,9 @@ type Database struct {
 	tree    *layerTree                   // The group for all known layers
 	freezer ethdb.ResettableAncientStore // Freezer for storing trie histories, nil possible in tests
 	lock    sync.RWMutex                 // Lock to prevent mutations from happening at the same time
+
+	indexer   *historyIndexer // Background indexer of state histories, nil if indexing is disabled
+	indexLock sync.Mutex      // Lock to serialize the state history indexing and truncation
 }
 
 // New attempts to load an already existing layer from a persistent key-value
@@ -234,6 +241,13 @@ func New(diskdb ethdb.Database, config *Config, isVerkle bool) *Database {
 	if err := db.repairHistory(); err != nil {
 		log.Crit("Failed to repair state history", "err", err)
 	}
+	// Align the state history index with the state histories, and index
+	// the histories which are not yet indexed in the background.
+	if db.freezer != nil && !db.readOnly {
+		if err := db.repairIndex(); err != nil {
+			log.Crit("Failed to repair state history index", "err", err)
+		}
+	}
 	// Disable database in case node is still in the initial state sync stage.
 	if rawdb.ReadSnapSyncStatusFlag(diskdb) == rawdb.StateSyncRunning && !db.readOnly {
 		if err := db.Disable(); err != nil {
@@ -399,7 +413,13 @@ func (db *Database) Enable(root common.Hash) error {
 	// mappings can be huge and might take a while to clear
 	// them, just leave them in disk and wait for overwriting.
 	if db.freezer != nil {
-		if err := db.freezer.Reset(); err != nil {
+		db.indexLock.Lock()
+		err := db.freezer.Reset()
+		if err == nil {
+			rawdb.DeleteStateHistoryIndex(db.diskdb)
+		}
+		db.indexLock.Unlock()
+		if err != nil {
 			return err
 		}
 	}
@@ -454,7 +474,9 @@ func (db *Database) Recover(root common.Hash) error {
 		db.tree.reset(dl)
 	}
 	rawdb.DeleteTrieJournal(db.diskdb)
+	db.indexLock.Lock()
 	_, err := truncateFromHead(db.diskdb, db.freezer, dl.stateID())
+	db.indexLock.Unlock()
 	if err != nil {
 		return err
 	}
@@ -508,6 +530,11 @@ func (db *Database) Close() error {
 	// Release the memory held by clean cache.
 	db.tree.bottom().resetCache()
 
+	// Terminate the history indexer before closing the freezer it reads from.
+	if db.indexer != nil {
+		db.indexer.close()
+		db.indexer = nil
+	}
 	// Close the attached state history freezer.
 	if db.freezer == nil {
 		return nil
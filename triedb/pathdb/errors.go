@@ -39,4 +39,16 @@ var (
 	// errStateUnrecoverable is returned if state is required to be reverted to
 	// a destination without associated state history available.
 	errStateUnrecoverable = errors.New("state is unrecoverable")
+
+	// errStateIndexDisabled is returned if a historical state is requested but
+	// the state history indexing is not enabled.
+	errStateIndexDisabled = errors.New("state history indexing is disabled")
+
+	// errStateNotIndexed is returned if a historical state is requested which is
+	// out of the range covered by the state history index.
+	errStateNotIndexed = errors.New("historical state is not available")
+
+	// errIndexingInterrupted is returned if the state history indexing is
+	// terminated before it's finished.
+	errIndexingInterrupted = errors.New("state history indexing is interrupted")
 )
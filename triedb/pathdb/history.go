@@ -544,7 +544,7 @@ func checkHistories(reader ethdb.AncientReader, start, count uint64, check func(
 
 // truncateFromHead removes the extra state histories from the head with the given
 // parameters. It returns the number of items removed from the head.
-func truncateFromHead(db ethdb.Batcher, store ethdb.AncientStore, nhead uint64) (int, error) {
+func truncateFromHead(db ethdb.KeyValueStore, store ethdb.AncientStore, nhead uint64) (int, error) {
 	ohead, err := store.Ancients()
 	if err != nil {
 		return 0, err
@@ -574,6 +574,9 @@ func truncateFromHead(db ethdb.Batcher, store ethdb.AncientStore, nhead uint64)
 		}
 		rawdb.DeleteStateID(batch, m.root)
 	}
+	if err := unindexHead(db, batch, store, nhead); err != nil {
+		return 0, err
+	}
 	if err := batch.Write(); err != nil {
 		return 0, err
 	}
@@ -586,7 +589,7 @@ func truncateFromHead(db ethdb.Batcher, store ethdb.AncientStore, nhead uint64)
 
 // truncateFromTail removes the extra state histories from the tail with the given
 // parameters. It returns the number of items removed from the tail.
-func truncateFromTail(db ethdb.Batcher, store ethdb.AncientStore, ntail uint64) (int, error) {
+func truncateFromTail(db ethdb.KeyValueStore, store ethdb.AncientStore, ntail uint64) (int, error) {
 	ohead, err := store.Ancients()
 	if err != nil {
 		return 0, err
@@ -616,6 +619,9 @@ func truncateFromTail(db ethdb.Batcher, store ethdb.AncientStore, ntail uint64)
 		}
 		rawdb.DeleteStateID(batch, m.root)
 	}
+	if err := unindexTail(db, batch, store, ntail); err != nil {
+		return 0, err
+	}
 	if err := batch.Write(); err != nil {
 		return 0, err
 	}
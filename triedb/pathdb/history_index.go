@@ -0,0 +1,339 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pathdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// The state history index maps each account and storage slot to the ids of
+// the state histories in which it was mutated. Every entry is stored as a
+// standalone key in the key-value store, ordered by the state id:
+//
+//   account: StateHistoryAccountIndexPrefix + address + id -> nil
+//   storage: StateHistoryStorageIndexPrefix + address + slot hash + id -> nil
+//
+// The value of an account or storage slot at a historical state n can then be
+// resolved by seeking the first entry with id > n. The original value recorded
+// in that state history is the value at state n. If no such entry exists, the
+// value hasn't been changed since then and it's still the same in the latest
+// persistent state.
+//
+// The index is maintained along with the state histories: histories are indexed
+// by the background indexer after they're written into the freezer, and are
+// unindexed before they're truncated.
+
+// indexMeta describes the range (tail, head] of the indexed state histories.
+type indexMeta struct {
+	tail uint64 // The id of the last unindexed history before the indexed range
+	head uint64 // The id of the last indexed history
+}
+
+// encode packs the index metadata into byte stream.
+func (m *indexMeta) encode() []byte {
+	var buf [16]byte
+	binary.BigEndian.PutUint64(buf[:8], m.tail)
+	binary.BigEndian.PutUint64(buf[8:], m.head)
+	return buf[:]
+}
+
+// decode unpacks the index metadata from byte stream.
+func (m *indexMeta) decode(blob []byte) error {
+	if len(blob) != 16 {
+		return fmt.Errorf("invalid index metadata length: %d", len(blob))
+	}
+	m.tail = binary.BigEndian.Uint64(blob[:8])
+	m.head = binary.BigEndian.Uint64(blob[8:])
+	return nil
+}
+
+// loadIndexMeta reads the index metadata from the database, nil is returned
+// if the state history is not indexed.
+func loadIndexMeta(db ethdb.KeyValueReader) *indexMeta {
+	blob := rawdb.ReadStateHistoryIndexMeta(db)
+	if len(blob) == 0 {
+		return nil
+	}
+	var m indexMeta
+	if err := m.decode(blob); err != nil {
+		log.Error("Failed to decode state history index metadata", "err", err)
+		return nil
+	}
+	return &m
+}
+
+// iterateHistory invokes the callbacks with the accounts and storage slots
+// mutated in the specified state history. Only the index sections of the
+// history are loaded, as the data itself is not needed.
+func iterateHistory(freezer ethdb.AncientReader, id uint64, onAccount func(common.Address), onSlot func(common.Address, common.Hash)) error {
+	var (
+		accountIndexes = rawdb.ReadStateAccountIndex(freezer, id)
+		storageIndexes = rawdb.ReadStateStorageIndex(freezer, id)
+	)
+	if len(accountIndexes)%accountIndexSize != 0 {
+		return fmt.Errorf("invalid account index length of history %d: %d", id, len(accountIndexes))
+	}
+	if len(storageIndexes)%slotIndexSize != 0 {
+		return fmt.Errorf("invalid storage index length of history %d: %d", id, len(storageIndexes))
+	}
+	for i := 0; i < len(accountIndexes)/accountIndexSize; i++ {
+		var accIndex accountIndex
+		accIndex.decode(accountIndexes[i*accountIndexSize : (i+1)*accountIndexSize])
+		onAccount(accIndex.address)
+
+		start, end := int(accIndex.storageOffset), int(accIndex.storageOffset+accIndex.storageSlots)
+		if end*slotIndexSize > len(storageIndexes) {
+			return fmt.Errorf("storage index out of range of history %d, end: %d, total: %d", id, end, len(storageIndexes)/slotIndexSize)
+		}
+		for j := start; j < end; j++ {
+			var slot slotIndex
+			slot.decode(storageIndexes[j*slotIndexSize : (j+1)*slotIndexSize])
+			onSlot(accIndex.address, slot.hash)
+		}
+	}
+	return nil
+}
+
+// indexHistories indexes the state histories in the freezer which are not yet
+// covered by the index, one batch at a time. The indexing is aborted if the
+// interrupt channel is closed.
+func (db *Database) indexHistories(interrupt chan struct{}) error {
+	var (
+		start   = time.Now()
+		logged  = time.Now()
+		indexed uint64
+	)
+	for {
+		select {
+		case <-interrupt:
+			return errIndexingInterrupted
+		default:
+		}
+		n, left, err := db.indexBatch()
+		if err != nil {
+			return err
+		}
+		indexed += n
+		if left == 0 {
+			break
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Indexing state histories", "indexed", indexed, "left", left, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if indexed > 1 {
+		log.Info("Indexed state histories", "count", indexed, "elapsed", common.PrettyDuration(time.Since(start)))
+	}
+	return nil
+}
+
+// indexBatch indexes the next batch of unindexed state histories in the freezer,
+// returning the number of histories indexed and the number still left. The index
+// metadata is initialized if it doesn't exist.
+//
+// The index lock is held to prevent the state histories from being truncated
+// concurrently.
+func (db *Database) indexBatch() (uint64, uint64, error) {
+	db.indexLock.Lock()
+	defer db.indexLock.Unlock()
+
+	head, err := db.freezer.Ancients()
+	if err != nil {
+		return 0, 0, err
+	}
+	tail, err := db.freezer.Tail()
+	if err != nil {
+		return 0, 0, err
+	}
+	m := loadIndexMeta(db.diskdb)
+	if m == nil {
+		m = &indexMeta{tail: tail, head: tail}
+		rawdb.WriteStateHistoryIndexMeta(db.diskdb, m.encode())
+	}
+	if m.head >= head {
+		return 0, 0, nil
+	}
+	var (
+		from  = m.head + 1
+		batch = db.diskdb.NewBatch()
+	)
+	for id := from; id <= head; id++ {
+		err := iterateHistory(db.freezer, id, func(addr common.Address) {
+			rawdb.WriteAccountHistoryIndex(batch, addr, id)
+		}, func(addr common.Address, slot common.Hash) {
+			rawdb.WriteStorageHistoryIndex(batch, addr, slot, id)
+		})
+		if err != nil {
+			return 0, 0, err
+		}
+		m.head = id
+		if batch.ValueSize() > ethdb.IdealBatchSize {
+			break
+		}
+	}
+	rawdb.WriteStateHistoryIndexMeta(batch, m.encode())
+	if err := batch.Write(); err != nil {
+		return 0, 0, err
+	}
+	return m.head - from + 1, head - m.head, nil
+}
+
+// historyIndexer indexes the state histories in the background, keeping the
+// index up to date without blocking the database initialization or the state
+// commits. It's notified whenever new state histories are written.
+type historyIndexer struct {
+	db     *Database
+	notify chan struct{} // Channel to signal the arrival of new state histories
+	closed chan struct{} // Channel to terminate the indexer
+	done   chan struct{} // Channel closed once the indexer is terminated
+}
+
+// newHistoryIndexer constructs the history indexer and starts indexing the
+// state histories which are not yet indexed.
+func newHistoryIndexer(db *Database) *historyIndexer {
+	i := &historyIndexer{
+		db:     db,
+		notify: make(chan struct{}, 1),
+		closed: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go i.run()
+	return i
+}
+
+// run is the main loop of the indexer, indexing the state histories on every
+// notification until it's terminated.
+func (i *historyIndexer) run() {
+	defer close(i.done)
+
+	for {
+		if err := i.db.indexHistories(i.closed); err != nil {
+			if errors.Is(err, errIndexingInterrupted) {
+				return
+			}
+			log.Error("Failed to index state histories", "err", err)
+		}
+		select {
+		case <-i.notify:
+		case <-i.closed:
+			return
+		}
+	}
+}
+
+// trigger notifies the indexer that new state histories are available. It never
+// blocks, the pending notification is reused if the indexer is busy.
+func (i *historyIndexer) trigger() {
+	select {
+	case i.notify <- struct{}{}:
+	default:
+	}
+}
+
+// close terminates the indexer and waits until the in-progress batch is done.
+func (i *historyIndexer) close() {
+	close(i.closed)
+	<-i.done
+}
+
+// unindexHistory removes the index entries of the specified state history.
+func unindexHistory(batch ethdb.KeyValueWriter, freezer ethdb.AncientReader, id uint64) error {
+	return iterateHistory(freezer, id, func(addr common.Address) {
+		rawdb.DeleteAccountHistoryIndex(batch, addr, id)
+	}, func(addr common.Address, slot common.Hash) {
+		rawdb.DeleteStorageHistoryIndex(batch, addr, slot, id)
+	})
+}
+
+// unindexHead removes the index entries of the state histories above the given
+// new head, which are about to be truncated from the freezer. The deletions are
+// written into the supplied batch along with the updated metadata.
+func unindexHead(db ethdb.KeyValueReader, batch ethdb.KeyValueWriter, freezer ethdb.AncientReader, nhead uint64) error {
+	m := loadIndexMeta(db)
+	if m == nil || m.head <= nhead {
+		return nil
+	}
+	for id := max(m.tail, nhead) + 1; id <= m.head; id++ {
+		if err := unindexHistory(batch, freezer, id); err != nil {
+			return err
+		}
+	}
+	m.head = nhead
+	m.tail = min(m.tail, nhead)
+	rawdb.WriteStateHistoryIndexMeta(batch, m.encode())
+	return nil
+}
+
+// unindexTail removes the index entries of the state histories below or equal
+// to the given new tail, which are about to be truncated from the freezer. The
+// deletions are written into the supplied batch along with the updated metadata.
+func unindexTail(db ethdb.KeyValueReader, batch ethdb.KeyValueWriter, freezer ethdb.AncientReader, ntail uint64) error {
+	m := loadIndexMeta(db)
+	if m == nil || m.tail >= ntail {
+		return nil
+	}
+	for id := m.tail + 1; id <= min(m.head, ntail); id++ {
+		if err := unindexHistory(batch, freezer, id); err != nil {
+			return err
+		}
+	}
+	m.tail = ntail
+	m.head = max(m.head, ntail)
+	rawdb.WriteStateHistoryIndexMeta(batch, m.encode())
+	return nil
+}
+
+// repairIndex aligns the state history index with the state histories in the
+// freezer, removing it entirely if the indexing is disabled, or starting the
+// background indexer to catch up with the unindexed histories otherwise.
+func (db *Database) repairIndex() error {
+	m := loadIndexMeta(db.diskdb)
+	if !db.config.EnableStateIndexing {
+		if m != nil {
+			rawdb.DeleteStateHistoryIndex(db.diskdb)
+			log.Info("Deleted state history index")
+		}
+		return nil
+	}
+	if m != nil {
+		head, err := db.freezer.Ancients()
+		if err != nil {
+			return err
+		}
+		tail, err := db.freezer.Tail()
+		if err != nil {
+			return err
+		}
+		// The index is no longer aligned with the freezer, which is only possible
+		// if the state histories were reset. Drop it and index from scratch.
+		if m.tail > m.head || m.tail < tail || m.head > head {
+			rawdb.DeleteStateHistoryIndex(db.diskdb)
+			log.Warn("Deleted misaligned state history index", "tail", m.tail, "head", m.head, "historytail", tail, "historyhead", head)
+		}
+	}
+	db.indexer = newHistoryIndexer(db)
+	return nil
+}
@@ -0,0 +1,246 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pathdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// HistoricalStateReader provides access to the state at a historical point,
+// which is already merged into the persistent state. The state is resolved
+// from the state histories with the help of the state history index.
+type HistoricalStateReader struct {
+	db   *Database
+	root common.Hash // The root of the historical state
+	id   uint64      // The id of the historical state
+}
+
+// HistoricReader constructs a reader for accessing the requested historic state.
+// The state must be within the range of retained state histories, and at or below
+// the persistent state.
+func (db *Database) HistoricReader(root common.Hash) (*HistoricalStateReader, error) {
+	if !db.config.EnableStateIndexing {
+		return nil, errStateIndexDisabled
+	}
+	if db.freezer == nil || db.isVerkle {
+		return nil, errors.New("historical state access is not supported")
+	}
+	id := rawdb.ReadStateID(db.diskdb, root)
+	if id == nil {
+		return nil, fmt.Errorf("state %#x is not available", root)
+	}
+	r := &HistoricalStateReader{db: db, root: root, id: *id}
+	dl, err := r.disk()
+	if err != nil {
+		return nil, err
+	}
+	// The root->id mappings are not removed if the state histories are reset,
+	// ensure the mapping still refers to the state which has the given id.
+	if *id == dl.stateID() {
+		if dl.rootHash() != root {
+			return nil, fmt.Errorf("state %#x is not available", root)
+		}
+	} else {
+		var m meta
+		if err := m.decode(rawdb.ReadStateHistoryMeta(db.freezer, *id+1)); err != nil {
+			return nil, err
+		}
+		if m.parent != root {
+			return nil, fmt.Errorf("state %#x is not available", root)
+		}
+	}
+	return r, nil
+}
+
+// disk returns the current disk layer, ensuring the state histories between the
+// requested state and the disk layer are all indexed.
+func (r *HistoricalStateReader) disk() (*diskLayer, error) {
+	dl := r.db.tree.bottom()
+	if r.id > dl.stateID() {
+		return nil, fmt.Errorf("state %#x is not persisted yet", r.root)
+	}
+	m := loadIndexMeta(r.db.diskdb)
+	if m == nil || r.id < m.tail || m.head < dl.stateID() {
+		return nil, errStateNotIndexed
+	}
+	return dl, nil
+}
+
+// AccountRLP retrieves the account associated with a particular address in the
+// slim data format. An empty blob is returned if the account didn't exist.
+func (r *HistoricalStateReader) AccountRLP(address common.Address) ([]byte, error) {
+	dl, err := r.disk()
+	if err != nil {
+		return nil, err
+	}
+	// The account is resolved from the first state history after the requested
+	// state which mutates it, or the persistent state if there is none.
+	id, found := rawdb.ReadAccountHistoryIndex(r.db.diskdb, address, r.id+1)
+	if found && id <= dl.stateID() {
+		return readAccountFromHistory(r.db.freezer, id, address)
+	}
+	return r.latestAccount(dl, address)
+}
+
+// Account retrieves the account associated with a particular address. Nil is
+// returned if the account didn't exist.
+func (r *HistoricalStateReader) Account(address common.Address) (*types.SlimAccount, error) {
+	blob, err := r.AccountRLP(address)
+	if err != nil {
+		return nil, err
+	}
+	if len(blob) == 0 {
+		return nil, nil
+	}
+	account := new(types.SlimAccount)
+	if err := rlp.DecodeBytes(blob, account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// Storage retrieves the storage slot associated with a particular address and
+// slot hash, in the RLP-encoded format. An empty blob is returned if the slot
+// didn't exist.
+func (r *HistoricalStateReader) Storage(address common.Address, slot common.Hash) ([]byte, error) {
+	dl, err := r.disk()
+	if err != nil {
+		return nil, err
+	}
+	id, found := rawdb.ReadStorageHistoryIndex(r.db.diskdb, address, slot, r.id+1)
+	if found && id <= dl.stateID() {
+		return readStorageFromHistory(r.db.freezer, id, address, slot)
+	}
+	// The storage slot is not mutated since the requested state, resolve it
+	// from the storage trie of the persistent state.
+	blob, err := r.latestAccount(dl, address)
+	if err != nil || len(blob) == 0 {
+		return nil, err
+	}
+	account, err := types.FullAccount(blob)
+	if err != nil {
+		return nil, err
+	}
+	if account.Root == types.EmptyRootHash {
+		return nil, nil
+	}
+	tr, err := trie.New(trie.StorageTrieID(dl.rootHash(), crypto.Keccak256Hash(address.Bytes()), account.Root), r.db)
+	if err != nil {
+		return nil, err
+	}
+	return tr.Get(slot.Bytes())
+}
+
+// latestAccount retrieves the account from the account trie of the disk layer,
+// converted into the slim data format.
+func (r *HistoricalStateReader) latestAccount(dl *diskLayer, address common.Address) ([]byte, error) {
+	tr, err := trie.New(trie.StateTrieID(dl.rootHash()), r.db)
+	if err != nil {
+		return nil, err
+	}
+	blob, err := tr.Get(crypto.Keccak256(address.Bytes()))
+	if err != nil || len(blob) == 0 {
+		return nil, err
+	}
+	account, err := types.FullAccount(blob)
+	if err != nil {
+		return nil, err
+	}
+	return types.SlimAccountRLP(*account), nil
+}
+
+// findAccountIndex locates the index of the specified account in the state
+// history with the given id.
+func findAccountIndex(freezer ethdb.AncientReader, id uint64, address common.Address) (*accountIndex, error) {
+	blob := rawdb.ReadStateAccountIndex(freezer, id)
+	if len(blob)%accountIndexSize != 0 {
+		return nil, fmt.Errorf("invalid account index length of history %d: %d", id, len(blob))
+	}
+	n := len(blob) / accountIndexSize
+	pos := sort.Search(n, func(i int) bool {
+		return bytes.Compare(blob[i*accountIndexSize:i*accountIndexSize+common.AddressLength], address.Bytes()) >= 0
+	})
+	if pos == n {
+		return nil, fmt.Errorf("account %#x is not found in history %d", address, id)
+	}
+	var index accountIndex
+	index.decode(blob[pos*accountIndexSize : (pos+1)*accountIndexSize])
+	if index.address != address {
+		return nil, fmt.Errorf("account %#x is not found in history %d", address, id)
+	}
+	return &index, nil
+}
+
+// readAccountFromHistory retrieves the original value of the account recorded
+// in the state history with the given id.
+func readAccountFromHistory(freezer ethdb.AncientReader, id uint64, address common.Address) ([]byte, error) {
+	index, err := findAccountIndex(freezer, id, address)
+	if err != nil {
+		return nil, err
+	}
+	data := rawdb.ReadStateAccountHistory(freezer, id)
+	end := uint64(index.offset) + uint64(index.length)
+	if end > uint64(len(data)) {
+		return nil, fmt.Errorf("account data out of range of history %d, end: %d, total: %d", id, end, len(data))
+	}
+	return common.CopyBytes(data[index.offset:end]), nil
+}
+
+// readStorageFromHistory retrieves the original value of the storage slot
+// recorded in the state history with the given id.
+func readStorageFromHistory(freezer ethdb.AncientReader, id uint64, address common.Address, slot common.Hash) ([]byte, error) {
+	index, err := findAccountIndex(freezer, id, address)
+	if err != nil {
+		return nil, err
+	}
+	blob := rawdb.ReadStateStorageIndex(freezer, id)
+	start, end := uint64(index.storageOffset)*slotIndexSize, uint64(index.storageOffset+index.storageSlots)*slotIndexSize
+	if end > uint64(len(blob)) {
+		return nil, fmt.Errorf("storage index out of range of history %d, end: %d, total: %d", id, end, len(blob))
+	}
+	blob = blob[start:end]
+
+	n := int(index.storageSlots)
+	pos := sort.Search(n, func(i int) bool {
+		return bytes.Compare(blob[i*slotIndexSize:i*slotIndexSize+common.HashLength], slot.Bytes()) >= 0
+	})
+	if pos == n {
+		return nil, fmt.Errorf("storage %#x %#x is not found in history %d", address, slot, id)
+	}
+	var sIndex slotIndex
+	sIndex.decode(blob[pos*slotIndexSize : (pos+1)*slotIndexSize])
+	if sIndex.hash != slot {
+		return nil, fmt.Errorf("storage %#x %#x is not found in history %d", address, slot, id)
+	}
+	data := rawdb.ReadStateStorageHistory(freezer, id)
+	dataEnd := uint64(sIndex.offset) + uint64(sIndex.length)
+	if dataEnd > uint64(len(data)) {
+		return nil, fmt.Errorf("storage data out of range of history %d, end: %d, total: %d", id, dataEnd, len(data))
+	}
+	return common.CopyBytes(data[sIndex.offset:dataEnd]), nil
+}
@@ -0,0 +1,243 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pathdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// verifyHistoricState checks all the known accounts and storage slots of the
+// tester against the historic reader of the given state.
+func (t *tester) verifyHistoricState(root common.Hash, accounts map[common.Hash][]byte, storages map[common.Hash]map[common.Hash][]byte) error {
+	reader, err := t.db.HistoricReader(root)
+	if err != nil {
+		return err
+	}
+	for addrHash, addr := range t.preimages {
+		blob, err := reader.AccountRLP(addr)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(blob, accounts[addrHash]) {
+			return fmt.Errorf("account %x is mismatched, want: %x, got: %x", addr, accounts[addrHash], blob)
+		}
+	}
+	for addrHash, slots := range storages {
+		for slot, want := range slots {
+			blob, err := reader.Storage(t.preimages[addrHash], slot)
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(blob, want) {
+				return fmt.Errorf("storage %x %x is mismatched, want: %x, got: %x", t.preimages[addrHash], slot, want, blob)
+			}
+		}
+	}
+	return nil
+}
+
+// waitIndexing blocks until the background indexer has indexed all the state
+// histories in the freezer.
+func (t *tester) waitIndexing() error {
+	for i := 0; i < 1000; i++ {
+		head, err := t.db.freezer.Ancients()
+		if err != nil {
+			return err
+		}
+		if m := loadIndexMeta(t.db.diskdb); m != nil && m.head == head {
+			return nil
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return errors.New("state history indexing timed out")
+}
+
+// verifyHistoricStates checks the historic states from the given index up to
+// the disk layer.
+func (t *tester) verifyHistoricStates(from int) error {
+	bottom := t.bottomIndex()
+	for i := from; i <= bottom; i++ {
+		root := t.roots[i]
+		accounts, storages := t.snapAccounts[root], t.snapStorages[root]
+		if i == len(t.roots)-1 {
+			accounts, storages = t.accounts, t.storages
+		}
+		if err := t.verifyHistoricState(root, accounts, storages); err != nil {
+			return fmt.Errorf("state %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func TestHistoricReader(t *testing.T) {
+	// Redefine the diff layer depth allowance for faster testing.
+	maxDiffLayers = 4
+	defer func() {
+		maxDiffLayers = 128
+	}()
+
+	tester := newTester(t, 0)
+	defer tester.release()
+
+	// Historic states are not accessible without the index.
+	if _, err := tester.db.HistoricReader(tester.roots[0]); !errors.Is(err, errStateIndexDisabled) {
+		t.Fatalf("Unexpected error, want: %v, got: %v", errStateIndexDisabled, err)
+	}
+	// Reopen the database with indexing enabled, the existing state histories
+	// are expected to be indexed.
+	if err := tester.db.Commit(tester.lastHash(), false); err != nil {
+		t.Fatalf("Failed to commit state, err: %v", err)
+	}
+	tester.db.Close()
+	tester.db = New(tester.db.diskdb, &Config{EnableStateIndexing: true}, false)
+	if err := tester.waitIndexing(); err != nil {
+		t.Fatal(err)
+	}
+	bottom := tester.bottomIndex()
+	if err := tester.verifyHistoricStates(0); err != nil {
+		t.Fatal(err)
+	}
+	// The empty state before the first state transition is accessible as well.
+	reader, err := tester.db.HistoricReader(types.EmptyRootHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, addr := range tester.preimages {
+		if blob, err := reader.AccountRLP(addr); err != nil || len(blob) != 0 {
+			t.Fatalf("Unexpected account %x in empty state: %x, %v", addr, blob, err)
+		}
+	}
+	// Truncate the state histories from the tail, the states below the new
+	// tail are no longer accessible.
+	tester.db.indexLock.Lock()
+	_, err = truncateFromTail(tester.db.diskdb, tester.db.freezer, uint64(bottom/2))
+	tester.db.indexLock.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tester.db.HistoricReader(tester.roots[bottom/2-1]); err == nil {
+		t.Fatal("Expected error for truncated state")
+	}
+	if err := tester.verifyHistoricStates(bottom / 2); err != nil {
+		t.Fatal(err)
+	}
+	it := tester.db.diskdb.NewIterator(rawdb.StateHistoryAccountIndexPrefix, nil)
+	defer it.Release()
+	for it.Next() {
+		if binary.BigEndian.Uint64(it.Key()[len(it.Key())-8:]) <= uint64(bottom/2) {
+			t.Fatalf("Unexpected index entry of truncated history: %x", it.Key())
+		}
+	}
+}
+
+func TestHistoricReaderIndexUpdate(t *testing.T) {
+	// Redefine the diff layer depth allowance for faster testing.
+	maxDiffLayers = 4
+	defer func() {
+		maxDiffLayers = 128
+	}()
+
+	tester := newTester(t, 0)
+	defer tester.release()
+
+	if err := tester.db.Commit(tester.lastHash(), false); err != nil {
+		t.Fatalf("Failed to commit state, err: %v", err)
+	}
+	tester.db.Close()
+	tester.db = New(tester.db.diskdb, &Config{EnableStateIndexing: true}, false)
+
+	// Extend the chain, the new state histories are indexed in the background
+	// once they are written into the freezer.
+	for i := 0; i < 8; i++ {
+		parent := tester.lastHash()
+		root, nodes, states := tester.generate(parent)
+		if err := tester.db.Update(root, parent, uint64(len(tester.roots)), nodes, states); err != nil {
+			t.Fatalf("Failed to update state changes, err: %v", err)
+		}
+		tester.roots = append(tester.roots, root)
+	}
+	if err := tester.db.Commit(tester.lastHash(), false); err != nil {
+		t.Fatalf("Failed to commit state, err: %v", err)
+	}
+	if err := tester.waitIndexing(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tester.verifyHistoricStates(0); err != nil {
+		t.Fatal(err)
+	}
+	// Rollback the database, the index of the reverted state histories is
+	// removed along with them.
+	target := len(tester.roots) - 4
+	if err := tester.db.Recover(tester.roots[target]); err != nil {
+		t.Fatalf("Failed to revert db, err: %v", err)
+	}
+	if m := loadIndexMeta(tester.db.diskdb); m == nil || m.head != uint64(target+1) {
+		t.Fatalf("Unexpected index metadata: %v", m)
+	}
+	if _, err := tester.db.HistoricReader(tester.lastHash()); err == nil {
+		t.Fatal("Expected error for reverted state")
+	}
+	// Disabling the indexing deletes the index.
+	tester.db.Close()
+	tester.db = New(tester.db.diskdb, nil, false)
+	if m := loadIndexMeta(tester.db.diskdb); m != nil {
+		t.Fatalf("Unexpected index metadata: %v", m)
+	}
+	it := tester.db.diskdb.NewIterator(rawdb.StateHistoryAccountIndexPrefix, nil)
+	defer it.Release()
+	if it.Next() {
+		t.Fatalf("Unexpected index entry: %x", it.Key())
+	}
+}
+
+func TestHistoryIndexerClose(t *testing.T) {
+	// Redefine the diff layer depth allowance for faster testing.
+	maxDiffLayers = 4
+	defer func() {
+		maxDiffLayers = 128
+	}()
+
+	tester := newTester(t, 0)
+	defer tester.release()
+
+	if err := tester.db.Commit(tester.lastHash(), false); err != nil {
+		t.Fatalf("Failed to commit state, err: %v", err)
+	}
+	tester.db.Close()
+
+	// Closing the database terminates the indexer, the interrupted indexing
+	// is resumed from where it's stopped after the restart.
+	tester.db = New(tester.db.diskdb, &Config{EnableStateIndexing: true}, false)
+	tester.db.Close()
+
+	tester.db = New(tester.db.diskdb, &Config{EnableStateIndexing: true}, false)
+	if err := tester.waitIndexing(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tester.verifyHistoricStates(0); err != nil {
+		t.Fatal(err)
+	}
+}
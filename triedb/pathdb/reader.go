@@ -24,6 +24,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie/heatmap"
 	"github.com/ethereum/go-ethereum/triedb/database"
 )
 
@@ -42,6 +43,20 @@ type nodeLoc struct {
 	depth int
 }
 
+// source returns the location in the form of the access sampling.
+func (loc *nodeLoc) source() heatmap.Source {
+	switch loc.loc {
+	case locDirtyCache:
+		return heatmap.SourceDirty
+	case locCleanCache:
+		return heatmap.SourceClean
+	case locDiffLayer:
+		return heatmap.SourceDiff
+	default:
+		return heatmap.SourceDisk
+	}
+}
+
 // string returns the string representation of node location.
 func (loc *nodeLoc) string() string {
 	return fmt.Sprintf("loc: %s, depth: %d", loc.loc, loc.depth)
@@ -62,6 +77,9 @@ func (r *reader) Node(owner common.Hash, path []byte, hash common.Hash) ([]byte,
 	if err != nil {
 		return nil, err
 	}
+	if heatmap.Enabled() {
+		heatmap.RecordSource(len(path), loc.source())
+	}
 	// Error out if the local one is inconsistent with the target.
 	if !r.noHashCheck && got != hash {
 		// Location is always available even if the node